@@ -57,6 +57,19 @@ type PolicyEnforcerOptions struct {
 	Parameters runtime.RawExtension `json:"parameters,omitempty"`
 }
 
+type CacheOptions struct {
+	// TTL is the duration to cache succeeded validation results and resolved
+	// digests, e.g. "10m". The server default is used if not set. Optional.
+	// +optional
+	TTL string `json:"ttl,omitempty"`
+
+	// FailureTTL is the duration to cache failed validation results and
+	// validation errors. If not set, failed results are cached for TTL and
+	// errors are not cached. Optional.
+	// +optional
+	FailureTTL string `json:"failureTTL,omitempty"`
+}
+
 // ExecutorSpec defines the desired state of Executor.
 type ExecutorSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
@@ -80,6 +93,30 @@ type ExecutorSpec struct {
 	// PolicyEnforcer contains the configuration options for the policy
 	// enforcer. Optional.
 	PolicyEnforcer *PolicyEnforcerOptions `json:"policyEnforcer,omitempty"`
+
+	// IndexMode defines how subjects that are image indexes or manifest lists
+	// are validated. Defaults to index-only. Optional.
+	// +kubebuilder:validation:Enum=index-only;children-all;children-any;platform
+	// +optional
+	IndexMode string `json:"indexMode,omitempty"`
+
+	// Layered evaluates the executor also for artifacts routed to executors of
+	// more specific scopes. An artifact passes only if every executor
+	// evaluated for it passes. Layered executors can share their scopes with
+	// each other and with one executor that is not layered. Optional.
+	// +optional
+	Layered bool `json:"layered,omitempty"`
+
+	// AuditOnly runs the full validation and reports its outcome, but does not
+	// fail artifacts the executor rejects. They are flagged as would have
+	// failed and logged instead. Optional.
+	// +optional
+	AuditOnly bool `json:"auditOnly,omitempty"`
+
+	// Cache overrides the server cache durations of validation results for
+	// artifacts under the scopes. Optional.
+	// +optional
+	Cache *CacheOptions `json:"cache,omitempty"`
 }
 
 // ExecutorStatus defines the observed state of Executor.
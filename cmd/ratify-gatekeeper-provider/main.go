@@ -18,15 +18,26 @@ package main
 import (
 	"errors"
 	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/notaryproject/ratify/v2/internal/audit"
 	"github.com/notaryproject/ratify/v2/internal/httpserver"
+	"github.com/notaryproject/ratify/v2/internal/logger"
 	"github.com/notaryproject/ratify/v2/internal/manager"
+	"github.com/notaryproject/ratify/v2/internal/tracing"
 	"github.com/sirupsen/logrus"
 )
 
 var startManagerFunc = manager.StartManager
 
+// redisPasswordEnv is the environment variable holding the password of the
+// Redis server, so that it is not exposed in the command line.
+const redisPasswordEnv = "RATIFY_REDIS_PASSWORD"
+
 // main is the entry point for the Ratify server.
 func main() {
 	if err := startRatify(parse()); err != nil {
@@ -36,29 +47,107 @@ func main() {
 }
 
 type options struct {
-	configFilePath       string
-	httpServerAddress    string
-	certFile             string
-	keyFile              string
-	gatekeeperCACertFile string
-	disableCertRotation  bool
-	disableMutation      bool
-	disableCRDManager    bool
-	verifyTimeout        time.Duration
-	mutateTimeout        time.Duration
+	configFilePath         string
+	httpServerAddress      string
+	healthProbeAddress     string
+	metricsAddress         string
+	certFile               string
+	keyFile                string
+	gatekeeperCACertFile   string
+	clientAllowlistFile    string
+	adminTokenFile         string
+	disableCertRotation    bool
+	disableMutation        bool
+	defaultPlatform        string
+	responseSchema         string
+	unmatchedScope         string
+	disableCRDManager      bool
+	verifyTimeout          time.Duration
+	mutateTimeout          time.Duration
+	drainGracePeriod       time.Duration
+	drainTimeout           time.Duration
+	maxConcurrentKeys      int
+	maxRequestBodyBytes    int64
+	maxKeys                int
+	maxInFlight            int
+	maxInFlightPerRegistry int
+	registryInFlightLimits string
+	maxQueued              int
+	responseReserve        time.Duration
+	cacheRefreshWindow     time.Duration
+	cacheMaxStaleness      time.Duration
+	prewarmFile            string
+	prewarmReferences      string
+	prewarmPodImages       bool
+	prewarmConcurrency     int
+	prewarmReadyPercent    int
+	tracing                tracing.Options
+	audit                  audit.Options
+	cache                  httpserver.CacheOptions
+	logFormatter           string
+	traceIDHeaders         string
 }
 
 func parse() *options {
 	opts := &options{}
 	flag.StringVar(&opts.configFilePath, "config", "", "Path to the Ratify configuration file")
 	flag.StringVar(&opts.httpServerAddress, "address", "", "HTTP server address")
+	flag.StringVar(&opts.healthProbeAddress, "health-probe-address", "", "Plain HTTP address serving the liveness and readiness endpoints, e.g. :9090. Disabled if not set")
+	flag.StringVar(&opts.metricsAddress, "metrics-address", "", "Plain HTTP address serving the Prometheus metrics at /metrics, e.g. :8888. Disabled if not set")
 	flag.StringVar(&opts.certFile, "cert-file", "", "Path to the TLS certificate file")
 	flag.StringVar(&opts.keyFile, "key-file", "", "Path to the TLS key file")
 	flag.StringVar(&opts.gatekeeperCACertFile, "gatekeeper-ca-cert-file", "", "Path to the Gatekeeper CA certificate file")
+	flag.StringVar(&opts.clientAllowlistFile, "client-allowlist-file", "", "Path to the JSON file of the client certificate common names, DNS names and SPIFFE IDs authorized to call the server. Requires -gatekeeper-ca-cert-file. All verified clients are accepted if not set")
+	flag.StringVar(&opts.adminTokenFile, "admin-token-file", "", "Path to the file containing the bearer token for the admin endpoints. Admin endpoints are disabled if not set")
 	flag.DurationVar(&opts.verifyTimeout, "verify-timeout", 5*time.Second, "Verification timeout duration (e.g. 5s, 1m), default is 5 seconds")
 	flag.DurationVar(&opts.mutateTimeout, "mutate-timeout", 2*time.Second, "Mutation timeout duration (e.g. 5s, 1m), default is 2 seconds")
+	flag.DurationVar(&opts.drainGracePeriod, "drain-grace-period", 0, "Duration the server keeps serving requests while reporting not ready after receiving SIGTERM, e.g. 5s. The server drains immediately if not set")
+	flag.DurationVar(&opts.drainTimeout, "drain-timeout", 0, "Maximum duration to wait for in-flight requests to complete after the drain grace period. Default is the verify timeout")
+	flag.IntVar(&opts.maxConcurrentKeys, "max-concurrent-keys", 8, "Maximum number of keys validated concurrently within a single request, default is 8")
+	flag.Int64Var(&opts.maxRequestBodyBytes, "max-request-body-bytes", 2<<20, "Maximum size of a request body in bytes, larger requests are rejected with 413, default is 2 MiB")
+	flag.IntVar(&opts.maxKeys, "max-keys", 0, "Maximum number of keys in a verify or mutate request, requests with more keys are rejected with 413. Unlimited if not set")
+	flag.IntVar(&opts.maxInFlight, "max-in-flight", 0, "Maximum number of validations and resolutions running concurrently across all requests. Unlimited if not set")
+	flag.IntVar(&opts.maxInFlightPerRegistry, "max-in-flight-per-registry", 0, "Maximum number of validations and resolutions running concurrently against a single registry. Unlimited if not set")
+	flag.StringVar(&opts.registryInFlightLimits, "registry-in-flight-limits", "", "Comma-separated per-registry overrides of -max-in-flight-per-registry, e.g. docker.io=4,ghcr.io=16")
+	flag.IntVar(&opts.maxQueued, "max-queued", 0, "Maximum number of validations and resolutions waiting for an in-flight slot, further ones are rejected with a retryable OVERLOADED error. Validations never wait if not set")
+	flag.DurationVar(&opts.responseReserve, "response-reserve", 200*time.Millisecond, "Duration reserved before the request deadline to write the response, default is 200 milliseconds")
+	flag.DurationVar(&opts.cacheRefreshWindow, "verify-cache-refresh-window", 0, "Duration before the expiry of a cached verification result within which a read triggers a background re-validation, e.g. 10s. Disabled if not set")
+	flag.DurationVar(&opts.cacheMaxStaleness, "verify-cache-max-staleness", 0, "Duration after the expiry of a cached verification result within which it is still served while re-validated in the background, e.g. 30s. Expired results are never served if not set")
+	flag.StringVar(&opts.prewarmFile, "prewarm-file", "", "Path to a file of artifact references, one per line, validated in the background whenever an executor is loaded to pre-warm the caches")
+	flag.StringVar(&opts.prewarmReferences, "prewarm-references", "", "Comma-separated artifact references to pre-warm, e.g. registry.example.com/app:v1")
+	flag.BoolVar(&opts.prewarmPodImages, "prewarm-pod-images", false, "Pre-warm the caches with the images of the running Pods of the cluster")
+	flag.IntVar(&opts.prewarmConcurrency, "prewarm-concurrency", 0, "Number of references pre-warmed concurrently, default is 4")
+	flag.IntVar(&opts.prewarmReadyPercent, "prewarm-ready-percent", 0, "Percentage of references the initial pre-warm run must process before the server reports ready. Readiness does not wait for pre-warming if not set")
+	flag.StringVar(&opts.tracing.Exporter, "tracing-exporter", "", "OpenTelemetry trace exporter, either otlp or file. Tracing is disabled if not set")
+	flag.StringVar(&opts.tracing.Endpoint, "tracing-endpoint", "", "Host and port of the OTLP/HTTP trace collector, e.g. localhost:4318")
+	flag.BoolVar(&opts.tracing.Insecure, "tracing-insecure", false, "Disable TLS when exporting traces to the OTLP collector")
+	flag.StringVar(&opts.tracing.FilePath, "tracing-file", "", "Path of the file traces are written to by the file exporter")
+	flag.Float64Var(&opts.tracing.SampleRatio, "tracing-sample-ratio", 1, "Ratio of new traces to sample, between 0 and 1, default is 1")
+	flag.StringVar(&opts.audit.FilePath, "audit-file", "", "Path of the file verification decisions are appended to as JSON lines. The file audit sink is disabled if not set")
+	flag.Int64Var(&opts.audit.FileMaxSizeBytes, "audit-file-max-size-bytes", 0, "Size in bytes after which the audit file is rotated. Not rotated by size if not set")
+	flag.DurationVar(&opts.audit.FileRotationInterval, "audit-file-rotation-interval", 0, "Interval after which the audit file is rotated, e.g. 24h. Not rotated by time if not set")
+	flag.IntVar(&opts.audit.FileMaxBackups, "audit-file-max-backups", 0, "Number of rotated audit files to keep. All rotated files are kept if not set")
+	flag.StringVar(&opts.audit.WebhookURL, "audit-webhook-url", "", "URL verification decisions are posted to in batches. The webhook audit sink is disabled if not set")
+	flag.IntVar(&opts.audit.WebhookBatchSize, "audit-webhook-batch-size", 0, "Maximum number of audit records per webhook request, default is 100")
+	flag.DurationVar(&opts.audit.WebhookFlushInterval, "audit-webhook-flush-interval", 0, "Maximum time an audit record waits for a webhook batch to fill up, default is 1 second")
+	flag.IntVar(&opts.audit.WebhookMaxRetries, "audit-webhook-max-retries", 0, "Number of times a failed webhook request is retried with exponential backoff, default is 3")
+	flag.DurationVar(&opts.audit.WebhookTimeout, "audit-webhook-timeout", 0, "Timeout of each audit webhook request, default is 5 seconds")
+	flag.IntVar(&opts.audit.BufferSize, "audit-buffer-size", 0, "Number of audit records buffered per sink, records are dropped if the buffer is full, default is 1024")
+	flag.StringVar(&opts.cache.VerifyBackend, "verify-cache-backend", "", "Backend of the verify cache, either memory or redis, default is memory")
+	flag.StringVar(&opts.cache.MutateBackend, "mutate-cache-backend", "", "Backend of the mutate cache, either memory or redis, default is memory")
+	flag.StringVar(&opts.cache.CredentialBackend, "credential-cache-backend", "", "Backend of the registry credential cache, either memory or redis, default is memory")
+	flag.StringVar(&opts.cache.Redis.Address, "redis-address", "", "Host and port of the Redis server backing the redis caches. The password is read from the "+redisPasswordEnv+" environment variable")
+	flag.StringVar(&opts.cache.Redis.Username, "redis-username", "", "Username used to authenticate with the Redis server")
+	flag.IntVar(&opts.cache.Redis.DB, "redis-db", 0, "Redis database of the caches, default is 0")
+	flag.BoolVar(&opts.cache.Redis.TLS, "redis-tls", false, "Enable TLS for the connections to the Redis server")
+	flag.StringVar(&opts.cache.RedisNamespace, "redis-namespace", "", "Prefix of the keys stored in Redis, shared by all replicas, default is ratify")
+	flag.StringVar(&opts.logFormatter, "log-formatter", "text", "Log formatter, one of text, json or logstash, default is text")
+	flag.StringVar(&opts.traceIDHeaders, "trace-id-headers", "", "Comma-separated names of the request headers carrying the trace ID of a request. The trace ID is also returned in these response headers")
 	flag.BoolVar(&opts.disableCertRotation, "disable-cert-rotation", false, "Disable certificate rotation")
 	flag.BoolVar(&opts.disableMutation, "disable-mutation", false, "Disable mutation wehbook")
+	flag.StringVar(&opts.defaultPlatform, "default-platform", "", "Platform in the format os/arch[/variant] used if a verify or mutate key has no platform hint, e.g. linux/amd64. Image index tags are resolved to the index digest if not set")
+	flag.StringVar(&opts.responseSchema, "response-schema", "v1", "Schema of the verify item values, either v1 or v2. The v2 schema adds verifier types and durations, the resolved digest, cache provenance and the matched scope, and renders verifier details as JSON objects, default is v1")
+	flag.StringVar(&opts.unmatchedScope, "unmatched-scope", "", "Decision for artifacts that match no executor scope, one of error, deny or allow. error fails the validation, deny reports the artifact as not succeeded and allow reports it as succeeded without validation, default is error")
 	flag.BoolVar(&opts.disableCRDManager, "disable-crd-manager", false, "Disable CRD manager for Gatekeeper provider")
 
 	flag.Parse()
@@ -70,22 +159,109 @@ func startRatify(opts *options) error {
 	if len(opts.httpServerAddress) == 0 {
 		return errors.New("HTTP server address is required")
 	}
+	if err := logger.InitLogConfig(logger.Config{
+		Formatter:      opts.logFormatter,
+		RequestHeaders: logRequestHeaders(opts.traceIDHeaders),
+	}); err != nil {
+		return fmt.Errorf("failed to initialize log config: %w", err)
+	}
+	registryLimits, err := parseRegistryLimits(opts.registryInFlightLimits)
+	if err != nil {
+		return err
+	}
+	cacheOpts := opts.cache
+	cacheOpts.Redis.Password = os.Getenv(redisPasswordEnv)
 	var certRotatorReady chan struct{}
 	if !opts.disableCertRotation {
 		certRotatorReady = make(chan struct{})
 	}
 	serverOpts := &httpserver.ServerOptions{
-		HTTPServerAddress:    opts.httpServerAddress,
-		CertFile:             opts.certFile,
-		KeyFile:              opts.keyFile,
-		GatekeeperCACertFile: opts.gatekeeperCACertFile,
-		VerifyTimeout:        opts.verifyTimeout,
-		MutateTimeout:        opts.mutateTimeout,
-		DisableMutation:      opts.disableMutation,
-		DisableCRDManager:    opts.disableCRDManager,
-		CertRotatorReady:     certRotatorReady,
+		HTTPServerAddress:        opts.httpServerAddress,
+		HealthProbeAddress:       opts.healthProbeAddress,
+		MetricsAddress:           opts.metricsAddress,
+		CertFile:                 opts.certFile,
+		KeyFile:                  opts.keyFile,
+		GatekeeperCACertFile:     opts.gatekeeperCACertFile,
+		ClientAllowlistFile:      opts.clientAllowlistFile,
+		AdminTokenFile:           opts.adminTokenFile,
+		VerifyTimeout:            opts.verifyTimeout,
+		MutateTimeout:            opts.mutateTimeout,
+		DrainGracePeriod:         opts.drainGracePeriod,
+		DrainTimeout:             opts.drainTimeout,
+		MaxConcurrentKeys:        opts.maxConcurrentKeys,
+		ResponseReserve:          opts.responseReserve,
+		VerifyCacheRefreshWindow: opts.cacheRefreshWindow,
+		VerifyCacheMaxStaleness:  opts.cacheMaxStaleness,
+		PrewarmFile:              opts.prewarmFile,
+		PrewarmReferences:        splitList(opts.prewarmReferences),
+		PrewarmPodImages:         opts.prewarmPodImages,
+		PrewarmConcurrency:       opts.prewarmConcurrency,
+		PrewarmReadyPercent:      opts.prewarmReadyPercent,
+		MaxRequestBodyBytes:      opts.maxRequestBodyBytes,
+		MaxKeys:                  opts.maxKeys,
+		MaxInFlight:              opts.maxInFlight,
+		MaxInFlightPerRegistry:   opts.maxInFlightPerRegistry,
+		RegistryInFlightLimits:   registryLimits,
+		MaxQueued:                opts.maxQueued,
+		DisableMutation:          opts.disableMutation,
+		DefaultPlatform:          opts.defaultPlatform,
+		ResponseSchema:           opts.responseSchema,
+		UnmatchedScope:           opts.unmatchedScope,
+		DisableCRDManager:        opts.disableCRDManager,
+		CertRotatorReady:         certRotatorReady,
+		Tracing:                  opts.tracing,
+		Audit:                    opts.audit,
+		Cache:                    cacheOpts,
 	}
 
-	go startManagerFunc(certRotatorReady, serverOpts.DisableMutation, serverOpts.DisableCRDManager)
+	go startManagerFunc(certRotatorReady, serverOpts.DisableMutation, serverOpts.DisableCRDManager, serverOpts.PrewarmPodImages)
 	return httpserver.StartServer(serverOpts, opts.configFilePath)
 }
+
+// logRequestHeaders converts the comma-separated trace ID header names to the
+// request headers of the log config.
+func logRequestHeaders(traceIDHeaders string) map[string]any {
+	names := splitList(traceIDHeaders)
+	if len(names) == 0 {
+		return nil
+	}
+	return map[string]any{
+		"traceIDHeaderName": names,
+	}
+}
+
+// splitList splits the comma-separated value into its non-empty, trimmed
+// elements.
+func splitList(value string) []string {
+	var elements []string
+	for _, element := range strings.Split(value, ",") {
+		if element = strings.TrimSpace(element); element != "" {
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+// parseRegistryLimits parses the comma-separated registry=limit pairs of the
+// per-registry in-flight limits.
+func parseRegistryLimits(value string) (map[string]int, error) {
+	var limits map[string]int
+	for _, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		registry, limit, ok := strings.Cut(pair, "=")
+		if !ok || registry == "" {
+			return nil, fmt.Errorf("invalid registry in-flight limit %q, expected registry=limit", pair)
+		}
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid in-flight limit %q of registry %s, expected a positive integer", limit, registry)
+		}
+		if limits == nil {
+			limits = make(map[string]int)
+		}
+		limits[registry] = n
+	}
+	return limits, nil
+}
@@ -21,6 +21,8 @@ import (
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/notaryproject/ratify/v2/internal/tracing"
 )
 
 func TestMain_FailedStartingRatify(t *testing.T) {
@@ -61,12 +63,18 @@ func TestParse(t *testing.T) {
 				"-verify-timeout=10s",
 			},
 			expected: &options{
-				configFilePath:    "config.json",
-				httpServerAddress: ":8080",
-				certFile:          "cert.pem",
-				keyFile:           "key.pem",
-				verifyTimeout:     10 * time.Second,
-				mutateTimeout:     2 * time.Second,
+				configFilePath:      "config.json",
+				httpServerAddress:   ":8080",
+				certFile:            "cert.pem",
+				keyFile:             "key.pem",
+				verifyTimeout:       10 * time.Second,
+				mutateTimeout:       2 * time.Second,
+				maxConcurrentKeys:   8,
+				maxRequestBodyBytes: 2 << 20,
+				responseReserve:     200 * time.Millisecond,
+				tracing:             tracing.Options{SampleRatio: 1},
+				logFormatter:        "text",
+				responseSchema:      "v1",
 			},
 		},
 		{
@@ -76,16 +84,28 @@ func TestParse(t *testing.T) {
 				"-mutate-timeout=10s",
 			},
 			expected: &options{
-				verifyTimeout: 30 * time.Second,
-				mutateTimeout: 10 * time.Second,
+				verifyTimeout:       30 * time.Second,
+				mutateTimeout:       10 * time.Second,
+				maxConcurrentKeys:   8,
+				maxRequestBodyBytes: 2 << 20,
+				responseReserve:     200 * time.Millisecond,
+				tracing:             tracing.Options{SampleRatio: 1},
+				logFormatter:        "text",
+				responseSchema:      "v1",
 			},
 		},
 		{
 			name: "default values",
 			args: []string{},
 			expected: &options{
-				verifyTimeout: 5 * time.Second,
-				mutateTimeout: 2 * time.Second,
+				verifyTimeout:       5 * time.Second,
+				mutateTimeout:       2 * time.Second,
+				maxConcurrentKeys:   8,
+				maxRequestBodyBytes: 2 << 20,
+				responseReserve:     200 * time.Millisecond,
+				tracing:             tracing.Options{SampleRatio: 1},
+				logFormatter:        "text",
+				responseSchema:      "v1",
 			},
 		},
 	}
@@ -109,7 +129,7 @@ func TestParse(t *testing.T) {
 }
 
 func TestStartRatify(t *testing.T) {
-	startManagerFunc = func(_ chan struct{}, _, _ bool) {}
+	startManagerFunc = func(_ chan struct{}, _, _, _ bool) {}
 	tests := []struct {
 		name        string
 		opts        *options
@@ -125,6 +145,17 @@ func TestStartRatify(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "invalid log formatter",
+			opts: &options{
+				httpServerAddress:   ":8080",
+				configFilePath:      "config.yaml",
+				logFormatter:        "invalid",
+				disableCertRotation: true,
+				disableCRDManager:   true,
+			},
+			expectError: true,
+		},
 		{
 			name: "failed to start the server",
 			opts: &options{
@@ -147,3 +178,74 @@ func TestStartRatify(t *testing.T) {
 		})
 	}
 }
+
+func TestLogRequestHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  string
+		expected map[string]any
+	}{
+		{
+			name:     "no headers",
+			headers:  "",
+			expected: nil,
+		},
+		{
+			name:    "multiple headers",
+			headers: "x-request-id, x-trace-id,",
+			expected: map[string]any{
+				"traceIDHeaderName": []string{"x-request-id", "x-trace-id"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := logRequestHeaders(tt.headers); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("logRequestHeaders() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseRegistryLimits(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expected    map[string]int
+		expectError bool
+	}{
+		{
+			name:     "empty",
+			value:    "",
+			expected: nil,
+		},
+		{
+			name:     "multiple registries",
+			value:    "docker.io=4, ghcr.io=16,",
+			expected: map[string]int{"docker.io": 4, "ghcr.io": 16},
+		},
+		{
+			name:        "missing limit",
+			value:       "docker.io",
+			expectError: true,
+		},
+		{
+			name:        "non-positive limit",
+			value:       "docker.io=0",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRegistryLimits(tt.value)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("parseRegistryLimits() error = %v, expectError %v", err, tt.expectError)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("parseRegistryLimits() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
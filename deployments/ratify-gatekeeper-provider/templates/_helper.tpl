@@ -34,6 +34,21 @@ false
 {{- end -}}
 {{- end -}}
 
+{{/*
+Check if the client identity allowlist of the TLS listener is configured
+*/}}
+{{- define "ratify.clientAllowlistConfigured" -}}
+{{- with .Values.provider.tls.clientIdentities -}}
+{{- if or .commonNames .dnsNames .spiffeIDs -}}
+true
+{{- else -}}
+false
+{{- end -}}
+{{- else -}}
+false
+{{- end -}}
+{{- end -}}
+
 {{/*
 Check if cosign verifier is configured based on certificate identity fields
 */}}
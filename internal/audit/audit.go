@@ -0,0 +1,395 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultBufferSize           = 1024
+	defaultBatchSize            = 100
+	defaultWebhookFlushInterval = time.Second
+	defaultWebhookMaxRetries    = 3
+	defaultWebhookTimeout       = 5 * time.Second
+)
+
+// Record is the audit record of a verification decision.
+type Record struct {
+	// Time is the time the decision was made.
+	Time time.Time `json:"time"`
+
+	// Key is the key of the verify request, e.g. the image reference.
+	Key string `json:"key"`
+
+	// Subject is the artifact reference of the key.
+	Subject string `json:"subject"`
+
+	// ResolvedDigest is the digest the subject was resolved to, if known.
+	ResolvedDigest string `json:"resolvedDigest,omitempty"`
+
+	// Platform is the platform of the validated manifest, if any.
+	Platform string `json:"platform,omitempty"`
+
+	// MatchedScope is the executor scope matched by the subject, if any.
+	MatchedScope string `json:"matchedScope,omitempty"`
+
+	// Unmatched is the decision applied to a subject that does not match the
+	// scope of any executor, either "deny" or "allow", if any.
+	Unmatched string `json:"unmatched,omitempty"`
+
+	// AuditOnlyScopes are the scopes of the audit-only executors that
+	// validated the subject, if any.
+	AuditOnlyScopes []string `json:"auditOnlyScopes,omitempty"`
+
+	// WouldHaveFailed indicates that an audit-only executor rejected the
+	// subject without failing the decision.
+	WouldHaveFailed bool `json:"wouldHaveFailed,omitempty"`
+
+	// AuditOnlyError is the error of the audit-only executors that could not
+	// complete the validation, if any.
+	AuditOnlyError string `json:"auditOnlyError,omitempty"`
+
+	// CacheHit indicates that the decision was served from the cache.
+	CacheHit bool `json:"cacheHit"`
+
+	// Succeeded is the final decision.
+	Succeeded bool `json:"succeeded"`
+
+	// ErrorCode is the code of the error that failed the validation, if any.
+	ErrorCode string `json:"errorCode,omitempty"`
+
+	// Error is the message of the error that failed the validation, if any.
+	Error string `json:"error,omitempty"`
+
+	// Verifiers are the outcomes of the verifiers applied to the referrers of
+	// the subject.
+	Verifiers []VerifierOutcome `json:"verifiers,omitempty"`
+
+	// TraceID is the trace ID of the request, if any.
+	TraceID string `json:"traceID,omitempty"`
+}
+
+// VerifierOutcome is the outcome of a verifier applied to an artifact.
+type VerifierOutcome struct {
+	// Subject is the reference of the artifact the verified artifact refers
+	// to.
+	Subject string `json:"subject"`
+
+	// Artifact is the digest of the verified artifact.
+	Artifact string `json:"artifact"`
+
+	// ArtifactType is the artifact type of the verified artifact, if known.
+	ArtifactType string `json:"artifactType,omitempty"`
+
+	// Verifier is the name of the verifier.
+	Verifier string `json:"verifier"`
+
+	// VerifierType is the type of the verifier, if known.
+	VerifierType string `json:"verifierType,omitempty"`
+
+	// Succeeded indicates whether the verifier accepted the artifact.
+	Succeeded bool `json:"succeeded"`
+
+	// Error is the reason the verifier rejected the artifact, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// Sink persists audit records.
+type Sink interface {
+	// Write persists the records in order. It is called by a single goroutine
+	// per sink, so implementations do not need to be safe for concurrent use.
+	Write(ctx context.Context, records []*Record) error
+
+	// Close flushes and releases the sink.
+	Close() error
+}
+
+// Options defines the sinks of the audit log.
+type Options struct {
+	// FilePath is the path of the file records are appended to as JSON lines.
+	// The file sink is disabled if not provided.
+	// Optional.
+	FilePath string
+
+	// FileMaxSizeBytes is the size in bytes after which the file is rotated.
+	// The file is not rotated by size if not specified.
+	// Optional.
+	FileMaxSizeBytes int64
+
+	// FileRotationInterval is the interval after which the file is rotated.
+	// The file is not rotated by time if not specified.
+	// Optional.
+	FileRotationInterval time.Duration
+
+	// FileMaxBackups is the number of rotated files to keep. All rotated files
+	// are kept if not specified.
+	// Optional.
+	FileMaxBackups int
+
+	// WebhookURL is the URL records are posted to in batches as a JSON array.
+	// The webhook sink is disabled if not provided.
+	// Optional.
+	WebhookURL string
+
+	// WebhookBatchSize is the maximum number of records per request. Default
+	// is 100 if not specified.
+	// Optional.
+	WebhookBatchSize int
+
+	// WebhookFlushInterval is the maximum time a record waits for a batch to
+	// fill up. Default is 1s if not specified.
+	// Optional.
+	WebhookFlushInterval time.Duration
+
+	// WebhookMaxRetries is the number of times a failed request is retried
+	// with exponential backoff. Default is 3 if not specified.
+	// Optional.
+	WebhookMaxRetries int
+
+	// WebhookTimeout is the timeout of each request. Default is 5s if not
+	// specified.
+	// Optional.
+	WebhookTimeout time.Duration
+
+	// BufferSize is the number of records buffered per sink. Records are
+	// dropped with a warning if the buffer of a sink is full so that a slow
+	// sink never blocks admission requests. Default is 1024 if not specified.
+	// Optional.
+	BufferSize int
+}
+
+// Logger dispatches audit records to the sinks asynchronously. A nil Logger
+// discards all records.
+type Logger struct {
+	dispatchers []*dispatcher
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+
+	// mu guards closed so that records logged by requests still in flight
+	// during shutdown are dropped instead of sent to closed queues.
+	mu     sync.RWMutex
+	closed bool
+}
+
+// New creates a Logger with the sinks configured by opts. It returns nil if
+// no sink is configured.
+func New(opts Options) (*Logger, error) {
+	bufferSize := opts.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+
+	var dispatchers []*dispatcher
+	if opts.FilePath != "" {
+		sink, err := NewFileSink(opts.FilePath, opts.FileMaxSizeBytes, opts.FileRotationInterval, opts.FileMaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		dispatchers = append(dispatchers, newDispatcher("file", sink, bufferSize, defaultBatchSize, 0))
+	}
+	if opts.WebhookURL != "" {
+		batchSize := opts.WebhookBatchSize
+		if batchSize <= 0 {
+			batchSize = defaultBatchSize
+		}
+		flushInterval := opts.WebhookFlushInterval
+		if flushInterval <= 0 {
+			flushInterval = defaultWebhookFlushInterval
+		}
+		maxRetries := opts.WebhookMaxRetries
+		if maxRetries <= 0 {
+			maxRetries = defaultWebhookMaxRetries
+		}
+		timeout := opts.WebhookTimeout
+		if timeout <= 0 {
+			timeout = defaultWebhookTimeout
+		}
+		sink, err := NewWebhookSink(opts.WebhookURL, maxRetries, timeout)
+		if err != nil {
+			return nil, errors.Join(err, closeDispatchers(dispatchers))
+		}
+		dispatchers = append(dispatchers, newDispatcher("webhook", sink, bufferSize, batchSize, flushInterval))
+	}
+	if len(dispatchers) == 0 {
+		return nil, nil
+	}
+	return newLogger(dispatchers), nil
+}
+
+// NewWithSinks creates a Logger that writes each record to the sinks as soon
+// as it is logged, buffering up to bufferSize records per sink.
+func NewWithSinks(bufferSize int, sinks ...Sink) *Logger {
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+	dispatchers := make([]*dispatcher, len(sinks))
+	for idx, sink := range sinks {
+		dispatchers[idx] = newDispatcher(fmt.Sprintf("sink-%d", idx), sink, bufferSize, defaultBatchSize, 0)
+	}
+	return newLogger(dispatchers)
+}
+
+func newLogger(dispatchers []*dispatcher) *Logger {
+	ctx, cancel := context.WithCancel(context.Background())
+	l := &Logger{
+		dispatchers: dispatchers,
+		cancel:      cancel,
+	}
+	for _, d := range dispatchers {
+		l.wg.Add(1)
+		go func() {
+			defer l.wg.Done()
+			d.run(ctx)
+		}()
+	}
+	return l
+}
+
+// Log queues the record to all sinks without blocking. The record must not be
+// modified afterwards. Records logged after Close are dropped.
+func (l *Logger) Log(record *Record) {
+	if l == nil {
+		return
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.closed {
+		return
+	}
+	for _, d := range l.dispatchers {
+		select {
+		case d.queue <- record:
+		default:
+			logrus.Warnf("audit %s sink buffer is full, dropping record of %s", d.name, record.Key)
+		}
+	}
+}
+
+// Close flushes the queued records and closes the sinks. Pending writes are
+// aborted when ctx is done.
+func (l *Logger) Close(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	for _, d := range l.dispatchers {
+		close(d.queue)
+	}
+	l.mu.Unlock()
+
+	flushed := make(chan struct{})
+	go func() {
+		l.wg.Wait()
+		close(flushed)
+	}()
+	select {
+	case <-flushed:
+	case <-ctx.Done():
+		// Abort pending writes and retries.
+		l.cancel()
+		<-flushed
+	}
+	l.cancel()
+	return closeDispatchers(l.dispatchers)
+}
+
+func closeDispatchers(dispatchers []*dispatcher) error {
+	var errs []error
+	for _, d := range dispatchers {
+		if err := d.sink.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close audit %s sink: %w", d.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// dispatcher writes the records of its queue to a sink in batches.
+type dispatcher struct {
+	name          string
+	sink          Sink
+	queue         chan *Record
+	batchSize     int
+	flushInterval time.Duration
+}
+
+func newDispatcher(name string, sink Sink, bufferSize, batchSize int, flushInterval time.Duration) *dispatcher {
+	return &dispatcher{
+		name:          name,
+		sink:          sink,
+		queue:         make(chan *Record, bufferSize),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+	}
+}
+
+// run writes batches until the queue is closed and drained. A batch is
+// written once it is full, the flush interval since its first record elapsed,
+// or no more records are queued if the flush interval is zero.
+func (d *dispatcher) run(ctx context.Context) {
+	for record := range d.queue {
+		batch := append(make([]*Record, 0, d.batchSize), record)
+		batch = d.fill(batch)
+		if err := d.sink.Write(ctx, batch); err != nil {
+			logrus.Errorf("failed to write %d audit records to %s sink: %v", len(batch), d.name, err)
+		}
+	}
+}
+
+// fill adds queued records to the batch until it is complete.
+func (d *dispatcher) fill(batch []*Record) []*Record {
+	var timeout <-chan time.Time
+	if d.flushInterval > 0 {
+		timer := time.NewTimer(d.flushInterval)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	for len(batch) < d.batchSize {
+		if timeout == nil {
+			select {
+			case record, ok := <-d.queue:
+				if !ok {
+					return batch
+				}
+				batch = append(batch, record)
+			default:
+				return batch
+			}
+			continue
+		}
+		select {
+		case record, ok := <-d.queue:
+			if !ok {
+				return batch
+			}
+			batch = append(batch, record)
+		case <-timeout:
+			return batch
+		}
+	}
+	return batch
+}
@@ -0,0 +1,256 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockSink struct {
+	mu      sync.Mutex
+	batches [][]*Record
+	block   chan struct{}
+	closed  bool
+}
+
+func (s *mockSink) Write(_ context.Context, records []*Record) error {
+	if s.block != nil {
+		<-s.block
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, records)
+	return nil
+}
+
+func (s *mockSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *mockSink) keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for _, batch := range s.batches {
+		for _, record := range batch {
+			keys = append(keys, record.Key)
+		}
+	}
+	return keys
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       Options
+		expectNil  bool
+		expectErr  bool
+		dispatches int
+	}{
+		{
+			name:      "no sinks",
+			expectNil: true,
+		},
+		{
+			name: "file and webhook sinks",
+			opts: Options{
+				FilePath:   filepath.Join(t.TempDir(), "audit.jsonl"),
+				WebhookURL: "https://audit.example.com/records",
+			},
+			dispatches: 2,
+		},
+		{
+			name: "invalid webhook URL",
+			opts: Options{
+				WebhookURL: "ftp://audit.example.com",
+			},
+			expectErr: true,
+		},
+		{
+			name: "unwritable file",
+			opts: Options{
+				FilePath: filepath.Join(t.TempDir(), "missing", "audit.jsonl"),
+			},
+			expectErr: true,
+		},
+		{
+			name: "negative rotation size",
+			opts: Options{
+				FilePath:         filepath.Join(t.TempDir(), "audit.jsonl"),
+				FileMaxSizeBytes: -1,
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, err := New(tt.opts)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error %v, got %v", tt.expectErr, err)
+			}
+			if tt.expectErr {
+				return
+			}
+			if (logger == nil) != tt.expectNil {
+				t.Fatalf("expected nil logger %v, got %v", tt.expectNil, logger)
+			}
+			if logger == nil {
+				return
+			}
+			defer logger.Close(context.Background())
+			if len(logger.dispatchers) != tt.dispatches {
+				t.Errorf("expected %d dispatchers, got %d", tt.dispatches, len(logger.dispatchers))
+			}
+		})
+	}
+}
+
+func TestLogger_LogAndClose(t *testing.T) {
+	sink := &mockSink{}
+	logger := NewWithSinks(10, sink)
+	for _, key := range []string{"a", "b", "c"} {
+		logger.Log(&Record{Key: key})
+	}
+	if err := logger.Close(context.Background()); err != nil {
+		t.Fatalf("failed to close logger: %v", err)
+	}
+	if keys := sink.keys(); len(keys) != 3 || keys[0] != "a" || keys[2] != "c" {
+		t.Errorf("expected records a, b, c in order, got %v", keys)
+	}
+	if !sink.closed {
+		t.Error("expected sink to be closed")
+	}
+
+	// Records logged after closing are dropped.
+	logger.Log(&Record{Key: "d"})
+	if keys := sink.keys(); len(keys) != 3 {
+		t.Errorf("expected record logged after close to be dropped, got %v", keys)
+	}
+	if err := logger.Close(context.Background()); err != nil {
+		t.Errorf("expected closing twice to succeed, got %v", err)
+	}
+}
+
+func TestLogger_Nil(t *testing.T) {
+	var logger *Logger
+	logger.Log(&Record{Key: "a"})
+	if err := logger.Close(context.Background()); err != nil {
+		t.Errorf("expected nil logger to close, got %v", err)
+	}
+}
+
+func TestLogger_DropsWhenBufferIsFull(t *testing.T) {
+	sink := &mockSink{block: make(chan struct{})}
+	logger := NewWithSinks(1, sink)
+
+	// Log must not block while the sink is stuck.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, key := range []string{"a", "b", "c", "d"} {
+			logger.Log(&Record{Key: key})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected Log not to block on a slow sink")
+	}
+
+	close(sink.block)
+	if err := logger.Close(context.Background()); err != nil {
+		t.Fatalf("failed to close logger: %v", err)
+	}
+	if keys := sink.keys(); len(keys) == 0 || len(keys) > 2 {
+		t.Errorf("expected at most the dispatched and the buffered record, got %v", keys)
+	}
+}
+
+func TestLogger_CloseAbortsOnContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	logger, err := New(Options{
+		WebhookURL:        server.URL,
+		WebhookMaxRetries: 100,
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	logger.Log(&Record{Key: "a"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := logger.Close(ctx); err != nil {
+		t.Fatalf("failed to close logger: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected close to abort retries, took %s", elapsed)
+	}
+}
+
+func TestLogger_WebhookBatching(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]*Record
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var records []*Record
+		if err := json.NewDecoder(r.Body).Decode(&records); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, records)
+	}))
+	defer server.Close()
+
+	logger, err := New(Options{
+		WebhookURL:           server.URL,
+		WebhookBatchSize:     2,
+		WebhookFlushInterval: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		logger.Log(&Record{Key: key})
+	}
+	if err := logger.Close(context.Background()); err != nil {
+		t.Fatalf("failed to close logger: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Fatalf("expected batches of 2 and 1 records, got %v", batches)
+	}
+	if batches[1][0].Key != "c" {
+		t.Errorf("expected last batch to contain c, got %s", batches[1][0].Key)
+	}
+}
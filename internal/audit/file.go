@@ -0,0 +1,185 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// backupTimeFormat is the format of the timestamp suffix of rotated files. It
+// sorts lexically in chronological order.
+const backupTimeFormat = "20060102T150405.000000000Z"
+
+// FileSink appends records as JSON lines to a file and rotates the file by
+// size or time. Rotated files are renamed with a UTC timestamp suffix, e.g.
+// "audit.jsonl.20250102T150405.000000000Z".
+type FileSink struct {
+	path       string
+	maxSize    int64
+	interval   time.Duration
+	maxBackups int
+
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	now      func() time.Time
+}
+
+// NewFileSink opens the file at path for appending. The file is rotated once
+// it exceeds maxSize bytes or interval elapsed since it was opened, and at most
+// maxBackups rotated files are kept. Zero values disable the respective limit.
+func NewFileSink(path string, maxSize int64, interval time.Duration, maxBackups int) (*FileSink, error) {
+	if maxSize < 0 || interval < 0 || maxBackups < 0 {
+		return nil, fmt.Errorf("invalid rotation settings of audit file %s: limits must not be negative", path)
+	}
+	s := &FileSink{
+		path:       path,
+		maxSize:    maxSize,
+		interval:   interval,
+		maxBackups: maxBackups,
+		now:        time.Now,
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Write appends the records and syncs the file to disk.
+func (s *FileSink) Write(_ context.Context, records []*Record) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, record := range records {
+		line := buf.Len()
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to encode audit record: %w", err)
+		}
+		if s.shouldRotate(s.size+int64(line), int64(buf.Len()-line)) {
+			// Write the lines encoded so far to the current file.
+			if err := s.write(buf.Bytes()[:line]); err != nil {
+				return err
+			}
+			if err := s.rotate(); err != nil {
+				return err
+			}
+			buf.Next(line)
+		}
+	}
+	return s.write(buf.Bytes())
+}
+
+// shouldRotate reports whether a record of size bytes must go to a new file
+// given the bytes already written or buffered for the current file. An empty
+// file is never rotated so that no empty backups are created and records
+// larger than the size limit are still written.
+func (s *FileSink) shouldRotate(current, size int64) bool {
+	if current == 0 {
+		return false
+	}
+	if s.interval > 0 && s.now().Sub(s.openedAt) >= s.interval {
+		return true
+	}
+	return s.maxSize > 0 && current+size > s.maxSize
+}
+
+func (s *FileSink) write(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	n, err := s.file.Write(data)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write audit file %s: %w", s.path, err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync audit file %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+func (s *FileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit file %s: %w", s.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat audit file %s: %w", s.path, err)
+	}
+	s.file = file
+	s.size = info.Size()
+	s.openedAt = s.now()
+	return nil
+}
+
+// rotate renames the current file with a timestamp suffix, opens a new file
+// and removes the oldest rotated files beyond maxBackups.
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("failed to close audit file %s: %w", s.path, err)
+	}
+	backup := s.path + "." + s.now().UTC().Format(backupTimeFormat)
+	if err := os.Rename(s.path, backup); err != nil {
+		return fmt.Errorf("failed to rotate audit file %s: %w", s.path, err)
+	}
+	if err := s.open(); err != nil {
+		return err
+	}
+	return s.prune()
+}
+
+func (s *FileSink) prune() error {
+	if s.maxBackups == 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(filepath.Dir(s.path))
+	if err != nil {
+		return fmt.Errorf("failed to list rotated audit files: %w", err)
+	}
+	prefix := filepath.Base(s.path) + "."
+	var backups []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), prefix) {
+			if _, err := time.Parse(backupTimeFormat, strings.TrimPrefix(entry.Name(), prefix)); err == nil {
+				backups = append(backups, entry.Name())
+			}
+		}
+	}
+	if len(backups) <= s.maxBackups {
+		return nil
+	}
+	slices.Sort(backups)
+	for _, name := range backups[:len(backups)-s.maxBackups] {
+		if err := os.Remove(filepath.Join(filepath.Dir(s.path), name)); err != nil {
+			return fmt.Errorf("failed to remove rotated audit file %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readRecords(t *testing.T, path string) []*Record {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+	var records []*Record
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("failed to decode line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, &record)
+	}
+	return records
+}
+
+func backups(t *testing.T, path string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		t.Fatalf("failed to list backups: %v", err)
+	}
+	return matches
+}
+
+func TestFileSink_Append(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	if err := os.WriteFile(path, []byte(`{"key":"existing"}`+"\n"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	sink, err := NewFileSink(path, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+	if err := sink.Write(context.Background(), []*Record{{Key: "a", Succeeded: true}, {Key: "b"}}); err != nil {
+		t.Fatalf("failed to write records: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("failed to close sink: %v", err)
+	}
+
+	records := readRecords(t, path)
+	if len(records) != 3 || records[0].Key != "existing" || records[1].Key != "a" || !records[1].Succeeded || records[2].Key != "b" {
+		t.Errorf("unexpected records: %+v", records)
+	}
+}
+
+func TestFileSink_RotateBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	record := &Record{Key: strings.Repeat("a", 50)}
+	line, _ := json.Marshal(record)
+	// Each file fits two records.
+	sink, err := NewFileSink(path, int64(2*(len(line)+1)), 0, 2)
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+	defer sink.Close()
+	now := time.Now()
+	sink.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	for range 3 {
+		if err := sink.Write(context.Background(), []*Record{record, record, record}); err != nil {
+			t.Fatalf("failed to write records: %v", err)
+		}
+	}
+
+	// 9 records fill 4 rotated files and one record remains in the current
+	// file. Only the 2 newest rotated files are kept.
+	if records := readRecords(t, path); len(records) != 1 {
+		t.Errorf("expected 1 record in current file, got %d", len(records))
+	}
+	files := backups(t, path)
+	if len(files) != 2 {
+		t.Fatalf("expected 2 backups, got %v", files)
+	}
+	for _, file := range files {
+		if records := readRecords(t, file); len(records) != 2 {
+			t.Errorf("expected 2 records in %s, got %d", file, len(records))
+		}
+	}
+}
+
+func TestFileSink_RotateByTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileSink(path, 0, time.Hour, 0)
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+	defer sink.Close()
+	now := time.Now()
+	sink.now = func() time.Time { return now }
+	sink.openedAt = now
+
+	write := func(key string) {
+		if err := sink.Write(context.Background(), []*Record{{Key: key}}); err != nil {
+			t.Fatalf("failed to write record: %v", err)
+		}
+	}
+	write("a")
+	write("b")
+	if files := backups(t, path); len(files) != 0 {
+		t.Fatalf("expected no rotation within the interval, got %v", files)
+	}
+
+	now = now.Add(time.Hour)
+	write("c")
+	files := backups(t, path)
+	if len(files) != 1 {
+		t.Fatalf("expected 1 backup, got %v", files)
+	}
+	if records := readRecords(t, files[0]); len(records) != 2 {
+		t.Errorf("expected 2 records in backup, got %d", len(records))
+	}
+	if records := readRecords(t, path); len(records) != 1 || records[0].Key != "c" {
+		t.Errorf("expected record c in current file, got %+v", records)
+	}
+}
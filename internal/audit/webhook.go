@@ -0,0 +1,113 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	webhookInitialBackoff = 100 * time.Millisecond
+	webhookMaxBackoff     = 5 * time.Second
+)
+
+// WebhookSink posts batches of records as a JSON array to an HTTP endpoint.
+// Requests failing with a network error, 429 or 5xx are retried with
+// exponential backoff.
+type WebhookSink struct {
+	url            string
+	client         *http.Client
+	maxRetries     int
+	initialBackoff time.Duration
+}
+
+// NewWebhookSink creates a sink posting to endpoint with the timeout per
+// request, retrying failed requests up to maxRetries times.
+func NewWebhookSink(endpoint string, maxRetries int, timeout time.Duration) (*WebhookSink, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse audit webhook URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported scheme %q of audit webhook URL, must be http or https", u.Scheme)
+	}
+	return &WebhookSink{
+		url: endpoint,
+		client: &http.Client{
+			Timeout: timeout,
+		},
+		maxRetries:     maxRetries,
+		initialBackoff: webhookInitialBackoff,
+	}, nil
+}
+
+// Write posts the records, retrying retryable failures.
+func (s *WebhookSink) Write(ctx context.Context, records []*Record) error {
+	body, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit records: %w", err)
+	}
+
+	backoff := s.initialBackoff
+	for attempt := 0; ; attempt++ {
+		retryable, err := s.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= s.maxRetries {
+			return err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", err, ctx.Err())
+		}
+		backoff = min(2*backoff, webhookMaxBackoff)
+	}
+}
+
+// post sends body once and reports whether a failure may be retried.
+func (s *WebhookSink) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create audit webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, fmt.Errorf("failed to post audit records: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+	return retryable, fmt.Errorf("audit webhook responded with status %d", resp.StatusCode)
+}
+
+// Close releases idle connections.
+func (s *WebhookSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
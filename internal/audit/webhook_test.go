@@ -0,0 +1,123 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewWebhookSink(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		expectErr bool
+	}{
+		{
+			name: "https URL",
+			url:  "https://audit.example.com/records",
+		},
+		{
+			name:      "unsupported scheme",
+			url:       "file:///tmp/audit",
+			expectErr: true,
+		},
+		{
+			name:      "invalid URL",
+			url:       "://",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewWebhookSink(tt.url, 0, time.Second)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("expected error %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestWebhookSink_Write(t *testing.T) {
+	tests := []struct {
+		name           string
+		statusCodes    []int
+		maxRetries     int
+		expectErr      bool
+		expectAttempts int32
+	}{
+		{
+			name:           "success",
+			statusCodes:    []int{http.StatusOK},
+			maxRetries:     3,
+			expectAttempts: 1,
+		},
+		{
+			name:           "retry on server error",
+			statusCodes:    []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusAccepted},
+			maxRetries:     3,
+			expectAttempts: 3,
+		},
+		{
+			name:           "retries exhausted",
+			statusCodes:    []int{http.StatusBadGateway},
+			maxRetries:     2,
+			expectErr:      true,
+			expectAttempts: 3,
+		},
+		{
+			name:           "no retry on client error",
+			statusCodes:    []int{http.StatusBadRequest},
+			maxRetries:     3,
+			expectErr:      true,
+			expectAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Content-Type") != "application/json" {
+					t.Errorf("unexpected content type %q", r.Header.Get("Content-Type"))
+				}
+				attempt := int(attempts.Add(1)) - 1
+				w.WriteHeader(tt.statusCodes[min(attempt, len(tt.statusCodes)-1)])
+			}))
+			defer server.Close()
+
+			sink, err := NewWebhookSink(server.URL, tt.maxRetries, time.Second)
+			if err != nil {
+				t.Fatalf("failed to create sink: %v", err)
+			}
+			sink.initialBackoff = time.Millisecond
+			defer sink.Close()
+
+			err = sink.Write(context.Background(), []*Record{{Key: "a"}})
+			if (err != nil) != tt.expectErr {
+				t.Errorf("expected error %v, got %v", tt.expectErr, err)
+			}
+			if got := attempts.Load(); got != tt.expectAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.expectAttempts, got)
+			}
+		})
+	}
+}
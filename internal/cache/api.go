@@ -38,4 +38,17 @@ type Cache[T any] interface {
 
 	// Delete removes the specified key/value from the cache.
 	Delete(ctx context.Context, key string) error
+
+	// DeleteByPrefix removes all key/values whose key starts with the
+	// specified prefix. An empty prefix removes all key/values.
+	DeleteByPrefix(ctx context.Context, prefix string) error
+}
+
+// TTLReader is implemented by caches that can report the remaining time to
+// live of their entries.
+type TTLReader[T any] interface {
+	// GetWithTTL returns the value associated with the key and its remaining
+	// time to live, or an error if not found. The remaining time to live is
+	// zero if the entry does not expire.
+	GetWithTTL(ctx context.Context, key string) (T, time.Duration, error)
 }
@@ -17,6 +17,7 @@ package inmemory
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"time"
 
@@ -77,6 +78,23 @@ func (c *Cache[T]) Get(_ context.Context, key string) (T, error) {
 	return item.value, nil
 }
 
+// GetWithTTL returns the value associated with the key and its remaining time
+// to live, or an error if not found.
+func (c *Cache[T]) GetWithTTL(_ context.Context, key string) (T, time.Duration, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var zero T
+	item, exists := c.items[key]
+	if !exists || item.isExpired() {
+		return zero, 0, cache.ErrNotFound
+	}
+	if item.expiration.IsZero() {
+		return item.value, 0, nil
+	}
+	return item.value, time.Until(item.expiration), nil
+}
+
 // Set stores a value with the specified key.
 func (c *Cache[T]) Set(_ context.Context, key string, value T, ttl time.Duration) error {
 	if ttl <= 0 {
@@ -119,3 +137,17 @@ func (c *Cache[T]) Delete(_ context.Context, key string) error {
 	delete(c.items, key)
 	return nil
 }
+
+// DeleteByPrefix removes all key/values whose key starts with the specified
+// prefix.
+func (c *Cache[T]) DeleteByPrefix(_ context.Context, prefix string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.items, key)
+		}
+	}
+	return nil
+}
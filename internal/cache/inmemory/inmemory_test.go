@@ -186,6 +186,44 @@ func TestCacheGetExpired(t *testing.T) {
 	}
 }
 
+func TestCacheGetWithTTL(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewCache[string](10)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	ttlReader, ok := c.(cache.TTLReader[string])
+	if !ok {
+		t.Fatal("expected cache to implement TTLReader")
+	}
+
+	if _, _, err := ttlReader.GetWithTTL(ctx, testKey); !errors.Is(err, cache.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	if err := c.Set(ctx, testKey, testValue, time.Minute); err != nil {
+		t.Fatalf("failed to set value: %v", err)
+	}
+	val, ttl, err := ttlReader.GetWithTTL(ctx, testKey)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if val != testValue {
+		t.Errorf("expected %v, got %v", testValue, val)
+	}
+	if ttl <= 0 || ttl > time.Minute {
+		t.Errorf("expected remaining ttl within a minute, got %v", ttl)
+	}
+
+	if err := c.Set(ctx, "expiring", testValue, time.Millisecond); err != nil {
+		t.Fatalf("failed to set value: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if _, _, err := ttlReader.GetWithTTL(ctx, "expiring"); !errors.Is(err, cache.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for expired entry, got %v", err)
+	}
+}
+
 func TestCacheDelete(t *testing.T) {
 	ctx := context.Background()
 	c, err := NewCache[string](10)
@@ -459,3 +497,36 @@ func TestCacheWithDifferentTypes(t *testing.T) {
 		t.Errorf("expected {Name: John, Age: 30}, got %+v", structVal)
 	}
 }
+
+func TestCacheDeleteByPrefix(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewCache[string](10)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	for _, key := range []string{"verify_a:v1", "verify_a:v2", "verify_b:v1"} {
+		if err := c.Set(ctx, key, testValue, time.Hour); err != nil {
+			t.Fatalf("failed to set value: %v", err)
+		}
+	}
+
+	if err := c.DeleteByPrefix(ctx, "verify_a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, key := range []string{"verify_a:v1", "verify_a:v2"} {
+		if _, err := c.Get(ctx, key); !errors.Is(err, cache.ErrNotFound) {
+			t.Errorf("expected key %s to be deleted, got %v", key, err)
+		}
+	}
+	if _, err := c.Get(ctx, "verify_b:v1"); err != nil {
+		t.Errorf("expected key verify_b:v1 to be kept, got %v", err)
+	}
+
+	if err := c.DeleteByPrefix(ctx, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := c.Get(ctx, "verify_b:v1"); !errors.Is(err, cache.ErrNotFound) {
+		t.Errorf("expected all keys to be deleted, got %v", err)
+	}
+}
@@ -0,0 +1,244 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/notaryproject/ratify/v2/internal/cache"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	// scanCount is the number of keys hinted to each SCAN iteration of
+	// DeleteByPrefix.
+	scanCount = 1000
+	// pttlNotFound is the remaining time to live reported by PTTL for a
+	// missing key.
+	pttlNotFound = -2
+
+	// dialTimeout, ioTimeout and maxRetries bound the cache operations, so
+	// that an unavailable server degrades to cache misses well within the
+	// admission deadlines instead of failing the requests.
+	dialTimeout = time.Second
+	ioTimeout   = 500 * time.Millisecond
+	maxRetries  = 1
+)
+
+// Options holds the options to connect to a Redis server.
+type Options struct {
+	// Address is the address of the Redis server in the format "host:port".
+	// Required.
+	Address string
+
+	// Username is the username used to authenticate with the Redis server.
+	// Optional.
+	Username string
+
+	// Password is the password used to authenticate with the Redis server.
+	// Optional.
+	Password string
+
+	// DB is the database selected after connecting to the Redis server.
+	// Optional. Defaults to 0.
+	DB int
+
+	// TLS enables TLS for the connections to the Redis server.
+	// Optional.
+	TLS bool
+}
+
+// NewClient creates a client connected to the Redis server and checks that
+// the server is reachable.
+func NewClient(ctx context.Context, opts Options) (*goredis.Client, error) {
+	if opts.Address == "" {
+		return nil, errors.New("redis address is required")
+	}
+	clientOpts := &goredis.Options{
+		Addr:     opts.Address,
+		Username: opts.Username,
+		Password: opts.Password,
+		DB:       opts.DB,
+
+		DialTimeout:  dialTimeout,
+		ReadTimeout:  ioTimeout,
+		WriteTimeout: ioTimeout,
+		MaxRetries:   maxRetries,
+	}
+	if opts.TLS {
+		clientOpts.TLSConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+	client := goredis.NewClient(clientOpts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to connect to redis at %s: %w", opts.Address, err)
+	}
+	return client, nil
+}
+
+// Cache is a cache backed by a Redis server. Values are stored JSON encoded
+// under the keys prefixed by the namespace of the cache, so that multiple
+// caches and replicas can share the same server.
+type Cache[T any] struct {
+	client    goredis.UniversalClient
+	namespace string
+	ttl       time.Duration
+}
+
+// NewCache creates a new Redis cache storing its entries under the specified
+// namespace with the specified default TTL. The client is not closed by the
+// cache.
+func NewCache[T any](client goredis.UniversalClient, namespace string, ttl time.Duration) (cache.Cache[T], error) {
+	if client == nil {
+		return nil, errors.New("redis client is required")
+	}
+	if ttl < 0 {
+		return nil, cache.ErrInvalidTTL
+	}
+	if namespace != "" && !strings.HasSuffix(namespace, ":") {
+		namespace += ":"
+	}
+	return &Cache[T]{
+		client:    client,
+		namespace: namespace,
+		ttl:       ttl,
+	}, nil
+}
+
+// Get returns the value associated with the key, or an error if not found.
+func (c *Cache[T]) Get(ctx context.Context, key string) (T, error) {
+	var zero T
+	data, err := c.client.Get(ctx, c.namespace+key).Bytes()
+	if err != nil {
+		if errors.Is(err, goredis.Nil) {
+			return zero, cache.ErrNotFound
+		}
+		return zero, fmt.Errorf("failed to get key %s from redis: %w", key, err)
+	}
+	return c.decode(key, data)
+}
+
+// GetWithTTL returns the value associated with the key and its remaining time
+// to live, or an error if not found.
+func (c *Cache[T]) GetWithTTL(ctx context.Context, key string) (T, time.Duration, error) {
+	var zero T
+	pipe := c.client.Pipeline()
+	getCmd := pipe.Get(ctx, c.namespace+key)
+	ttlCmd := pipe.PTTL(ctx, c.namespace+key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		if errors.Is(err, goredis.Nil) {
+			return zero, 0, cache.ErrNotFound
+		}
+		return zero, 0, fmt.Errorf("failed to get key %s from redis: %w", key, err)
+	}
+
+	ttl := ttlCmd.Val()
+	if ttl == pttlNotFound {
+		// The entry expired between the commands.
+		return zero, 0, cache.ErrNotFound
+	}
+	if ttl < 0 {
+		// The entry does not expire.
+		ttl = 0
+	}
+	data, err := getCmd.Bytes()
+	if err != nil {
+		return zero, 0, fmt.Errorf("failed to get key %s from redis: %w", key, err)
+	}
+	value, err := c.decode(key, data)
+	if err != nil {
+		return zero, 0, err
+	}
+	return value, ttl, nil
+}
+
+// Set stores a value with the specified key.
+func (c *Cache[T]) Set(ctx context.Context, key string, value T, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = c.ttl // Use the cache's configured TTL if none is provided
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal value of key %s: %w", key, err)
+	}
+	if err := c.client.Set(ctx, c.namespace+key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("%w: %w", cache.ErrAddFailed, err)
+	}
+	return nil
+}
+
+// Delete removes the specified key/value from the cache.
+func (c *Cache[T]) Delete(ctx context.Context, key string) error {
+	if err := c.client.Del(ctx, c.namespace+key).Err(); err != nil {
+		return fmt.Errorf("failed to delete key %s from redis: %w", key, err)
+	}
+	return nil
+}
+
+// DeleteByPrefix removes all key/values whose key starts with the specified
+// prefix. The keys are looked up with SCAN so that the server is not blocked
+// by large namespaces.
+func (c *Cache[T]) DeleteByPrefix(ctx context.Context, prefix string) error {
+	match := escapePattern(c.namespace+prefix) + "*"
+	var cursor uint64
+	for {
+		keys, next, err := c.client.Scan(ctx, cursor, match, scanCount).Result()
+		if err != nil {
+			return fmt.Errorf("failed to scan keys with prefix %s in redis: %w", prefix, err)
+		}
+		if len(keys) > 0 {
+			if err := c.client.Unlink(ctx, keys...).Err(); err != nil {
+				return fmt.Errorf("failed to delete keys with prefix %s from redis: %w", prefix, err)
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// decode unmarshals the stored value of the key.
+func (c *Cache[T]) decode(key string, data []byte) (T, error) {
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		var zero T
+		return zero, fmt.Errorf("failed to unmarshal value of key %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// escapePattern escapes the glob-style special characters of a SCAN MATCH
+// pattern.
+func escapePattern(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
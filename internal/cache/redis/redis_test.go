@@ -0,0 +1,287 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/notaryproject/ratify/v2/internal/cache"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+type testValue struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func newTestClient(t *testing.T) (*miniredis.Miniredis, *goredis.Client) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client, err := NewClient(context.Background(), Options{Address: server.Addr()})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return server, client
+}
+
+func TestNewClient(t *testing.T) {
+	server := miniredis.RunT(t)
+	server.RequireUserAuth("ratify", "secret")
+
+	tests := []struct {
+		name        string
+		opts        Options
+		expectError bool
+	}{
+		{
+			name:        "missing address",
+			opts:        Options{},
+			expectError: true,
+		},
+		{
+			name:        "invalid credentials",
+			opts:        Options{Address: server.Addr(), Username: "ratify", Password: "wrong"},
+			expectError: true,
+		},
+		{
+			name: "valid credentials",
+			opts: Options{Address: server.Addr(), Username: "ratify", Password: "secret"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(context.Background(), tt.opts)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("NewClient() error = %v, expectError %v", err, tt.expectError)
+			}
+			if client != nil {
+				_ = client.Close()
+			}
+		})
+	}
+}
+
+func TestNewCache(t *testing.T) {
+	_, client := newTestClient(t)
+
+	if _, err := NewCache[string](nil, "ratify", 0); err == nil {
+		t.Error("expected error for nil client, got nil")
+	}
+	if _, err := NewCache[string](client, "ratify", -time.Second); !errors.Is(err, cache.ErrInvalidTTL) {
+		t.Errorf("expected error %v, got %v", cache.ErrInvalidTTL, err)
+	}
+	if _, err := NewCache[string](client, "ratify", time.Second); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestCache_SetGet(t *testing.T) {
+	server, client := newTestClient(t)
+	ctx := context.Background()
+	c, err := NewCache[*testValue](client, "ratify:verify", time.Minute)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+
+	if _, err := c.Get(ctx, "key"); !errors.Is(err, cache.ErrNotFound) {
+		t.Fatalf("expected error %v, got %v", cache.ErrNotFound, err)
+	}
+
+	want := &testValue{Name: "value", Count: 3}
+	if err := c.Set(ctx, "key", want, 0); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	got, err := c.Get(ctx, "key")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if *got != *want {
+		t.Errorf("Get() = %+v, want %+v", got, want)
+	}
+
+	// The value is namespaced and JSON encoded.
+	raw, err := server.Get("ratify:verify:key")
+	if err != nil {
+		t.Fatalf("expected namespaced key to exist: %v", err)
+	}
+	if raw != `{"name":"value","count":3}` {
+		t.Errorf("stored value = %s", raw)
+	}
+	if ttl := server.TTL("ratify:verify:key"); ttl != time.Minute {
+		t.Errorf("stored TTL = %v, want default TTL %v", ttl, time.Minute)
+	}
+
+	// The TTL of the entry is passed through.
+	if err := c.Set(ctx, "key", want, 2*time.Second); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if ttl := server.TTL("ratify:verify:key"); ttl != 2*time.Second {
+		t.Errorf("stored TTL = %v, want %v", ttl, 2*time.Second)
+	}
+	server.FastForward(3 * time.Second)
+	if _, err := c.Get(ctx, "key"); !errors.Is(err, cache.ErrNotFound) {
+		t.Errorf("expected expired entry to be not found, got %v", err)
+	}
+}
+
+func TestCache_GetInvalidValue(t *testing.T) {
+	server, client := newTestClient(t)
+	c, err := NewCache[*testValue](client, "ratify", 0)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	if err := server.Set("ratify:key", "not json"); err != nil {
+		t.Fatalf("failed to seed server: %v", err)
+	}
+	if _, err := c.Get(context.Background(), "key"); err == nil || errors.Is(err, cache.ErrNotFound) {
+		t.Errorf("expected decoding error, got %v", err)
+	}
+}
+
+func TestCache_ServerUnavailable(t *testing.T) {
+	server, client := newTestClient(t)
+	ctx := context.Background()
+	c, err := NewCache[string](client, "ratify", 0)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	server.Close()
+
+	if _, err := c.Get(ctx, "key"); err == nil || errors.Is(err, cache.ErrNotFound) {
+		t.Errorf("Get() expected connection error, got %v", err)
+	}
+	if err := c.Set(ctx, "key", "value", 0); !errors.Is(err, cache.ErrAddFailed) {
+		t.Errorf("Set() expected error %v, got %v", cache.ErrAddFailed, err)
+	}
+	if err := c.Delete(ctx, "key"); err == nil {
+		t.Error("Delete() expected error, got nil")
+	}
+	if err := c.DeleteByPrefix(ctx, ""); err == nil {
+		t.Error("DeleteByPrefix() expected error, got nil")
+	}
+}
+
+func TestCache_GetWithTTL(t *testing.T) {
+	server, client := newTestClient(t)
+	ctx := context.Background()
+	c, err := NewCache[string](client, "ratify", 0)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	reader, ok := c.(cache.TTLReader[string])
+	if !ok {
+		t.Fatal("expected cache to implement TTLReader")
+	}
+
+	if _, _, err := reader.GetWithTTL(ctx, "key"); !errors.Is(err, cache.ErrNotFound) {
+		t.Fatalf("expected error %v, got %v", cache.ErrNotFound, err)
+	}
+
+	if err := c.Set(ctx, "key", "value", 10*time.Second); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	server.FastForward(4 * time.Second)
+	value, ttl, err := reader.GetWithTTL(ctx, "key")
+	if err != nil {
+		t.Fatalf("GetWithTTL() error = %v", err)
+	}
+	if value != "value" || ttl != 6*time.Second {
+		t.Errorf("GetWithTTL() = %q, %v, want %q, %v", value, ttl, "value", 6*time.Second)
+	}
+
+	// Entries without TTL report zero.
+	if err := c.Set(ctx, "persistent", "value", 0); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if _, ttl, err := reader.GetWithTTL(ctx, "persistent"); err != nil || ttl != 0 {
+		t.Errorf("GetWithTTL() = %v, %v, want 0, nil", ttl, err)
+	}
+}
+
+func TestCache_Delete(t *testing.T) {
+	server, client := newTestClient(t)
+	ctx := context.Background()
+	c, err := NewCache[string](client, "ratify", 0)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+
+	if err := c.Set(ctx, "key", "value", 0); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := c.Delete(ctx, "key"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if server.Exists("ratify:key") {
+		t.Error("expected key to be deleted")
+	}
+	if err := c.Delete(ctx, "missing"); err != nil {
+		t.Errorf("Delete() of missing key error = %v", err)
+	}
+}
+
+func TestCache_DeleteByPrefix(t *testing.T) {
+	server, client := newTestClient(t)
+	ctx := context.Background()
+	c, err := NewCache[string](client, "ratify:verify", 0)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	other, err := NewCache[string](client, "ratify:mutate", 0)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+
+	keys := []string{"registry/a*b:v1", "registry/a*b:v2", "registry/ab:v1", "other:v1"}
+	for _, key := range keys {
+		if err := c.Set(ctx, key, "value", 0); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+	if err := other.Set(ctx, "registry/a*b:v1", "value", 0); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	// Special characters of the prefix are matched literally.
+	if err := c.DeleteByPrefix(ctx, "registry/a*b"); err != nil {
+		t.Fatalf("DeleteByPrefix() error = %v", err)
+	}
+	for key, exists := range map[string]bool{
+		"ratify:verify:registry/a*b:v1": false,
+		"ratify:verify:registry/a*b:v2": false,
+		"ratify:verify:registry/ab:v1":  true,
+		"ratify:verify:other:v1":        true,
+		"ratify:mutate:registry/a*b:v1": true,
+	} {
+		if server.Exists(key) != exists {
+			t.Errorf("key %s exists = %v, want %v", key, !exists, exists)
+		}
+	}
+
+	// An empty prefix removes all entries of the namespace only.
+	if err := c.DeleteByPrefix(ctx, ""); err != nil {
+		t.Fatalf("DeleteByPrefix() error = %v", err)
+	}
+	if got := server.Keys(); len(got) != 1 || got[0] != "ratify:mutate:registry/a*b:v1" {
+		t.Errorf("remaining keys = %v", got)
+	}
+}
@@ -17,9 +17,12 @@ package ristretto
 
 import (
 	"context"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/dgraph-io/ristretto/v2"
+	"github.com/dgraph-io/ristretto/v2/z"
 	"github.com/notaryproject/ratify/v2/internal/cache"
 	"github.com/sirupsen/logrus"
 )
@@ -32,6 +35,19 @@ const (
 type Cache[T any] struct {
 	cache *ristretto.Cache[string, T]
 	ttl   time.Duration
+
+	// keys tracks the keys stored in the cache by their hashes as ristretto
+	// does not support iterating over its entries. Keys are untracked when
+	// ristretto evicts, expires or rejects their entries.
+	keysMu sync.Mutex
+	keys   map[uint64]trackedKey
+}
+
+// trackedKey is a key stored in the cache with the conflict hash ristretto
+// uses to tell apart keys of the same hash.
+type trackedKey struct {
+	key      string
+	conflict uint64
 }
 
 // NewCache creates a new Ristretto cache with the specified TTL.
@@ -40,20 +56,23 @@ func NewCache[T any](ttl time.Duration) (cache.Cache[T], error) {
 		return nil, cache.ErrInvalidTTL
 	}
 
+	r := &Cache[T]{
+		ttl:  ttl,
+		keys: make(map[uint64]trackedKey),
+	}
 	memoryCache, err := ristretto.NewCache(&ristretto.Config[string, T]{
 		NumCounters: defaultCountNum, // number of keys to track frequency.
 		MaxCost:     defaultMaxSize,  // Max size in Megabytes.
 		BufferItems: 64,              // number of keys per Get buffer. 64 is recommended by the ristretto library.
+		OnEvict:     r.untrackItem,   // called for evicted and expired entries.
+		OnReject:    r.untrackItem,
 	})
 	if err != nil {
 		logrus.Errorf("could not create ristretto cache, err: %s", err)
 		return nil, err
 	}
-
-	return &Cache[T]{
-		cache: memoryCache,
-		ttl:   ttl,
-	}, nil
+	r.cache = memoryCache
+	return r, nil
 }
 
 // Get returns the value associated with the key, or an error if not found.
@@ -66,23 +85,93 @@ func (r *Cache[T]) Get(_ context.Context, key string) (T, error) {
 	return zero, cache.ErrNotFound
 }
 
+// GetWithTTL returns the value associated with the key and its remaining time
+// to live, or an error if not found.
+func (r *Cache[T]) GetWithTTL(_ context.Context, key string) (T, time.Duration, error) {
+	var zero T
+	cacheValue, found := r.cache.Get(key)
+	if !found {
+		return zero, 0, cache.ErrNotFound
+	}
+	ttl, found := r.cache.GetTTL(key)
+	if !found {
+		// The entry expired between the lookups.
+		return zero, 0, cache.ErrNotFound
+	}
+	return cacheValue, ttl, nil
+}
+
 // Set stores a value with the specified key.
 func (r *Cache[T]) Set(_ context.Context, key string, value T, ttl time.Duration) error {
 	if ttl <= 0 {
 		ttl = r.ttl // Use the cache's configured TTL if none is provided
 	}
+	// Track the key before the entry is processed, so that it is untracked
+	// again if ristretto rejects the entry.
+	r.trackKey(key)
 	saved := r.cache.SetWithTTL(key, value, 1, ttl)
 	r.cache.Wait()
 	if saved {
 		return nil
 	}
+	r.untrackKey(key)
 	return cache.ErrAddFailed
 }
 
+// trackKey records the key as stored in the cache.
+func (r *Cache[T]) trackKey(key string) {
+	hash, conflict := z.KeyToHash(key)
+	r.keysMu.Lock()
+	defer r.keysMu.Unlock()
+	r.keys[hash] = trackedKey{key: key, conflict: conflict}
+}
+
+// untrackKey removes the key from the tracked keys.
+func (r *Cache[T]) untrackKey(key string) {
+	hash, conflict := z.KeyToHash(key)
+	r.untrack(hash, conflict)
+}
+
+// untrackItem removes the key of an entry evicted, expired or rejected by
+// ristretto from the tracked keys.
+func (r *Cache[T]) untrackItem(item *ristretto.Item[T]) {
+	r.untrack(item.Key, item.Conflict)
+}
+
+func (r *Cache[T]) untrack(hash, conflict uint64) {
+	r.keysMu.Lock()
+	defer r.keysMu.Unlock()
+	if tracked, ok := r.keys[hash]; ok && tracked.conflict == conflict {
+		delete(r.keys, hash)
+	}
+}
+
 // Delete removes the specified key/value from the cache.
 func (r *Cache[T]) Delete(_ context.Context, key string) error {
 	r.cache.Del(key)
+	r.untrackKey(key)
 	// Note: ristretto does not return a bool for delete.
 	// Delete ops are eventually consistent and we don't want to block on them.
 	return nil
 }
+
+// DeleteByPrefix removes all key/values whose key starts with the specified
+// prefix.
+func (r *Cache[T]) DeleteByPrefix(_ context.Context, prefix string) error {
+	var keys []string
+	r.keysMu.Lock()
+	for hash, tracked := range r.keys {
+		if strings.HasPrefix(tracked.key, prefix) {
+			keys = append(keys, tracked.key)
+			delete(r.keys, hash)
+		}
+	}
+	r.keysMu.Unlock()
+
+	// Delete the entries without holding keysMu, as ristretto may block on
+	// the eviction callbacks taking it.
+	for _, key := range keys {
+		r.cache.Del(key)
+	}
+	return nil
+}
@@ -21,6 +21,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/dgraph-io/ristretto/v2"
+	"github.com/dgraph-io/ristretto/v2/z"
 	"github.com/notaryproject/ratify/v2/internal/cache"
 )
 
@@ -111,6 +113,36 @@ func TestRistrettoCacheGet(t *testing.T) {
 	}
 }
 
+func TestRistrettoCacheGetWithTTL(t *testing.T) {
+	cacheInstance, err := NewCache[string](1 * time.Second)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	ttlReader, ok := cacheInstance.(cache.TTLReader[string])
+	if !ok {
+		t.Fatal("expected cache to implement TTLReader")
+	}
+	ctx := context.Background()
+
+	if _, _, err := ttlReader.GetWithTTL(ctx, "nonexistent"); !errors.Is(err, cache.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	if err := cacheInstance.Set(ctx, testKey, testValue, time.Minute); err != nil {
+		t.Fatalf("failed to set value: %v", err)
+	}
+	val, ttl, err := ttlReader.GetWithTTL(ctx, testKey)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if val != testValue {
+		t.Errorf("expected %v, got %v", testValue, val)
+	}
+	if ttl <= 0 || ttl > time.Minute {
+		t.Errorf("expected remaining ttl within a minute, got %v", ttl)
+	}
+}
+
 func TestRistrettoCacheSet(t *testing.T) {
 	// Test with string type
 	t.Run("string cache", func(t *testing.T) {
@@ -326,3 +358,79 @@ func TestRistrettoCacheMultipleKeys(t *testing.T) {
 		}
 	}
 }
+
+func TestRistrettoCacheDeleteByPrefix(t *testing.T) {
+	cacheInstance, err := NewCache[string](time.Minute)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+
+	for _, key := range []string{"verify_a:v1", "verify_a:v2", "verify_b:v1"} {
+		if err := cacheInstance.Set(ctx, key, testValue, 0); err != nil {
+			t.Fatalf("failed to set value: %v", err)
+		}
+	}
+
+	if err := cacheInstance.DeleteByPrefix(ctx, "verify_a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, key := range []string{"verify_a:v1", "verify_a:v2"} {
+		if _, err := cacheInstance.Get(ctx, key); !errors.Is(err, cache.ErrNotFound) {
+			t.Errorf("expected key %s to be deleted, got %v", key, err)
+		}
+	}
+	if _, err := cacheInstance.Get(ctx, "verify_b:v1"); err != nil {
+		t.Errorf("expected key verify_b:v1 to be kept, got %v", err)
+	}
+
+	if err := cacheInstance.DeleteByPrefix(ctx, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := cacheInstance.Get(ctx, "verify_b:v1"); !errors.Is(err, cache.ErrNotFound) {
+		t.Errorf("expected all keys to be deleted, got %v", err)
+	}
+}
+
+func TestRistrettoCacheUntrackItem(t *testing.T) {
+	cacheInstance, err := NewCache[string](time.Minute)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	r := cacheInstance.(*Cache[string])
+	ctx := context.Background()
+
+	for _, key := range []string{"verify_a:v1", "verify_a:v2", "verify_b:v1"} {
+		if err := r.Set(ctx, key, testValue, 0); err != nil {
+			t.Fatalf("failed to set value: %v", err)
+		}
+	}
+	if len(r.keys) != 3 {
+		t.Fatalf("expected 3 tracked keys, got %d", len(r.keys))
+	}
+
+	// An evicted entry is untracked, while an entry of the same hash but a
+	// different key is not.
+	hash, conflict := z.KeyToHash("verify_a:v1")
+	r.untrackItem(&ristretto.Item[string]{Key: hash, Conflict: conflict + 1})
+	if len(r.keys) != 3 {
+		t.Errorf("expected 3 tracked keys after evicting a conflicting key, got %d", len(r.keys))
+	}
+	r.untrackItem(&ristretto.Item[string]{Key: hash, Conflict: conflict})
+	if _, ok := r.keys[hash]; ok || len(r.keys) != 2 {
+		t.Errorf("expected verify_a:v1 to be untracked, got %v", r.keys)
+	}
+
+	if err := r.Delete(ctx, "verify_b:v1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(r.keys) != 1 {
+		t.Errorf("expected 1 tracked key after delete, got %d", len(r.keys))
+	}
+	if err := r.DeleteByPrefix(ctx, "verify_a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(r.keys) != 0 {
+		t.Errorf("expected no tracked keys after delete by prefix, got %d", len(r.keys))
+	}
+}
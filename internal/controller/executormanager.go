@@ -34,6 +34,9 @@ type executorManager struct {
 	mutex    sync.Mutex
 	opts     map[string]*e.ScopedOptions
 	executor atomic.Pointer[e.ScopedExecutor]
+	err      error
+	// generation counts the executors created from the options.
+	generation uint64
 }
 
 // GlobalExecutorManager is an instance of executorManager that is used by
@@ -52,6 +55,15 @@ func (m *executorManager) GetExecutor() *e.ScopedExecutor {
 	return m.executor.Load()
 }
 
+// Err returns the error of the last attempt to refresh the executor. It
+// returns nil if the last refresh succeeded. The previously created executor
+// keeps serving requests while Err returns a non-nil error.
+func (m *executorManager) Err() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.err
+}
+
 // upsertExecutor updates or inserts an executor instance under the given
 // namespace and name.
 func (m *executorManager) upsertExecutor(namespace, name string, opts *configv2alpha1.Executor) error {
@@ -86,10 +98,19 @@ func (m *executorManager) deleteExecutor(namespace, name string) error {
 	return fmt.Errorf("executor resource: %s/%s is not found", namespace, name)
 }
 
-// refreshExecutor creates a new executor instance based on the current options.
+// refreshExecutor creates a new executor instance based on the current options
+// and records the outcome to be reported by Err.
 func (m *executorManager) refreshExecutor() error {
+	m.err = m.createExecutor()
+	return m.err
+}
+
+// createExecutor creates a new executor instance based on the current options.
+func (m *executorManager) createExecutor() error {
+	m.generation++
 	opts := &e.Options{
-		Executors: make([]*e.ScopedOptions, len(m.opts)),
+		Executors:  make([]*e.ScopedOptions, len(m.opts)),
+		Generation: m.generation,
 	}
 	i := 0
 	for _, scopedOpts := range m.opts {
@@ -113,7 +134,11 @@ func (m *executorManager) refreshExecutor() error {
 // ScopedOptions.
 func convertOptions(opts *configv2alpha1.Executor) (*e.ScopedOptions, error) {
 	scopedOpts := &e.ScopedOptions{
-		Scopes: opts.Spec.Scopes,
+		Scopes:    opts.Spec.Scopes,
+		IndexMode: opts.Spec.IndexMode,
+		Layered:   opts.Spec.Layered,
+		AuditOnly: opts.Spec.AuditOnly,
+		Cache:     convertCacheOptions(opts.Spec.Cache),
 	}
 
 	verifierOpts, err := convertVerifierOptions(opts.Spec.Verifiers)
@@ -176,6 +201,16 @@ func convertPolicyOptions(policy *configv2alpha1.PolicyEnforcerOptions) *policye
 	}
 }
 
+func convertCacheOptions(cache *configv2alpha1.CacheOptions) *e.CacheOptions {
+	if cache == nil {
+		return nil
+	}
+	return &e.CacheOptions{
+		TTL:        cache.TTL,
+		FailureTTL: cache.FailureTTL,
+	}
+}
+
 func createOptsKey(namespace, name string) string {
 	return fmt.Sprintf("%s/%s", namespace, name)
 }
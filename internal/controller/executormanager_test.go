@@ -19,6 +19,7 @@ package controller
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/notaryproject/ratify-go"
 	configv2alpha1 "github.com/notaryproject/ratify/v2/api/v2alpha1"
@@ -141,6 +142,84 @@ func TestUpsertExecutor_InvalidOpts(t *testing.T) {
 	}
 }
 
+func TestUpsertExecutor_IndexMode(t *testing.T) {
+	mgr := executorManager{opts: map[string]*e.ScopedOptions{}}
+	executorOpts := newValidExecutor()
+	executorOpts.Spec.IndexMode = string(e.IndexModeChildrenAll)
+	if err := mgr.upsertExecutor("default", "exec1", executorOpts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := mgr.opts[createOptsKey("default", "exec1")].IndexMode; got != string(e.IndexModeChildrenAll) {
+		t.Fatalf("expected index mode %s, got %q", e.IndexModeChildrenAll, got)
+	}
+
+	executorOpts.Spec.IndexMode = "invalid"
+	if err := mgr.upsertExecutor("default", "exec1", executorOpts); err == nil {
+		t.Fatalf("expected error for invalid index mode, got nil")
+	}
+}
+
+func TestUpsertExecutor_Layered(t *testing.T) {
+	mgr := executorManager{opts: map[string]*e.ScopedOptions{}}
+	executorOpts := newValidExecutor()
+	executorOpts.Spec.Layered = true
+	if err := mgr.upsertExecutor("default", "exec1", executorOpts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !mgr.opts[createOptsKey("default", "exec1")].Layered {
+		t.Fatalf("expected layered executor options")
+	}
+}
+
+func TestUpsertExecutor_AuditOnly(t *testing.T) {
+	mgr := executorManager{opts: map[string]*e.ScopedOptions{}}
+	executorOpts := newValidExecutor()
+	executorOpts.Spec.AuditOnly = true
+	if err := mgr.upsertExecutor("default", "exec1", executorOpts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !mgr.opts[createOptsKey("default", "exec1")].AuditOnly {
+		t.Fatalf("expected audit-only executor options")
+	}
+}
+
+func TestUpsertExecutor_Cache(t *testing.T) {
+	mgr := executorManager{opts: map[string]*e.ScopedOptions{}}
+	executorOpts := newValidExecutor()
+	executorOpts.Spec.Cache = &configv2alpha1.CacheOptions{
+		TTL:        "10m",
+		FailureTTL: "30s",
+	}
+	if err := mgr.upsertExecutor("default", "exec1", executorOpts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := e.CacheTTL{Success: 10 * time.Minute, Failure: 30 * time.Second}
+	if got := mgr.GetExecutor().CacheTTL("example.com/test/image:v1"); got != expected {
+		t.Fatalf("expected cache ttl %+v, got %+v", expected, got)
+	}
+
+	executorOpts.Spec.Cache.TTL = "invalid"
+	if err := mgr.upsertExecutor("default", "exec1", executorOpts); err == nil {
+		t.Fatalf("expected error for invalid cache ttl, got nil")
+	}
+}
+
+func TestUpsertExecutor_ReloadChangesFingerprint(t *testing.T) {
+	mgr := executorManager{opts: map[string]*e.ScopedOptions{}}
+	if err := mgr.upsertExecutor("default", "exec1", newValidExecutor()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	before := mgr.GetExecutor().Fingerprint("example.com/test/image:v1")
+
+	// Identical options may reference changed trust material.
+	if err := mgr.upsertExecutor("default", "exec1", newValidExecutor()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if after := mgr.GetExecutor().Fingerprint("example.com/test/image:v1"); after == before {
+		t.Fatalf("expected a new fingerprint after the refresh, got %q", after)
+	}
+}
+
 func TestUpsertExecutor_UpdateExistingEntry(t *testing.T) {
 	mgr := executorManager{opts: map[string]*e.ScopedOptions{}}
 
@@ -209,3 +288,32 @@ func TestDeleteExecutor_RemoveExistingEntry(t *testing.T) {
 		t.Fatalf("expected non-nil executor after deletion")
 	}
 }
+
+func TestErr_ReflectsLastRefresh(t *testing.T) {
+	mgr := executorManager{opts: map[string]*e.ScopedOptions{}}
+
+	if err := mgr.upsertExecutor("default", "exec1", newValidExecutor()); err != nil {
+		t.Fatalf("failed to upsert exec1: %v", err)
+	}
+	if err := mgr.Err(); err != nil {
+		t.Fatalf("expected no error after successful refresh, got %v", err)
+	}
+
+	// A second executor with the same scope conflicts with exec1.
+	if err := mgr.upsertExecutor("default", "exec2", newValidExecutor()); err == nil {
+		t.Fatalf("expected error when scopes conflict, got nil")
+	}
+	if err := mgr.Err(); err == nil {
+		t.Fatalf("expected error after failed refresh, got nil")
+	}
+	if exec := mgr.GetExecutor(); exec == nil {
+		t.Fatalf("expected previous executor to be kept after failed refresh")
+	}
+
+	if err := mgr.deleteExecutor("default", "exec2"); err != nil {
+		t.Fatalf("failed to delete exec2: %v", err)
+	}
+	if err := mgr.Err(); err != nil {
+		t.Fatalf("expected no error after recovery, got %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// podImageLister lists the container images of the running Pods in the
+// cluster.
+type podImageLister struct {
+	mutex  sync.Mutex
+	reader client.Reader
+	ready  chan struct{}
+}
+
+// GlobalPodImageLister is an instance of podImageLister that is set up by the
+// manager and used to pre-warm the caches with the images of running Pods.
+var GlobalPodImageLister = newPodImageLister()
+
+func newPodImageLister() *podImageLister {
+	return &podImageLister{
+		ready: make(chan struct{}),
+	}
+}
+
+// SetReader sets the reader used to list the Pods. It unblocks pending calls
+// to ListImages.
+func (l *podImageLister) SetReader(reader client.Reader) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.reader == nil {
+		close(l.ready)
+	}
+	l.reader = reader
+}
+
+// ListImages returns the deduplicated images of the containers, init
+// containers and ephemeral containers of all Pods that are pending or
+// running. It blocks until the reader is set or ctx is done.
+func (l *podImageLister) ListImages(ctx context.Context) ([]string, error) {
+	select {
+	case <-l.ready:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("pod image lister is not set up: %w", ctx.Err())
+	}
+	l.mutex.Lock()
+	reader := l.reader
+	l.mutex.Unlock()
+
+	var pods corev1.PodList
+	if err := reader.List(ctx, &pods); err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	var images []string
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, container := range pod.Spec.InitContainers {
+			images = append(images, container.Image)
+		}
+		for _, container := range pod.Spec.Containers {
+			images = append(images, container.Image)
+		}
+		for _, container := range pod.Spec.EphemeralContainers {
+			images = append(images, container.Image)
+		}
+	}
+	slices.Sort(images)
+	return slices.Compact(images), nil
+}
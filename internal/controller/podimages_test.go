@@ -0,0 +1,89 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPodImageLister_ListImages(t *testing.T) {
+	pods := []*corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-a"},
+			Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "init", Image: "registry.example.com/init:v1"}},
+				Containers: []corev1.Container{
+					{Name: "app", Image: "registry.example.com/app:v1"},
+					{Name: "sidecar", Image: "registry.example.com/sidecar:v1"},
+				},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-b"},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "app", Image: "registry.example.com/app:v1"}},
+				EphemeralContainers: []corev1.EphemeralContainer{
+					{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debug", Image: "registry.example.com/debug:v1"}},
+				},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodPending},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "job", Namespace: "team-a"},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "job", Image: "registry.example.com/job:v1"}},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodSucceeded},
+		},
+	}
+	builder := fake.NewClientBuilder()
+	for _, pod := range pods {
+		builder = builder.WithObjects(pod)
+	}
+
+	lister := newPodImageLister()
+	lister.SetReader(builder.Build())
+	images, err := lister.ListImages(context.Background())
+	if err != nil {
+		t.Fatalf("failed to list images: %v", err)
+	}
+	expected := []string{
+		"registry.example.com/app:v1",
+		"registry.example.com/debug:v1",
+		"registry.example.com/init:v1",
+		"registry.example.com/sidecar:v1",
+	}
+	if !slices.Equal(images, expected) {
+		t.Errorf("expected images %v, got %v", expected, images)
+	}
+}
+
+func TestPodImageLister_NotSetUp(t *testing.T) {
+	lister := newPodImageLister()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := lister.ListImages(ctx); err == nil {
+		t.Error("expected error if the reader is not set")
+	}
+}
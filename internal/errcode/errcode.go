@@ -0,0 +1,270 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"oras.land/oras-go/v2/errdef"
+	orasErrcode "oras.land/oras-go/v2/registry/remote/errcode"
+)
+
+// Category groups error codes by their root cause.
+type Category string
+
+const (
+	// CategoryConfiguration indicates that Ratify is not configured to handle
+	// the request.
+	CategoryConfiguration Category = "configuration"
+
+	// CategoryInvalidInput indicates that the request itself is malformed.
+	CategoryInvalidInput Category = "invalidInput"
+
+	// CategoryAuthentication indicates that Ratify failed to authenticate
+	// against a remote service such as a registry.
+	CategoryAuthentication Category = "authentication"
+
+	// CategoryNotFound indicates that the requested artifact does not exist.
+	CategoryNotFound Category = "notFound"
+
+	// CategoryRegistry indicates that the registry failed to serve the request.
+	CategoryRegistry Category = "registry"
+
+	// CategoryTimeout indicates that the operation did not complete in time.
+	CategoryTimeout Category = "timeout"
+
+	// CategoryOverload indicates that Ratify rejected the request to protect
+	// itself and the registries from excessive load.
+	CategoryOverload Category = "overload"
+
+	// CategoryVerification indicates that an artifact failed verification.
+	CategoryVerification Category = "verification"
+
+	// CategoryInternal indicates an unexpected error.
+	CategoryInternal Category = "internal"
+)
+
+// Code is a stable, machine-readable identifier of an error condition.
+type Code string
+
+const (
+	// ExecutorNotConfigured is returned when no executor has been loaded yet.
+	ExecutorNotConfigured Code = "EXECUTOR_NOT_CONFIGURED"
+
+	// ScopeNotMatched is returned when no executor is configured for the
+	// scope of the artifact.
+	ScopeNotMatched Code = "SCOPE_NOT_MATCHED"
+
+	// InvalidReference is returned when the artifact reference is malformed.
+	InvalidReference Code = "INVALID_REFERENCE"
+
+	// InvalidRequest is returned when the request body is malformed, e.g. it
+	// is not valid JSON.
+	InvalidRequest Code = "INVALID_REQUEST"
+
+	// RequestTooLarge is returned when the request body or the number of keys
+	// exceeds the configured limits.
+	RequestTooLarge Code = "REQUEST_TOO_LARGE"
+
+	// RegistryAuthFailed is returned when registry credentials cannot be
+	// obtained or are rejected by the registry.
+	RegistryAuthFailed Code = "REGISTRY_AUTH_FAILED"
+
+	// SubjectNotFound is returned when the subject or one of its referrers
+	// cannot be found.
+	SubjectNotFound Code = "SUBJECT_NOT_FOUND"
+
+	// RegistryUnavailable is returned when the registry is throttling or
+	// failing requests.
+	RegistryUnavailable Code = "REGISTRY_UNAVAILABLE"
+
+	// Timeout is returned when the operation exceeds its deadline.
+	Timeout Code = "TIMEOUT"
+
+	// Overloaded is returned when the request is shed because the limits of
+	// in-flight and queued validations are reached.
+	Overloaded Code = "OVERLOADED"
+
+	// VerificationFailed is returned when a verifier rejects an artifact, e.g.
+	// the signature is invalid or not trusted.
+	VerificationFailed Code = "VERIFICATION_FAILED"
+
+	// Unknown is returned when the error cannot be classified.
+	Unknown Code = "UNKNOWN"
+)
+
+// descriptor describes the properties shared by all errors of a code.
+type descriptor struct {
+	category    Category
+	retryable   bool
+	remediation string
+}
+
+var descriptors = map[Code]descriptor{
+	ExecutorNotConfigured: {
+		category:    CategoryConfiguration,
+		retryable:   true,
+		remediation: "Wait for the executor configuration to be loaded and check the Executor resources or configuration file for errors.",
+	},
+	ScopeNotMatched: {
+		category:    CategoryConfiguration,
+		remediation: "Add a scope covering the registry or repository of the artifact to an executor.",
+	},
+	InvalidReference: {
+		category:    CategoryInvalidInput,
+		remediation: "Use a fully qualified reference in the form registry/repository[:tag|@digest].",
+	},
+	InvalidRequest: {
+		category:    CategoryInvalidInput,
+		remediation: "Send a request body that is valid JSON and matches the schema of the API.",
+	},
+	RequestTooLarge: {
+		category:    CategoryInvalidInput,
+		remediation: "Reduce the size of the request body or the number of keys, or raise the configured request limits.",
+	},
+	RegistryAuthFailed: {
+		category:    CategoryAuthentication,
+		retryable:   true,
+		remediation: "Check the credential provider configuration of the store and the permissions of the identity used to access the registry.",
+	},
+	SubjectNotFound: {
+		category:    CategoryNotFound,
+		remediation: "Check that the artifact exists in the registry and that the reference is spelled correctly.",
+	},
+	RegistryUnavailable: {
+		category:    CategoryRegistry,
+		retryable:   true,
+		remediation: "Retry later. Check the registry health and throttling limits if the problem persists.",
+	},
+	Timeout: {
+		category:    CategoryTimeout,
+		retryable:   true,
+		remediation: "Retry the request. Consider increasing the verification timeout if the problem persists.",
+	},
+	Overloaded: {
+		category:    CategoryOverload,
+		retryable:   true,
+		remediation: "Retry later. Consider raising the in-flight and queue limits or scaling out Ratify if the problem persists.",
+	},
+	VerificationFailed: {
+		category:    CategoryVerification,
+		remediation: "Check that the artifact is signed by a trusted identity and matches the configured trust policy.",
+	},
+	Unknown: {
+		category: CategoryInternal,
+	},
+}
+
+// Category returns the category of the code.
+func (c Code) Category() Category {
+	if d, ok := descriptors[c]; ok {
+		return d.category
+	}
+	return CategoryInternal
+}
+
+// Retryable reports whether a request failed with the code may succeed if
+// retried without changing the configuration.
+func (c Code) Retryable() bool {
+	return descriptors[c].retryable
+}
+
+// Remediation returns a human readable hint on how to resolve the error.
+func (c Code) Remediation() string {
+	return descriptors[c].remediation
+}
+
+// Wrap annotates err with the code. It returns nil if err is nil.
+func (c Code) Wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{
+		Code: c,
+		Err:  err,
+	}
+}
+
+// Errorf formats an error according to a format specifier and annotates it
+// with the code.
+func (c Code) Errorf(format string, a ...any) error {
+	return c.Wrap(fmt.Errorf(format, a...))
+}
+
+// Error is an error annotated with a [Code].
+type Error struct {
+	// Code is the classification of the error. Required.
+	Code Code
+
+	// Err is the underlying error. Required.
+	Err error
+}
+
+// Error returns the message of the underlying error.
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Classify returns the code of err. Errors annotated by [Code.Wrap] are
+// reported with their code, well-known errors from the context, network and
+// registry packages are mapped to the closest code, and all other errors are
+// reported as [Unknown]. It returns an empty code if err is nil.
+func Classify(err error) Code {
+	if err == nil {
+		return ""
+	}
+
+	var codedErr *Error
+	if errors.As(err, &codedErr) {
+		return codedErr.Code
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return Timeout
+	}
+
+	var respErr *orasErrcode.ErrorResponse
+	if errors.As(err, &respErr) {
+		switch {
+		case respErr.StatusCode == http.StatusUnauthorized || respErr.StatusCode == http.StatusForbidden:
+			return RegistryAuthFailed
+		case respErr.StatusCode == http.StatusNotFound:
+			return SubjectNotFound
+		case respErr.StatusCode == http.StatusTooManyRequests || respErr.StatusCode >= http.StatusInternalServerError:
+			return RegistryUnavailable
+		}
+	}
+	if errors.Is(err, errdef.ErrNotFound) {
+		return SubjectNotFound
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return Timeout
+		}
+		return RegistryUnavailable
+	}
+	return Unknown
+}
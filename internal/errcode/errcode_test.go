@@ -0,0 +1,121 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"oras.land/oras-go/v2/errdef"
+	orasErrcode "oras.land/oras-go/v2/registry/remote/errcode"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected Code
+	}{
+		{
+			name:     "nil error",
+			err:      nil,
+			expected: "",
+		},
+		{
+			name:     "coded error",
+			err:      ScopeNotMatched.Errorf("no executor"),
+			expected: ScopeNotMatched,
+		},
+		{
+			name:     "wrapped coded error",
+			err:      fmt.Errorf("failed to resolve: %w", RegistryAuthFailed.Wrap(errors.New("token exchange failed"))),
+			expected: RegistryAuthFailed,
+		},
+		{
+			name:     "deadline exceeded",
+			err:      fmt.Errorf("failed: %w", context.DeadlineExceeded),
+			expected: Timeout,
+		},
+		{
+			name:     "registry unauthorized",
+			err:      &orasErrcode.ErrorResponse{StatusCode: http.StatusUnauthorized},
+			expected: RegistryAuthFailed,
+		},
+		{
+			name:     "registry not found",
+			err:      &orasErrcode.ErrorResponse{StatusCode: http.StatusNotFound},
+			expected: SubjectNotFound,
+		},
+		{
+			name:     "registry throttling",
+			err:      &orasErrcode.ErrorResponse{StatusCode: http.StatusTooManyRequests},
+			expected: RegistryUnavailable,
+		},
+		{
+			name:     "not found",
+			err:      fmt.Errorf("image:v1: %w", errdef.ErrNotFound),
+			expected: SubjectNotFound,
+		},
+		{
+			name:     "unknown error",
+			err:      errors.New("unknown"),
+			expected: Unknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.expected {
+				t.Errorf("Classify() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCode_Descriptor(t *testing.T) {
+	if Timeout.Category() != CategoryTimeout || !Timeout.Retryable() {
+		t.Errorf("unexpected descriptor for %s", Timeout)
+	}
+	if Overloaded.Category() != CategoryOverload || !Overloaded.Retryable() {
+		t.Errorf("unexpected descriptor for %s", Overloaded)
+	}
+	if InvalidRequest.Category() != CategoryInvalidInput || InvalidRequest.Retryable() {
+		t.Errorf("unexpected descriptor for %s", InvalidRequest)
+	}
+	if VerificationFailed.Retryable() {
+		t.Errorf("expected %s to be non-retryable", VerificationFailed)
+	}
+	if Code("NOT_REGISTERED").Category() != CategoryInternal {
+		t.Errorf("expected unregistered code to fall back to %s", CategoryInternal)
+	}
+}
+
+func TestCode_Wrap(t *testing.T) {
+	if err := Timeout.Wrap(nil); err != nil {
+		t.Errorf("expected nil error, got %v", err)
+	}
+	inner := errors.New("inner")
+	err := SubjectNotFound.Wrap(inner)
+	if !errors.Is(err, inner) {
+		t.Errorf("expected wrapped error to match the inner error")
+	}
+	if err.Error() != inner.Error() {
+		t.Errorf("expected message %q, got %q", inner.Error(), err.Error())
+	}
+}
@@ -18,13 +18,21 @@ package executor
 import (
 	"context"
 	"fmt"
+	"iter"
+	"maps"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/errcode"
+	"github.com/notaryproject/ratify/v2/internal/logger"
 	"github.com/notaryproject/ratify/v2/internal/policyenforcer"
 	"github.com/notaryproject/ratify/v2/internal/store"
+	"github.com/notaryproject/ratify/v2/internal/tracing"
 	"github.com/notaryproject/ratify/v2/internal/verifier"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"go.opentelemetry.io/otel/attribute"
 
 	"oras.land/oras-go/v2/registry"
 )
@@ -45,6 +53,29 @@ type ScopedOptions struct {
 	// Policy contains the configuration options for the policy enforcer.
 	// Optional.
 	Policy *policyenforcer.NewOptions `json:"policyEnforcer,omitempty"`
+
+	// Cache overrides the global cache options for artifacts under the scopes.
+	// Unset fields fall back to the global cache options. Optional.
+	Cache *CacheOptions `json:"cache,omitempty"`
+
+	// IndexMode defines how subjects that are image indexes or manifest lists
+	// are validated. One of "index-only", "children-all", "children-any" or
+	// "platform". Defaults to "index-only". Optional.
+	IndexMode string `json:"indexMode,omitempty"`
+
+	// Layered evaluates the executor also for artifacts routed to the
+	// executors of more specific scopes, e.g. to enforce an organization-wide
+	// baseline beneath the requirements of individual teams. An artifact
+	// passes only if every executor evaluated for it passes. Layered executors
+	// can share their scopes with each other and with one executor that is not
+	// layered. Optional.
+	Layered bool `json:"layered,omitempty"`
+
+	// AuditOnly runs the full validation of the executor and reports its
+	// outcome, but does not fail artifacts it rejects. Such artifacts are
+	// flagged as would have failed and logged instead, e.g. to observe a new
+	// verifier or policy before enforcing it. Optional.
+	AuditOnly bool `json:"auditOnly,omitempty"`
 }
 
 // Options contains the configuration options to create a scoped executor.
@@ -54,30 +85,185 @@ type Options struct {
 	// enforcer. At least one executor must be provided.
 	// Required.
 	Executors []*ScopedOptions `json:"executors"`
+
+	// Cache contains the global options for caching validation results.
+	// Optional.
+	Cache *CacheOptions `json:"cache,omitempty"`
+
+	// Generation identifies the load of the options. Loaders increment it
+	// whenever they swap the executor, so that results cached under a previous
+	// load are not served even if the options are unchanged, e.g. because the
+	// certificates or secrets they reference changed. It is not read from the
+	// configuration. Optional.
+	Generation uint64 `json:"-"`
+}
+
+// CacheOptions contains the configuration options for caching validation
+// results. Durations are in the format accepted by [time.ParseDuration], e.g.
+// "30s" or "5m".
+type CacheOptions struct {
+	// TTL is the duration to cache succeeded validation results and resolved
+	// digests. The server default is used if not set. Optional.
+	TTL string `json:"ttl,omitempty"`
+
+	// FailureTTL is the duration to cache failed validation results and
+	// validation errors. If not set, failed results are cached for TTL and
+	// errors are not cached. Optional.
+	FailureTTL string `json:"failureTTL,omitempty"`
 }
 
+// CacheTTL contains the durations to cache validation results. A zero
+// duration means the value is not configured.
+type CacheTTL struct {
+	// Success is the duration to cache succeeded validation results.
+	Success time.Duration
+
+	// Failure is the duration to cache failed validation results and errors.
+	Failure time.Duration
+}
+
+// parse converts the cache options into a [CacheTTL] with unset fields taken
+// from base.
+func (o *CacheOptions) parse(base CacheTTL) (CacheTTL, error) {
+	if o == nil {
+		return base, nil
+	}
+	ttl := base
+	var err error
+	if o.TTL != "" {
+		if ttl.Success, err = parseTTL(o.TTL); err != nil {
+			return CacheTTL{}, fmt.Errorf("invalid cache ttl %q: %w", o.TTL, err)
+		}
+	}
+	if o.FailureTTL != "" {
+		if ttl.Failure, err = parseTTL(o.FailureTTL); err != nil {
+			return CacheTTL{}, fmt.Errorf("invalid cache failureTTL %q: %w", o.FailureTTL, err)
+		}
+	}
+	return ttl, nil
+}
+
+func parseTTL(value string) (time.Duration, error) {
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if ttl <= 0 {
+		return 0, fmt.Errorf("duration must be positive")
+	}
+	return ttl, nil
+}
+
+// catchAllScope is the scope matching any artifact not matched by another
+// scope.
+const catchAllScope = "*"
+
 // ScopedExecutor manages multiple ratify.Executor instances, each associated
 // with specific scopes (registries or repositories). It provides a mechanism to
 // route artifact validation requests to the appropriate executor based on the
 // artifact's reference.
 //
-// The executor supports three types of scope patterns:
+// The executor supports five types of scope patterns:
 //   - Wildcard registries: "*.example.com" matches any subdomain of example.com
 //   - Specific registries: "registry.example.com" matches only that registry
 //   - Repository paths: "registry.example.com/namespace/repo" matches a
 //     specific repository
+//   - Repository patterns: "registry.example.com/namespace/*" matches the
+//     repositories directly under namespace and
+//     "registry.example.com/namespace/**" matches the repositories at any
+//     depth under namespace. A "*" matches any characters within a
+//     repository component, e.g. "registry.example.com/team-*/app".
+//   - Catch-all: "*" matches any artifact not matched by another scope
 //
-// Note: Top level domain wildcard is also not supported. That is, "*" is not a
-// valid pattern.
 // Scope matching follows a precedence order from most specific to least
 // specific:
 //  1. Exact repository match
-//  2. Exact registry match
-//  3. Wildcard registry match
+//  2. Repository pattern match, preferring the longest prefix before the
+//     first wildcard, then the most literal characters, then a single-level
+//     pattern over a multi-level one
+//  3. Exact registry match
+//  4. Wildcard registry match
+//  5. Catch-all match
+//
+// Repository patterns of different executors that match a common repository
+// with the same precedence are rejected when the executor is created, unless
+// both executors are layered.
+//
+// An artifact is validated by the executor of the most specific matching
+// scope and by the layered executors of all more general matching scopes. The
+// validation succeeds only if every evaluated executor passes. Several
+// executors compose on the same scope if all but at most one of them are
+// layered; the executor that is not layered, if any, is the one the scope
+// routes to. Layering is opt-in so that an executor of a more specific scope
+// keeps overriding the executors of more general scopes unless they are
+// explicitly meant to apply beneath it.
 type ScopedExecutor struct {
 	wildcard   map[string]*ratify.Executor
 	registry   map[string]*ratify.Executor
 	repository map[string]*ratify.Executor
+
+	// repositoryPatterns contains the repository patterns per registry,
+	// sorted by precedence.
+	repositoryPatterns map[string][]*repositoryPattern
+
+	// catchAll is the executor of the "*" scope, if any.
+	catchAll *ratify.Executor
+
+	// stacked contains the further executors registered on a scope besides
+	// the one the scope routes to. They are all layered.
+	stacked map[string][]*ratify.Executor
+
+	// cacheTTL is the global cache TTL and scopedCacheTTL overrides it for
+	// the executors with scoped cache options.
+	cacheTTL       CacheTTL
+	scopedCacheTTL map[*ratify.Executor]CacheTTL
+
+	// indexModes contains the index mode of the executors that do not
+	// validate indexes in the default index-only mode.
+	indexModes map[*ratify.Executor]IndexMode
+
+	// layered contains the executors evaluated also for artifacts routed to
+	// the executors of more specific scopes.
+	layered map[*ratify.Executor]bool
+
+	// auditOnly contains the executors whose failures do not fail the
+	// validation.
+	auditOnly map[*ratify.Executor]bool
+
+	// fingerprints contains the fingerprint of the configuration of each
+	// executor and fingerprint is the fingerprint of the whole configuration.
+	fingerprints map[*ratify.Executor]string
+	fingerprint  string
+}
+
+// ValidateOptions contains the options to validate an artifact.
+type ValidateOptions struct {
+	ratify.ValidateArtifactOptions
+
+	// Platform selects the child manifest to validate if the subject is an
+	// image index and the matched executor is in the platform index mode.
+	// Optional.
+	Platform *ocispec.Platform
+}
+
+// ValidationResult is the result of validating an artifact with a
+// [ScopedExecutor].
+type ValidationResult struct {
+	*ratify.ValidationResult
+
+	// AuditOnlyScopes are the scopes of the audit-only executors that
+	// validated the artifact, if any.
+	AuditOnlyScopes []string
+
+	// WouldHaveFailed indicates that an audit-only executor rejected the
+	// artifact. Succeeded is not affected by the audit-only executors, while
+	// their reports keep the actual outcome.
+	WouldHaveFailed bool
+
+	// AuditOnlyErr is the error of the audit-only executors that could not
+	// complete the validation, e.g. because the registry is unavailable. The
+	// result is flagged as would have failed instead, if set.
+	AuditOnlyErr error
 }
 
 // NewScopedExecutor creates a new ScopedExecutor instance based on the provided
@@ -87,10 +273,22 @@ func NewScopedExecutor(opts *Options) (*ScopedExecutor, error) {
 	if opts == nil || len(opts.Executors) == 0 {
 		return nil, fmt.Errorf("at least 1 executor should be provided")
 	}
+	cacheTTL, err := opts.Cache.parse(CacheTTL{})
+	if err != nil {
+		return nil, err
+	}
 	scopedExecutor := &ScopedExecutor{
-		wildcard:   make(map[string]*ratify.Executor),
-		registry:   make(map[string]*ratify.Executor),
-		repository: make(map[string]*ratify.Executor),
+		wildcard:           make(map[string]*ratify.Executor),
+		registry:           make(map[string]*ratify.Executor),
+		repository:         make(map[string]*ratify.Executor),
+		repositoryPatterns: make(map[string][]*repositoryPattern),
+		stacked:            make(map[string][]*ratify.Executor),
+		cacheTTL:           cacheTTL,
+		scopedCacheTTL:     make(map[*ratify.Executor]CacheTTL),
+		indexModes:         make(map[*ratify.Executor]IndexMode),
+		layered:            make(map[*ratify.Executor]bool),
+		auditOnly:          make(map[*ratify.Executor]bool),
+		fingerprints:       make(map[*ratify.Executor]string),
 	}
 
 	for _, executorOpts := range opts.Executors {
@@ -101,24 +299,51 @@ func NewScopedExecutor(opts *Options) (*ScopedExecutor, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to create executor: %w", err)
 		}
+		if executorOpts.Cache != nil {
+			scopedCacheTTL, err := executorOpts.Cache.parse(cacheTTL)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse cache options for scopes %v: %w", executorOpts.Scopes, err)
+			}
+			scopedExecutor.scopedCacheTTL[executor] = scopedCacheTTL
+		}
+		indexMode, err := parseIndexMode(executorOpts.IndexMode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse index mode for scopes %v: %w", executorOpts.Scopes, err)
+		}
+		if indexMode != IndexModeIndexOnly {
+			scopedExecutor.indexModes[executor] = indexMode
+		}
+		if executorOpts.Layered {
+			scopedExecutor.layered[executor] = true
+		}
+		if executorOpts.AuditOnly {
+			scopedExecutor.auditOnly[executor] = true
+		}
+		if scopedExecutor.fingerprints[executor], err = scopedFingerprint(opts.Generation, opts.Cache, executorOpts); err != nil {
+			return nil, fmt.Errorf("failed to fingerprint options for scopes %v: %w", executorOpts.Scopes, err)
+		}
 		for _, scope := range executorOpts.Scopes {
 			if err = scopedExecutor.registerExecutor(scope, executor); err != nil {
 				return nil, fmt.Errorf("failed to register executor for scope %q: %w", scope, err)
 			}
 		}
 	}
+	if scopedExecutor.fingerprint, err = combineFingerprints(slices.Collect(maps.Values(scopedExecutor.fingerprints))); err != nil {
+		return nil, fmt.Errorf("failed to fingerprint options: %w", err)
+	}
 	return scopedExecutor, nil
 }
 
 // newExecutor creates a new [ratify.Executor] instance based on the provided
 // options.
 func newExecutor(opts *ScopedOptions) (*ratify.Executor, error) {
-	verifiers, err := verifier.NewVerifiers(opts.Verifiers, opts.Scopes)
+	scopes := componentScopes(opts.Scopes)
+	verifiers, err := verifier.NewVerifiers(opts.Verifiers, scopes)
 	if err != nil {
 		return nil, err
 	}
 
-	storeMux, err := store.New(opts.Stores, opts.Scopes)
+	storeMux, err := store.New(opts.Stores, scopes)
 	if err != nil {
 		return nil, err
 	}
@@ -137,51 +362,235 @@ func newExecutor(opts *ScopedOptions) (*ratify.Executor, error) {
 // ValidateArtifact routes the artifact validation request to the appropriate
 // executor based on the artifact's reference. It returns the validation result
 // or an error if no matching executor is found.
-func (s *ScopedExecutor) ValidateArtifact(ctx context.Context, artifact string) (*ratify.ValidationResult, error) {
-	executor, err := s.matchExecutor(artifact)
+func (s *ScopedExecutor) ValidateArtifact(ctx context.Context, artifact string) (*ValidationResult, error) {
+	return s.ValidateArtifactWithOptions(ctx, ValidateOptions{
+		ValidateArtifactOptions: ratify.ValidateArtifactOptions{
+			Subject: artifact,
+		},
+	})
+}
+
+// ValidateArtifactWithOptions is like [ScopedExecutor.ValidateArtifact] but
+// accepts the full validation options, e.g. to restrict the verified
+// reference types. The executor is matched against opts.Subject.
+//
+// If the subject is an image index and the matched executor is not in the
+// index-only mode, the child manifests are validated instead and reported as
+// the artifacts of the index with their own reports nested.
+//
+// If layered executors of more general scopes also match the subject, every
+// executor validates it and is reported as an artifact of the subject with its
+// own reports nested. The validation succeeds only if all of them pass.
+//
+// Executors in the audit-only mode do not fail the validation. If they reject
+// the subject or cannot complete the validation, the result is flagged as
+// would have failed and a warning is logged.
+func (s *ScopedExecutor) ValidateArtifactWithOptions(ctx context.Context, opts ValidateOptions) (*ValidationResult, error) {
+	artifact := opts.Subject
+	layers, err := s.tracedMatchLayers(ctx, artifact)
 	if err != nil {
 		return nil, fmt.Errorf("failed to match executor for artifact %q: %w", artifact, err)
 	}
-	opts := ratify.ValidateArtifactOptions{
-		Subject: artifact,
+	log := logger.GetLogger(ctx, logOpt)
+	log.Debugf("validating artifact %s", artifact)
+	var result *ValidationResult
+	if len(layers) == 1 {
+		result, err = s.validateLayer(ctx, layers[0], opts)
+	} else {
+		result, err = s.validateLayers(ctx, layers, opts)
+	}
+	if err != nil {
+		log.Debugf("failed to validate artifact %s: %v", artifact, err)
+		return nil, err
+	}
+	if result.AuditOnlyErr != nil {
+		log.Warnf("artifact %s would have failed validation by the audit-only executors of scopes %v: %v", artifact, result.AuditOnlyScopes, result.AuditOnlyErr)
+	} else if result.WouldHaveFailed {
+		log.Warnf("artifact %s would have failed validation by the audit-only executors of scopes %v", artifact, result.AuditOnlyScopes)
 	}
-	return executor.ValidateArtifact(ctx, opts)
+	log.Debugf("validated artifact %s, succeeded: %t", artifact, result.Succeeded)
+	return result, nil
+}
+
+// validate validates the artifact with the executor in its index mode.
+func (s *ScopedExecutor) validate(ctx context.Context, executor *ratify.Executor, opts ValidateOptions) (*ratify.ValidationResult, error) {
+	if mode, ok := s.indexModes[executor]; ok {
+		return validateIndex(ctx, executor, mode, opts)
+	}
+	return executor.ValidateArtifact(ctx, opts.ValidateArtifactOptions)
 }
 
 // Resolve retrieves the descriptor for the specified artifact by routing the
 // request to the appropriate executor based on the artifact's reference.
 // It returns the descriptor or an error if no matching executor is found.
 func (s *ScopedExecutor) Resolve(ctx context.Context, artifact string) (ocispec.Descriptor, error) {
-	executor, err := s.matchExecutor(artifact)
+	executor, err := s.tracedMatchExecutor(ctx, artifact)
 	if err != nil {
 		return ocispec.Descriptor{}, fmt.Errorf("failed to match executor for artifact %q: %w", artifact, err)
 	}
 	return executor.Store.Resolve(ctx, artifact)
 }
 
+// CacheTTL returns the durations to cache validation results of the specified
+// artifact. Scoped cache options take precedence over the global ones, which
+// are also returned if the artifact does not match any executor. If several
+// layered executors validate the artifact, the shortest configured durations
+// of them are returned.
+func (s *ScopedExecutor) CacheTTL(artifact string) CacheTTL {
+	layers, err := s.matchLayers(artifact)
+	if err != nil {
+		return s.cacheTTL
+	}
+	var cacheTTL CacheTTL
+	for _, l := range layers {
+		ttl, ok := s.scopedCacheTTL[l.executor]
+		if !ok {
+			ttl = s.cacheTTL
+		}
+		cacheTTL.Success = shorterTTL(cacheTTL.Success, ttl.Success)
+		cacheTTL.Failure = shorterTTL(cacheTTL.Failure, ttl.Failure)
+	}
+	return cacheTTL
+}
+
+// shorterTTL returns the shorter of two durations, where zero means not
+// configured.
+func shorterTTL(a, b time.Duration) time.Duration {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// Fingerprint returns the fingerprint of the configuration of the executors
+// validating the artifact, combined if several layered executors validate it.
+// It changes whenever that configuration changes, so that results cached
+// under a previous configuration are not served. The fingerprint of the whole
+// configuration is returned if the artifact does not match any executor.
+func (s *ScopedExecutor) Fingerprint(artifact string) string {
+	layers, err := s.matchLayers(artifact)
+	if err != nil {
+		return s.fingerprint
+	}
+	if len(layers) == 1 {
+		return s.fingerprints[layers[0].executor]
+	}
+	fingerprints := make([]string, len(layers))
+	for i, l := range layers {
+		fingerprints[i] = s.fingerprints[l.executor]
+	}
+	combined, err := combineFingerprints(fingerprints)
+	if err != nil {
+		return s.fingerprint
+	}
+	return combined
+}
+
+var logOpt = logger.Option{
+	ComponentType: logger.Executor,
+}
+
+// tracedMatchExecutor calls matchExecutor within a span.
+func (s *ScopedExecutor) tracedMatchExecutor(ctx context.Context, artifact string) (*ratify.Executor, error) {
+	_, span := tracing.StartSpan(ctx, "ScopedExecutor.matchExecutor", attribute.String("ratify.artifact", artifact))
+	executor, err := s.matchExecutor(artifact)
+	tracing.EndSpan(span, err)
+	return executor, err
+}
+
+// MatchScope returns the scope of the executor the artifact is routed to,
+// e.g. "registry.example.com/namespace/repo" or "*.example.com".
+func (s *ScopedExecutor) MatchScope(artifact string) (string, error) {
+	scope, _, err := s.match(artifact)
+	return scope, err
+}
+
+// AuditOnly reports whether the executor the artifact is routed to is in the
+// audit-only mode.
+func (s *ScopedExecutor) AuditOnly(artifact string) bool {
+	executor, err := s.matchExecutor(artifact)
+	return err == nil && s.auditOnly[executor]
+}
+
 // matchExecutor finds the appropriate executor for the given artifact.
 func (s *ScopedExecutor) matchExecutor(artifact string) (*ratify.Executor, error) {
+	_, executor, err := s.match(artifact)
+	return executor, err
+}
+
+// match finds the appropriate executor for the given artifact and the scope
+// it is registered for.
+func (s *ScopedExecutor) match(artifact string) (string, *ratify.Executor, error) {
 	ref, err := registry.ParseReference(artifact)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse artifact reference %q: %w", artifact, err)
+		return "", nil, errcode.InvalidReference.Errorf("failed to parse artifact reference %q: %w", artifact, err)
 	}
-
-	repo := ref.Registry + "/" + ref.Repository
-	if executor, ok := s.repository[repo]; ok {
-		return executor, nil
+	for scope, executor := range s.candidates(ref) {
+		return scope, executor, nil
 	}
+	return "", nil, errcode.ScopeNotMatched.Errorf("no executor configured for the artifact %q", artifact)
+}
 
-	registry := ref.Registry
-	if executor, ok := s.registry[registry]; ok {
-		return executor, nil
+// candidates yields the scopes matched by the reference with their executors,
+// from the most specific to the most general scope. The executor a scope
+// routes to is yielded before the executors stacked on the same scope.
+func (s *ScopedExecutor) candidates(ref registry.Reference) iter.Seq2[string, *ratify.Executor] {
+	return func(yield func(string, *ratify.Executor) bool) {
+		yieldScope := func(scope string, executor *ratify.Executor) bool {
+			if !yield(scope, executor) {
+				return false
+			}
+			for _, stacked := range s.stacked[scope] {
+				if !yield(scope, stacked) {
+					return false
+				}
+			}
+			return true
+		}
+		repo := ref.Registry + "/" + ref.Repository
+		if executor, ok := s.repository[repo]; ok && !yieldScope(repo, executor) {
+			return
+		}
+		for _, pattern := range s.repositoryPatterns[ref.Registry] {
+			if pattern.match(ref.Repository) && !yieldScope(pattern.scope, pattern.executor) {
+				return
+			}
+		}
+		if executor, ok := s.registry[ref.Registry]; ok && !yieldScope(ref.Registry, executor) {
+			return
+		}
+		if _, after, ok := strings.Cut(ref.Registry, "."); ok {
+			if executor, ok := s.wildcard[after]; ok && !yieldScope("*."+after, executor) {
+				return
+			}
+		}
+		if s.catchAll != nil {
+			yieldScope(catchAllScope, s.catchAll)
+		}
 	}
+}
 
-	if _, after, ok := strings.Cut(ref.Registry, "."); ok {
-		if executor, ok := s.wildcard[after]; ok {
-			return executor, nil
-		}
+// stack registers the executor on a scope the registered executor is already
+// registered on and returns the executor the scope routes to. Executors
+// compose on the same scope only if all but at most one of them are layered,
+// as the executor to route to would be ambiguous otherwise. The executor that
+// is not layered, if any, is routed to and the others are stacked.
+func (s *ScopedExecutor) stack(scope string, registered, executor *ratify.Executor) (*ratify.Executor, error) {
+	if registered == executor || slices.Contains(s.stacked[scope], executor) {
+		return nil, fmt.Errorf("executor already registered for scope %q", scope)
+	}
+	if !s.layered[registered] && !s.layered[executor] {
+		return nil, fmt.Errorf("executor already registered for scope %q: only layered executors can be registered on the same scope", scope)
+	}
+	if s.stacked == nil {
+		s.stacked = map[string][]*ratify.Executor{}
+	}
+	if s.layered[registered] && !s.layered[executor] {
+		s.stacked[scope] = append(s.stacked[scope], registered)
+		return executor, nil
 	}
-	return nil, fmt.Errorf("no executor configured for the artifact %q", artifact)
+	s.stacked[scope] = append(s.stacked[scope], executor)
+	return registered, nil
 }
 
 // registerExecutor registers an executor for a given scope.
@@ -193,6 +602,17 @@ func (s *ScopedExecutor) registerExecutor(scope string, executor *ratify.Executo
 		return fmt.Errorf("executor cannot be nil")
 	}
 
+	if scope == catchAllScope {
+		if s.catchAll != nil {
+			var err error
+			executor, err = s.stack(scope, s.catchAll, executor)
+			if err != nil {
+				return err
+			}
+		}
+		s.catchAll = executor
+		return nil
+	}
 	if strings.Contains(scope, "/") {
 		return s.registerRepository(scope, executor)
 	}
@@ -200,11 +620,12 @@ func (s *ScopedExecutor) registerExecutor(scope string, executor *ratify.Executo
 }
 
 // registerRepository registers an executor for a specific repository scope.
-// The scope must be a valid repository path without wildcards, tags, or digests.
+// The scope must be a valid repository path without tags or digests. Scopes
+// containing wildcards are registered as repository patterns.
 // It returns an error if the scope is invalid or if the executor is nil.
 func (s *ScopedExecutor) registerRepository(scope string, executor *ratify.Executor) error {
 	if strings.Contains(scope, "*") {
-		return fmt.Errorf("invalid scope %q: scope cannot contain wildcard for repository", scope)
+		return s.registerRepositoryPattern(scope, executor)
 	}
 	ref, err := registry.ParseReference(scope)
 	if err != nil {
@@ -217,13 +638,52 @@ func (s *ScopedExecutor) registerRepository(scope string, executor *ratify.Execu
 	if s.repository == nil {
 		s.repository = map[string]*ratify.Executor{}
 	}
-	if _, ok := s.repository[scope]; ok {
-		return fmt.Errorf("executor already registered for scope %q", scope)
+	if registered, ok := s.repository[scope]; ok {
+		if executor, err = s.stack(scope, registered, executor); err != nil {
+			return err
+		}
 	}
 	s.repository[scope] = executor
 	return nil
 }
 
+// registerRepositoryPattern registers an executor for a repository scope
+// containing wildcards. It returns an error if the scope is already registered
+// by an executor that cannot be stacked with it or if it overlaps with a
+// pattern of another executor with the same precedence, as the executor to
+// route to would be ambiguous. Patterns of layered executors may overlap, as
+// both executors are evaluated.
+func (s *ScopedExecutor) registerRepositoryPattern(scope string, executor *ratify.Executor) error {
+	pattern, err := parseRepositoryPattern(scope, executor)
+	if err != nil {
+		return err
+	}
+	registryName, _, _ := strings.Cut(scope, "/")
+	patterns := s.repositoryPatterns[registryName]
+	for _, registered := range patterns {
+		if registered.scope == scope {
+			routed, err := s.stack(scope, registered.executor, executor)
+			if err != nil {
+				return err
+			}
+			registered.executor = routed
+			return nil
+		}
+		if registered.executor != executor && !(s.layered[registered.executor] && s.layered[executor]) &&
+			registered.compare(pattern) == 0 && registered.overlaps(pattern) {
+			return fmt.Errorf("scope %q overlaps with scope %q of another executor with the same precedence", scope, registered.scope)
+		}
+	}
+
+	if s.repositoryPatterns == nil {
+		s.repositoryPatterns = map[string][]*repositoryPattern{}
+	}
+	patterns = append(patterns, pattern)
+	slices.SortStableFunc(patterns, (*repositoryPattern).compare)
+	s.repositoryPatterns[registryName] = patterns
+	return nil
+}
+
 // registerRegistry registers an executor for a given registry scope.
 // It supports both exact registry matches and wildcard registry matches.
 // The scope can be a specific registry (e.g., "registry.example.com") or a
@@ -241,22 +701,27 @@ func (s *ScopedExecutor) registerRegistry(scope string, executor *ratify.Executo
 		if s.registry == nil {
 			s.registry = map[string]*ratify.Executor{}
 		}
-		if _, ok := s.registry[scope]; ok {
-			return fmt.Errorf("executor already registered for scope %q", scope)
+		if registered, ok := s.registry[scope]; ok {
+			var err error
+			if executor, err = s.stack(scope, registered, executor); err != nil {
+				return err
+			}
 		}
 		s.registry[scope] = executor
 	case 1:
 		if !strings.HasPrefix(scope, "*.") {
 			return fmt.Errorf("invalid scope %q: wildcard must be at the beginning of the scope", scope)
 		}
-		scope = scope[2:]
 		if s.wildcard == nil {
 			s.wildcard = map[string]*ratify.Executor{}
 		}
-		if _, ok := s.wildcard[scope]; ok {
-			return fmt.Errorf("executor already registered for wildcard scope %q", scope)
+		if registered, ok := s.wildcard[scope[2:]]; ok {
+			var err error
+			if executor, err = s.stack(scope, registered, executor); err != nil {
+				return err
+			}
 		}
-		s.wildcard[scope] = executor
+		s.wildcard[scope[2:]] = executor
 	default:
 		return fmt.Errorf("invalid scope %q: scope can only contain one wildcard", scope)
 	}
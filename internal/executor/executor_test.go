@@ -18,6 +18,7 @@ package executor
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/notaryproject/ratify-go"
 
@@ -96,6 +97,7 @@ func TestNewExecutor(t *testing.T) {
 		opts           *Options
 		expectErr      bool
 		expectExecutor bool
+		resolve        string
 	}{
 		{
 			name:           "nil options",
@@ -118,9 +120,53 @@ func TestNewExecutor(t *testing.T) {
 			expectExecutor: false,
 		},
 		{
-			name: "invalid executor scopes",
+			name: "catch-all executor scope",
+			opts: &Options{
+				Executors: []*ScopedOptions{
+					{
+						Scopes: []string{"*"},
+						Verifiers: []*verifier.NewOptions{
+							{
+								Name: mockVerifierName,
+								Type: mockVerifierType,
+							},
+						},
+						Stores: []*store.NewOptions{
+							{
+								Type:   mockStoreType,
+								Scopes: []string{"testrepo"},
+							},
+						},
+						Policy: &policyenforcer.NewOptions{
+							Type: mockPolicyEnforcerType,
+						},
+					},
+				},
+			},
+			expectErr:      false,
+			expectExecutor: true,
+		},
+		{
+			name: "catch-all executor scope inherited by stores",
 			opts: &Options{
 				Executors: []*ScopedOptions{
+					{
+						Scopes: []string{"registry.example.com"},
+						Verifiers: []*verifier.NewOptions{
+							{
+								Name: mockVerifierName,
+								Type: mockVerifierType,
+							},
+						},
+						Stores: []*store.NewOptions{
+							{
+								Type: mockStoreType,
+							},
+						},
+						Policy: &policyenforcer.NewOptions{
+							Type: mockPolicyEnforcerType,
+						},
+					},
 					{
 						Scopes: []string{"*"},
 						Verifiers: []*verifier.NewOptions{
@@ -129,6 +175,33 @@ func TestNewExecutor(t *testing.T) {
 								Type: mockVerifierType,
 							},
 						},
+						Stores: []*store.NewOptions{
+							{
+								Type: mockStoreType,
+							},
+						},
+						Policy: &policyenforcer.NewOptions{
+							Type: mockPolicyEnforcerType,
+						},
+					},
+				},
+			},
+			expectErr:      false,
+			expectExecutor: true,
+			resolve:        "unknown.example.com/app:v1",
+		},
+		{
+			name: "invalid executor scopes",
+			opts: &Options{
+				Executors: []*ScopedOptions{
+					{
+						Scopes: []string{"*.example.com.*"},
+						Verifiers: []*verifier.NewOptions{
+							{
+								Name: mockVerifierName,
+								Type: mockVerifierType,
+							},
+						},
 						Stores: []*store.NewOptions{
 							{
 								Type:   mockStoreType,
@@ -232,6 +305,33 @@ func TestNewExecutor(t *testing.T) {
 			expectErr:      false,
 			expectExecutor: true,
 		},
+		{
+			name: "repository pattern scopes inherited by stores",
+			opts: &Options{
+				Executors: []*ScopedOptions{
+					{
+						Scopes: []string{"myregistry.io/team-a/*", "myregistry.io/team-b/**"},
+						Verifiers: []*verifier.NewOptions{
+							{
+								Name: mockVerifierName,
+								Type: mockVerifierType,
+							},
+						},
+						Stores: []*store.NewOptions{
+							{
+								Type: mockStoreType,
+							},
+						},
+						Policy: &policyenforcer.NewOptions{
+							Type: mockPolicyEnforcerType,
+						},
+					},
+				},
+			},
+			expectErr:      false,
+			expectExecutor: true,
+			resolve:        "myregistry.io/team-b/app/server:v1",
+		},
 	}
 
 	for _, test := range tests {
@@ -243,6 +343,11 @@ func TestNewExecutor(t *testing.T) {
 			if (executor != nil) != test.expectExecutor {
 				t.Errorf("expected executor: %v, got: %v", test.expectExecutor, executor != nil)
 			}
+			if test.resolve != "" && executor != nil {
+				if _, err := executor.Resolve(context.Background(), test.resolve); err != nil {
+					t.Errorf("expected %s to be resolved, got: %v", test.resolve, err)
+				}
+			}
 		})
 	}
 }
@@ -256,12 +361,15 @@ func TestRegisterExecutor(t *testing.T) {
 		wildcardScoped   bool
 		registryScoped   bool
 		repositoryScoped bool
+		patternScoped    bool
+		catchAllScoped   bool
 	}{
 		{
-			name:          "Register executor with global wildcard scope",
-			scope:         "*",
-			executor:      &ratify.Executor{},
-			registerError: true,
+			name:           "Register executor with global wildcard scope",
+			scope:          "*",
+			executor:       &ratify.Executor{},
+			registerError:  false,
+			catchAllScoped: true,
 		},
 		{
 			name:          "Register executor with empty scope",
@@ -285,6 +393,32 @@ func TestRegisterExecutor(t *testing.T) {
 			name:          "Register repository scoped executor with wildcard scope",
 			scope:         "registry.example.com/repository*",
 			executor:      &ratify.Executor{},
+			registerError: false,
+			patternScoped: true,
+		},
+		{
+			name:          "Register repository scoped executor with multi-level wildcard scope",
+			scope:         "registry.example.com/namespace/**",
+			executor:      &ratify.Executor{},
+			registerError: false,
+			patternScoped: true,
+		},
+		{
+			name:          "Register repository scoped executor with multi-level wildcard in the middle",
+			scope:         "registry.example.com/**/repository",
+			executor:      &ratify.Executor{},
+			registerError: true,
+		},
+		{
+			name:          "Register repository scoped executor with wildcard registry",
+			scope:         "*.example.com/repository/*",
+			executor:      &ratify.Executor{},
+			registerError: true,
+		},
+		{
+			name:          "Register repository scoped executor with invalid pattern",
+			scope:         "registry.example.com/Repository/*",
+			executor:      &ratify.Executor{},
 			registerError: true,
 		},
 		{
@@ -345,6 +479,12 @@ func TestRegisterExecutor(t *testing.T) {
 				if test.repositoryScoped && len(scopedExecutor.repository) == 0 {
 					t.Errorf("expected repository scoped executors to be registered, but got none")
 				}
+				if test.patternScoped && len(scopedExecutor.repositoryPatterns) == 0 {
+					t.Errorf("expected repository pattern scoped executors to be registered, but got none")
+				}
+				if test.catchAllScoped && scopedExecutor.catchAll == nil {
+					t.Errorf("expected catch-all executor to be registered, but got none")
+				}
 			}
 		})
 	}
@@ -365,10 +505,23 @@ func TestMatchExecutor(t *testing.T) {
 			"registry.example.com/repository/foo": e3,
 		},
 	}
+	e4 := &ratify.Executor{}
+	e5 := &ratify.Executor{}
+	e6 := &ratify.Executor{}
+	for scope, executor := range map[string]*ratify.Executor{
+		"registry.example.com/repository/**":  e4,
+		"registry.example.com/repository/*":   e5,
+		"registry.example.com/repository/*/x": e6,
+	} {
+		if err := scopedExecutor.registerExecutor(scope, executor); err != nil {
+			t.Fatalf("failed to register executor for scope %q: %v", scope, err)
+		}
+	}
 	tests := []struct {
 		name             string
 		artifact         string
 		expectedExecutor *ratify.Executor
+		expectedScope    string
 		expectedError    bool
 	}{
 		{
@@ -380,21 +533,52 @@ func TestMatchExecutor(t *testing.T) {
 		{
 			name:             "Match wildcard executor",
 			artifact:         "foo.example.com/bar:v1",
+			expectedScope:    "*.example.com",
 			expectedExecutor: e1,
 			expectedError:    false,
 		},
 		{
 			name:             "Match registry executor",
 			artifact:         "registry.example.com/foo:v1",
+			expectedScope:    "registry.example.com",
 			expectedExecutor: e2,
 			expectedError:    false,
 		},
 		{
 			name:             "Match repository executor",
 			artifact:         "registry.example.com/repository/foo:v1",
+			expectedScope:    "registry.example.com/repository/foo",
 			expectedExecutor: e3,
 			expectedError:    false,
 		},
+		{
+			name:             "Match single-level repository pattern over multi-level one",
+			artifact:         "registry.example.com/repository/bar:v1",
+			expectedScope:    "registry.example.com/repository/*",
+			expectedExecutor: e5,
+			expectedError:    false,
+		},
+		{
+			name:             "Match multi-level repository pattern",
+			artifact:         "registry.example.com/repository/bar/baz:v1",
+			expectedScope:    "registry.example.com/repository/**",
+			expectedExecutor: e4,
+			expectedError:    false,
+		},
+		{
+			name:             "Match repository pattern with more literal characters",
+			artifact:         "registry.example.com/repository/bar/x:v1",
+			expectedScope:    "registry.example.com/repository/*/x",
+			expectedExecutor: e6,
+			expectedError:    false,
+		},
+		{
+			name:             "Match registry executor for the prefix of a repository pattern",
+			artifact:         "registry.example.com/repository:v1",
+			expectedScope:    "registry.example.com",
+			expectedExecutor: e2,
+			expectedError:    false,
+		},
 		{
 			name:             "No match",
 			artifact:         "unknown.com/foo:v1",
@@ -412,10 +596,38 @@ func TestMatchExecutor(t *testing.T) {
 			if executor != test.expectedExecutor {
 				t.Errorf("expected executor: %v, got: %v", test.expectedExecutor, executor)
 			}
+			if scope, _ := scopedExecutor.MatchScope(test.artifact); scope != test.expectedScope {
+				t.Errorf("expected scope: %q, got: %q", test.expectedScope, scope)
+			}
 		})
 	}
 }
 
+func TestMatchExecutor_CatchAll(t *testing.T) {
+	registryExecutor := &ratify.Executor{}
+	catchAllExecutor := &ratify.Executor{}
+	scopedExecutor := &ScopedExecutor{}
+	if err := scopedExecutor.registerExecutor("registry.example.com", registryExecutor); err != nil {
+		t.Fatalf("failed to register registry executor: %v", err)
+	}
+	if err := scopedExecutor.registerExecutor("*", catchAllExecutor); err != nil {
+		t.Fatalf("failed to register catch-all executor: %v", err)
+	}
+	if err := scopedExecutor.registerExecutor("*", &ratify.Executor{}); err == nil {
+		t.Errorf("expected error registering a second catch-all executor")
+	}
+
+	if executor, err := scopedExecutor.matchExecutor("registry.example.com/foo:v1"); err != nil || executor != registryExecutor {
+		t.Errorf("expected registry executor, got: %v, %v", executor, err)
+	}
+	if executor, err := scopedExecutor.matchExecutor("unknown.com/foo:v1"); err != nil || executor != catchAllExecutor {
+		t.Errorf("expected catch-all executor, got: %v, %v", executor, err)
+	}
+	if scope, err := scopedExecutor.MatchScope("unknown.com/foo:v1"); err != nil || scope != "*" {
+		t.Errorf("expected scope \"*\", got: %q, %v", scope, err)
+	}
+}
+
 func TestValidateArtifact(t *testing.T) {
 	scopedExecutor := &ScopedExecutor{
 		wildcard: map[string]*ratify.Executor{
@@ -449,3 +661,98 @@ func TestResolve(t *testing.T) {
 		t.Error("expected no error for valid artifact with wildcard scope, got:", err)
 	}
 }
+
+func TestCacheTTL(t *testing.T) {
+	e1 := &ratify.Executor{}
+	e2 := &ratify.Executor{}
+	globalTTL := CacheTTL{Success: 10 * time.Second}
+	scopedTTL := CacheTTL{Success: 10 * time.Second, Failure: time.Minute}
+	scopedExecutor := &ScopedExecutor{
+		registry: map[string]*ratify.Executor{
+			"registry.example.com": e1,
+			"other.example.com":    e2,
+		},
+		cacheTTL: globalTTL,
+		scopedCacheTTL: map[*ratify.Executor]CacheTTL{
+			e1: scopedTTL,
+		},
+	}
+
+	tests := []struct {
+		name     string
+		artifact string
+		expected CacheTTL
+	}{
+		{
+			name:     "scoped cache options",
+			artifact: "registry.example.com/foo:v1",
+			expected: scopedTTL,
+		},
+		{
+			name:     "global cache options",
+			artifact: "other.example.com/foo:v1",
+			expected: globalTTL,
+		},
+		{
+			name:     "no matching executor",
+			artifact: "unknown.com/foo:v1",
+			expected: globalTTL,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if ttl := scopedExecutor.CacheTTL(test.artifact); ttl != test.expected {
+				t.Errorf("expected cache TTL: %+v, got: %+v", test.expected, ttl)
+			}
+		})
+	}
+}
+
+func TestCacheOptionsParse(t *testing.T) {
+	base := CacheTTL{Success: time.Second, Failure: 2 * time.Second}
+	tests := []struct {
+		name      string
+		opts      *CacheOptions
+		expected  CacheTTL
+		expectErr bool
+	}{
+		{
+			name:     "nil options",
+			opts:     nil,
+			expected: base,
+		},
+		{
+			name:     "override ttl only",
+			opts:     &CacheOptions{TTL: "30s"},
+			expected: CacheTTL{Success: 30 * time.Second, Failure: 2 * time.Second},
+		},
+		{
+			name:     "override both",
+			opts:     &CacheOptions{TTL: "1m", FailureTTL: "10s"},
+			expected: CacheTTL{Success: time.Minute, Failure: 10 * time.Second},
+		},
+		{
+			name:      "invalid ttl",
+			opts:      &CacheOptions{TTL: "invalid"},
+			expectErr: true,
+		},
+		{
+			name:      "non-positive failure ttl",
+			opts:      &CacheOptions{FailureTTL: "0s"},
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ttl, err := test.opts.parse(base)
+			if (err != nil) != test.expectErr {
+				t.Fatalf("expected error: %v, got: %v", test.expectErr, err)
+			}
+			if !test.expectErr && ttl != test.expected {
+				t.Errorf("expected cache TTL: %+v, got: %+v", test.expected, ttl)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// fingerprintLength is the number of hex characters kept of the SHA-256
+// digest of a configuration.
+const fingerprintLength = 16
+
+// fingerprint returns a short digest of the JSON encoding of the
+// configuration. Maps are encoded with sorted keys, so equal configurations
+// have equal fingerprints in every process.
+func fingerprint(config any) (string, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:fingerprintLength], nil
+}
+
+// scopedFingerprint returns the fingerprint of the configuration an executor
+// validates artifacts with, i.e. its scoped options, the global cache options
+// its cache options fall back to and the generation of the load. The options
+// do not cover the trust material they reference, so every load gets a new
+// fingerprint.
+func scopedFingerprint(generation uint64, global *CacheOptions, opts *ScopedOptions) (string, error) {
+	return fingerprint(struct {
+		Generation uint64         `json:"generation,omitempty"`
+		Cache      *CacheOptions  `json:"cache,omitempty"`
+		Executor   *ScopedOptions `json:"executor"`
+	}{
+		Generation: generation,
+		Cache:      global,
+		Executor:   opts,
+	})
+}
+
+// combineFingerprints returns the fingerprint of a set of executor
+// fingerprints regardless of their order.
+func combineFingerprints(fingerprints []string) (string, error) {
+	sorted := slices.Clone(fingerprints)
+	slices.Sort(sorted)
+	return fingerprint(strings.Join(sorted, ","))
+}
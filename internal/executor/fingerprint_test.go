@@ -0,0 +1,160 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"testing"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/store"
+	"github.com/notaryproject/ratify/v2/internal/verifier"
+)
+
+func newFingerprintOptions(issuer string) *ScopedOptions {
+	return &ScopedOptions{
+		Scopes: []string{"registry.example.com"},
+		Verifiers: []*verifier.NewOptions{
+			{
+				Name: "notation",
+				Type: "notation",
+				Parameters: map[string]any{
+					"trustPolicy": map[string]any{"issuer": issuer, "level": "strict"},
+				},
+			},
+		},
+		Stores: []*store.NewOptions{{Type: "registry-store"}},
+	}
+}
+
+func TestScopedFingerprint(t *testing.T) {
+	base, err := scopedFingerprint(1, nil, newFingerprintOptions("ca1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(base) != fingerprintLength {
+		t.Errorf("expected fingerprint of length %d, got %q", fingerprintLength, base)
+	}
+
+	tests := []struct {
+		name       string
+		generation uint64
+		global     *CacheOptions
+		opts       *ScopedOptions
+		expectSame bool
+	}{
+		{
+			name:       "same options",
+			generation: 1,
+			opts:       newFingerprintOptions("ca1"),
+			expectSame: true,
+		},
+		{
+			name:       "changed verifier parameters",
+			generation: 1,
+			opts:       newFingerprintOptions("ca2"),
+		},
+		{
+			name:       "changed global cache options",
+			generation: 1,
+			global:     &CacheOptions{TTL: "1m"},
+			opts:       newFingerprintOptions("ca1"),
+		},
+		{
+			name:       "reloaded options",
+			generation: 2,
+			opts:       newFingerprintOptions("ca1"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := scopedFingerprint(tt.generation, tt.global, tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (got == base) != tt.expectSame {
+				t.Errorf("fingerprint %q, base %q, expect same: %t", got, base, tt.expectSame)
+			}
+		})
+	}
+}
+
+func TestCombineFingerprints(t *testing.T) {
+	ab, err := combineFingerprints([]string{"a", "b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ba, err := combineFingerprints([]string{"b", "a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ab != ba {
+		t.Errorf("expected fingerprints independent of the order, got %q and %q", ab, ba)
+	}
+	abc, err := combineFingerprints([]string{"a", "b", "c"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ab == abc {
+		t.Errorf("expected different fingerprints for different sets, got %q", ab)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	e1 := &ratify.Executor{}
+	e2 := &ratify.Executor{}
+	scopedExecutor := &ScopedExecutor{
+		registry: map[string]*ratify.Executor{
+			"registry.example.com": e1,
+			"other.example.com":    e2,
+		},
+		fingerprints: map[*ratify.Executor]string{
+			e1: "fingerprint1",
+			e2: "fingerprint2",
+		},
+		fingerprint: "fingerprint",
+	}
+
+	tests := []struct {
+		name     string
+		artifact string
+		expected string
+	}{
+		{
+			name:     "matched executor",
+			artifact: "registry.example.com/foo:v1",
+			expected: "fingerprint1",
+		},
+		{
+			name:     "other executor",
+			artifact: "other.example.com/foo:v1",
+			expected: "fingerprint2",
+		},
+		{
+			name:     "no matching executor",
+			artifact: "unknown.com/foo:v1",
+			expected: "fingerprint",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scopedExecutor.Fingerprint(tt.artifact); got != tt.expected {
+				t.Errorf("expected fingerprint %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
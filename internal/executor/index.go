@@ -0,0 +1,224 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/errcode"
+	"github.com/notaryproject/ratify/v2/internal/logger"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+)
+
+// IndexMode defines how a subject that is an image index or a manifest list
+// is validated.
+type IndexMode string
+
+const (
+	// IndexModeIndexOnly validates the index itself. This is the default.
+	IndexModeIndexOnly IndexMode = "index-only"
+
+	// IndexModeChildrenAll validates every child manifest of the index. The
+	// validation succeeds only if all children pass.
+	IndexModeChildrenAll IndexMode = "children-all"
+
+	// IndexModeChildrenAny validates every child manifest of the index. The
+	// validation succeeds if at least one child passes.
+	IndexModeChildrenAny IndexMode = "children-any"
+
+	// IndexModePlatform validates only the child manifest matching the
+	// platform of the validation options.
+	IndexModePlatform IndexMode = "platform"
+)
+
+// maxConcurrentChildren is the maximum number of child manifests of an index
+// validated concurrently, so that a large index does not fan out to the
+// registry without bound.
+const maxConcurrentChildren = 4
+
+// annotationDockerReferenceType marks the attestation manifests that docker
+// buildx adds to an index. They are not platform manifests and are skipped.
+const annotationDockerReferenceType = "vnd.docker.reference.type"
+
+// parseIndexMode validates the index mode. An empty mode defaults to
+// [IndexModeIndexOnly].
+func parseIndexMode(value string) (IndexMode, error) {
+	switch mode := IndexMode(value); mode {
+	case "":
+		return IndexModeIndexOnly, nil
+	case IndexModeIndexOnly, IndexModeChildrenAll, IndexModeChildrenAny, IndexModePlatform:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid index mode %q: must be one of %s, %s, %s or %s", value, IndexModeIndexOnly, IndexModeChildrenAll, IndexModeChildrenAny, IndexModePlatform)
+	}
+}
+
+// childResult is the validation result of a child manifest of an index.
+type childResult struct {
+	desc   ocispec.Descriptor
+	result *ratify.ValidationResult
+	err    error
+}
+
+// validateIndex validates the children of the subject according to mode if
+// the subject is an image index or a manifest list. Other subjects are
+// validated as is.
+func validateIndex(ctx context.Context, executor *ratify.Executor, mode IndexMode, opts ValidateOptions) (*ratify.ValidationResult, error) {
+	subject := opts.Subject
+	ref, err := registry.ParseReference(subject)
+	if err != nil {
+		return nil, errcode.InvalidReference.Errorf("failed to parse artifact reference %q: %w", subject, err)
+	}
+	desc, err := executor.Store.Resolve(ctx, subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve artifact %q: %w", subject, err)
+	}
+	if desc.MediaType != ocispec.MediaTypeImageIndex && desc.MediaType != mediaTypeDockerManifestList {
+		return executor.ValidateArtifact(ctx, opts.ValidateArtifactOptions)
+	}
+
+	repo := ref.Registry + "/" + ref.Repository
+	manifest, err := executor.Store.FetchManifest(ctx, repo, desc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch index of artifact %q: %w", subject, err)
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(manifest, &index); err != nil {
+		return nil, fmt.Errorf("failed to decode index of artifact %q: %w", subject, err)
+	}
+
+	var children []ocispec.Descriptor
+	for _, child := range index.Manifests {
+		if child.Annotations[annotationDockerReferenceType] != "" {
+			continue
+		}
+		if mode == IndexModePlatform {
+			if opts.Platform == nil {
+				return nil, errcode.InvalidReference.Errorf("a platform is required to validate the index of artifact %q in %s index mode", subject, IndexModePlatform)
+			}
+			if !matchPlatform(child.Platform, opts.Platform) {
+				continue
+			}
+		}
+		children = append(children, child)
+	}
+	if len(children) == 0 {
+		if mode == IndexModePlatform {
+			return nil, errcode.SubjectNotFound.Errorf("no manifest found for platform %s in the index of artifact %q", FormatPlatform(opts.Platform), subject)
+		}
+		return nil, errcode.SubjectNotFound.Errorf("no child manifest found in the index of artifact %q", subject)
+	}
+	if mode == IndexModePlatform {
+		// Validate the first matching manifest only.
+		children = children[:1]
+	}
+
+	logger.GetLogger(ctx, logOpt).Debugf("validating %d child manifests of index %s in %s index mode", len(children), subject, mode)
+	results := make([]childResult, len(children))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentChildren)
+	for idx, child := range children {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			// Stop scheduling children that cannot complete in time.
+			results[idx] = childResult{desc: child, err: ctx.Err()}
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			childOpts := opts.ValidateArtifactOptions
+			childOpts.Subject = repo + "@" + child.Digest.String()
+			result, err := executor.ValidateArtifact(ctx, childOpts)
+			results[idx] = childResult{desc: child, result: result, err: err}
+		}()
+	}
+	wg.Wait()
+	return aggregateChildResults(subject, mode, results)
+}
+
+// aggregateChildResults combines the results of the children into the result
+// of the index. Each child is reported as an artifact of the index with the
+// reports of the child nested. Children that could not be validated are
+// reported with their error.
+func aggregateChildResults(subject string, mode IndexMode, results []childResult) (*ratify.ValidationResult, error) {
+	var errs []error
+	passed := 0
+	reports := make([]*ratify.ValidationReport, 0, len(results))
+	for _, child := range results {
+		report := &ratify.ValidationReport{
+			Subject:  subject,
+			Artifact: child.desc,
+		}
+		if child.err != nil {
+			err := fmt.Errorf("failed to validate child manifest %s: %w", child.desc.Digest, child.err)
+			errs = append(errs, err)
+			report.Results = []*ratify.VerificationResult{{
+				Description: fmt.Sprintf("child manifest %s%s could not be validated", child.desc.Digest, describePlatform(child.desc.Platform)),
+				Err:         err,
+			}}
+			reports = append(reports, report)
+			continue
+		}
+		report.ArtifactReports = child.result.ArtifactReports
+		verification := &ratify.VerificationResult{
+			Description: fmt.Sprintf("child manifest %s%s passed validation", child.desc.Digest, describePlatform(child.desc.Platform)),
+		}
+		if child.result.Succeeded {
+			passed++
+		} else {
+			verification.Description = fmt.Sprintf("child manifest %s%s failed validation", child.desc.Digest, describePlatform(child.desc.Platform))
+			verification.Err = errcode.VerificationFailed.Errorf("child manifest %s failed validation", child.desc.Digest)
+		}
+		report.Results = []*ratify.VerificationResult{verification}
+		reports = append(reports, report)
+	}
+
+	var succeeded bool
+	switch mode {
+	case IndexModeChildrenAny:
+		succeeded = passed > 0
+		if !succeeded && len(errs) > 0 {
+			// A child that could not be validated may still have passed.
+			return nil, errors.Join(errs...)
+		}
+	default:
+		if len(errs) > 0 {
+			return nil, errors.Join(errs...)
+		}
+		succeeded = passed == len(results)
+	}
+	return &ratify.ValidationResult{
+		Succeeded:       succeeded,
+		ArtifactReports: reports,
+	}, nil
+}
+
+// describePlatform renders the platform as a suffix of a child description.
+func describePlatform(platform *ocispec.Platform) string {
+	if platform == nil {
+		return ""
+	}
+	return " (" + FormatPlatform(platform) + ")"
+}
@@ -0,0 +1,387 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/errcode"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const signatureArtifactType = "application/vnd.cncf.notary.signature"
+
+var attestationDesc = ocispec.Descriptor{
+	MediaType: ocispec.MediaTypeImageManifest,
+	Digest:    digest.FromString("attestation"),
+	Platform:  &ocispec.Platform{OS: "unknown", Architecture: "unknown"},
+	Annotations: map[string]string{
+		annotationDockerReferenceType: "attestation-manifest",
+	},
+}
+
+// childStore resolves tags to an image index of amd64Desc, arm64Desc and
+// attestationDesc, and digests to image manifests. Only the manifests in
+// signed have a signature.
+type childStore struct {
+	mockStore
+	signed map[digest.Digest]bool
+	// unavailable are the manifests whose referrers cannot be listed.
+	unavailable map[digest.Digest]bool
+}
+
+func (s *childStore) Resolve(_ context.Context, ref string) (ocispec.Descriptor, error) {
+	if _, dgst, ok := strings.Cut(ref, "@"); ok {
+		return ocispec.Descriptor{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Digest:    digest.Digest(dgst),
+		}, nil
+	}
+	return indexDesc, nil
+}
+
+func (s *childStore) FetchManifest(_ context.Context, _ string, _ ocispec.Descriptor) ([]byte, error) {
+	return json.Marshal(ocispec.Index{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{amd64Desc, arm64Desc, attestationDesc},
+	})
+}
+
+func (s *childStore) ListReferrers(_ context.Context, ref string, _ []string, fn func(referrers []ocispec.Descriptor) error) error {
+	_, dgst, _ := strings.Cut(ref, "@")
+	if s.unavailable[digest.Digest(dgst)] {
+		return errcode.RegistryUnavailable.Errorf("failed to list referrers of %s", ref)
+	}
+	if !s.signed[digest.Digest(dgst)] {
+		return nil
+	}
+	return fn([]ocispec.Descriptor{{
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: signatureArtifactType,
+		Digest:       digest.FromString("signature of " + dgst),
+	}})
+}
+
+// passingVerifier accepts every artifact.
+type passingVerifier struct {
+	mockVerifier
+}
+
+func (v *passingVerifier) Verify(_ context.Context, _ *ratify.VerifyOptions) (*ratify.VerificationResult, error) {
+	return &ratify.VerificationResult{Verifier: v}, nil
+}
+
+func newChildExecutor(t *testing.T, mode IndexMode, signed ...digest.Digest) *ScopedExecutor {
+	t.Helper()
+	executor := newSignedExecutor(t, signed...)
+	scopedExecutor := &ScopedExecutor{
+		registry: map[string]*ratify.Executor{
+			"registry.example.com": executor,
+		},
+		indexModes: map[*ratify.Executor]IndexMode{},
+	}
+	if mode != IndexModeIndexOnly {
+		scopedExecutor.indexModes[executor] = mode
+	}
+	return scopedExecutor
+}
+
+// newSignedExecutor creates an executor with a [childStore] where only the
+// manifests in signed have a signature.
+func newSignedExecutor(t *testing.T, signed ...digest.Digest) *ratify.Executor {
+	t.Helper()
+	signedSet := make(map[digest.Digest]bool)
+	for _, dgst := range signed {
+		signedSet[dgst] = true
+	}
+	policy, err := ratify.NewThresholdPolicyEnforcer(&ratify.ThresholdPolicyRule{
+		Rules: []*ratify.ThresholdPolicyRule{{Verifier: mockVerifierName}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create policy enforcer: %v", err)
+	}
+	executor, err := ratify.NewExecutor(&childStore{signed: signedSet}, []ratify.Verifier{&passingVerifier{}}, policy)
+	if err != nil {
+		t.Fatalf("failed to create executor: %v", err)
+	}
+	return executor
+}
+
+func TestParseIndexMode(t *testing.T) {
+	if mode, err := parseIndexMode(""); err != nil || mode != IndexModeIndexOnly {
+		t.Errorf("expected default mode %s, got %s, %v", IndexModeIndexOnly, mode, err)
+	}
+	if mode, err := parseIndexMode("children-any"); err != nil || mode != IndexModeChildrenAny {
+		t.Errorf("expected mode %s, got %s, %v", IndexModeChildrenAny, mode, err)
+	}
+	if _, err := parseIndexMode("children"); err == nil {
+		t.Error("expected error for invalid mode, got nil")
+	}
+}
+
+func TestValidateArtifactWithOptions_IndexMode(t *testing.T) {
+	tests := []struct {
+		name              string
+		mode              IndexMode
+		signed            []digest.Digest
+		unavailable       []digest.Digest
+		subject           string
+		platform          *ocispec.Platform
+		expectedSucceeded bool
+		expectedChildren  []digest.Digest
+		expectedFailed    []digest.Digest
+		expectedError     errcode.Code
+	}{
+		{
+			name:              "index only",
+			mode:              IndexModeIndexOnly,
+			signed:            []digest.Digest{indexDesc.Digest},
+			subject:           "registry.example.com/app:v1",
+			expectedSucceeded: true,
+		},
+		{
+			name:              "children all with unsigned child",
+			mode:              IndexModeChildrenAll,
+			signed:            []digest.Digest{indexDesc.Digest, amd64Desc.Digest},
+			subject:           "registry.example.com/app:v1",
+			expectedSucceeded: false,
+			expectedChildren:  []digest.Digest{amd64Desc.Digest, arm64Desc.Digest},
+			expectedFailed:    []digest.Digest{arm64Desc.Digest},
+		},
+		{
+			name:              "children all with signed children",
+			mode:              IndexModeChildrenAll,
+			signed:            []digest.Digest{amd64Desc.Digest, arm64Desc.Digest},
+			subject:           "registry.example.com/app:v1",
+			expectedSucceeded: true,
+			expectedChildren:  []digest.Digest{amd64Desc.Digest, arm64Desc.Digest},
+		},
+		{
+			name:              "children any with unsigned child",
+			mode:              IndexModeChildrenAny,
+			signed:            []digest.Digest{amd64Desc.Digest},
+			subject:           "registry.example.com/app:v1",
+			expectedSucceeded: true,
+			expectedChildren:  []digest.Digest{amd64Desc.Digest, arm64Desc.Digest},
+			expectedFailed:    []digest.Digest{arm64Desc.Digest},
+		},
+		{
+			name:              "children any with unsigned children",
+			mode:              IndexModeChildrenAny,
+			signed:            []digest.Digest{indexDesc.Digest},
+			subject:           "registry.example.com/app:v1",
+			expectedSucceeded: false,
+			expectedChildren:  []digest.Digest{amd64Desc.Digest, arm64Desc.Digest},
+			expectedFailed:    []digest.Digest{amd64Desc.Digest, arm64Desc.Digest},
+		},
+		{
+			name:              "children any with unavailable child",
+			mode:              IndexModeChildrenAny,
+			signed:            []digest.Digest{amd64Desc.Digest},
+			unavailable:       []digest.Digest{arm64Desc.Digest},
+			subject:           "registry.example.com/app:v1",
+			expectedSucceeded: true,
+			expectedChildren:  []digest.Digest{amd64Desc.Digest, arm64Desc.Digest},
+			expectedFailed:    []digest.Digest{arm64Desc.Digest},
+		},
+		{
+			name:          "children any with unavailable children",
+			mode:          IndexModeChildrenAny,
+			unavailable:   []digest.Digest{amd64Desc.Digest, arm64Desc.Digest},
+			subject:       "registry.example.com/app:v1",
+			expectedError: errcode.RegistryUnavailable,
+		},
+		{
+			name:          "children all with unavailable child",
+			mode:          IndexModeChildrenAll,
+			signed:        []digest.Digest{amd64Desc.Digest},
+			unavailable:   []digest.Digest{arm64Desc.Digest},
+			subject:       "registry.example.com/app:v1",
+			expectedError: errcode.RegistryUnavailable,
+		},
+		{
+			name:              "platform",
+			mode:              IndexModePlatform,
+			signed:            []digest.Digest{arm64Desc.Digest},
+			subject:           "registry.example.com/app:v1",
+			platform:          &ocispec.Platform{OS: "linux", Architecture: "arm64"},
+			expectedSucceeded: true,
+			expectedChildren:  []digest.Digest{arm64Desc.Digest},
+		},
+		{
+			name:          "platform without platform",
+			mode:          IndexModePlatform,
+			subject:       "registry.example.com/app:v1",
+			expectedError: errcode.InvalidReference,
+		},
+		{
+			name:          "platform not in index",
+			mode:          IndexModePlatform,
+			subject:       "registry.example.com/app:v1",
+			platform:      &ocispec.Platform{OS: "windows", Architecture: "amd64"},
+			expectedError: errcode.SubjectNotFound,
+		},
+		{
+			name:              "not an index",
+			mode:              IndexModeChildrenAll,
+			signed:            []digest.Digest{amd64Desc.Digest},
+			subject:           "registry.example.com/app@" + amd64Desc.Digest.String(),
+			expectedSucceeded: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scopedExecutor := newChildExecutor(t, test.mode, test.signed...)
+			store := scopedExecutor.registry["registry.example.com"].Store.(*childStore)
+			store.unavailable = make(map[digest.Digest]bool)
+			for _, dgst := range test.unavailable {
+				store.unavailable[dgst] = true
+			}
+			result, err := scopedExecutor.ValidateArtifactWithOptions(context.Background(), ValidateOptions{
+				ValidateArtifactOptions: ratify.ValidateArtifactOptions{
+					Subject: test.subject,
+				},
+				Platform: test.platform,
+			})
+			if code := errcode.Classify(err); code != test.expectedError {
+				t.Fatalf("expected error code %q, got %q (%v)", test.expectedError, code, err)
+			}
+			if err != nil {
+				return
+			}
+			if result.Succeeded != test.expectedSucceeded {
+				t.Errorf("expected succeeded %t, got %t", test.expectedSucceeded, result.Succeeded)
+			}
+			if test.expectedChildren == nil {
+				return
+			}
+
+			if len(result.ArtifactReports) != len(test.expectedChildren) {
+				t.Fatalf("expected %d child reports, got %d", len(test.expectedChildren), len(result.ArtifactReports))
+			}
+			failed := make(map[digest.Digest]bool)
+			for _, dgst := range test.expectedFailed {
+				failed[dgst] = true
+			}
+			for idx, report := range result.ArtifactReports {
+				if report.Artifact.Digest != test.expectedChildren[idx] {
+					t.Errorf("expected child %s, got %s", test.expectedChildren[idx], report.Artifact.Digest)
+				}
+				if len(report.Results) != 1 {
+					t.Fatalf("expected 1 result for child %s, got %d", report.Artifact.Digest, len(report.Results))
+				}
+				if hasErr := report.Results[0].Err != nil; hasErr != failed[report.Artifact.Digest] {
+					t.Errorf("expected child %s failed: %t, got %t", report.Artifact.Digest, failed[report.Artifact.Digest], hasErr)
+				}
+				if !failed[report.Artifact.Digest] && len(report.ArtifactReports) != 1 {
+					t.Errorf("expected nested signature report for child %s, got %d", report.Artifact.Digest, len(report.ArtifactReports))
+				}
+			}
+		})
+	}
+}
+
+// wideIndexStore resolves tags to an image index of children manifests and
+// records the maximum number of concurrently listed referrers.
+type wideIndexStore struct {
+	mockStore
+	children int
+
+	mu             sync.Mutex
+	inFlight       int
+	maxConcurrency int
+}
+
+func (s *wideIndexStore) Resolve(_ context.Context, ref string) (ocispec.Descriptor, error) {
+	if _, dgst, ok := strings.Cut(ref, "@"); ok {
+		return ocispec.Descriptor{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Digest:    digest.Digest(dgst),
+		}, nil
+	}
+	return indexDesc, nil
+}
+
+func (s *wideIndexStore) FetchManifest(_ context.Context, _ string, _ ocispec.Descriptor) ([]byte, error) {
+	index := ocispec.Index{
+		MediaType: ocispec.MediaTypeImageIndex,
+	}
+	for idx := range s.children {
+		index.Manifests = append(index.Manifests, ocispec.Descriptor{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Digest:    digest.FromString(fmt.Sprintf("child %d", idx)),
+		})
+	}
+	return json.Marshal(index)
+}
+
+func (s *wideIndexStore) ListReferrers(_ context.Context, _ string, _ []string, _ func(referrers []ocispec.Descriptor) error) error {
+	s.mu.Lock()
+	s.inFlight++
+	s.maxConcurrency = max(s.maxConcurrency, s.inFlight)
+	s.mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	s.mu.Lock()
+	s.inFlight--
+	s.mu.Unlock()
+	return nil
+}
+
+func TestValidateArtifactWithOptions_IndexConcurrency(t *testing.T) {
+	store := &wideIndexStore{children: 3 * maxConcurrentChildren}
+	policy, err := ratify.NewThresholdPolicyEnforcer(&ratify.ThresholdPolicyRule{
+		Rules: []*ratify.ThresholdPolicyRule{{Verifier: mockVerifierName}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create policy enforcer: %v", err)
+	}
+	executor, err := ratify.NewExecutor(store, []ratify.Verifier{&passingVerifier{}}, policy)
+	if err != nil {
+		t.Fatalf("failed to create executor: %v", err)
+	}
+	scopedExecutor := &ScopedExecutor{
+		registry: map[string]*ratify.Executor{
+			"registry.example.com": executor,
+		},
+		indexModes: map[*ratify.Executor]IndexMode{
+			executor: IndexModeChildrenAll,
+		},
+	}
+
+	result, err := scopedExecutor.ValidateArtifactWithOptions(context.Background(), ValidateOptions{
+		ValidateArtifactOptions: ratify.ValidateArtifactOptions{
+			Subject: "registry.example.com/app:v1",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.ArtifactReports) != store.children {
+		t.Errorf("expected %d child reports, got %d", store.children, len(result.ArtifactReports))
+	}
+	if store.maxConcurrency > maxConcurrentChildren {
+		t.Errorf("expected at most %d children validated concurrently, got %d", maxConcurrentChildren, store.maxConcurrency)
+	}
+}
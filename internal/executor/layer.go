@@ -0,0 +1,223 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/errcode"
+	"github.com/notaryproject/ratify/v2/internal/logger"
+	"github.com/notaryproject/ratify/v2/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"oras.land/oras-go/v2/registry"
+)
+
+// layer is an executor evaluated for an artifact and the scope the artifact
+// matched it by.
+type layer struct {
+	scope    string
+	executor *ratify.Executor
+}
+
+// layerResult is the validation result of a layer.
+type layerResult struct {
+	layer
+	result *ratify.ValidationResult
+	err    error
+}
+
+// matchLayers finds the executors to evaluate for the artifact, from the most
+// specific to the most general scope. The first layer is the executor the
+// artifact is routed to, followed by the layered executors of the same and
+// the more general scopes matched by the artifact. Each executor is evaluated
+// once.
+func (s *ScopedExecutor) matchLayers(artifact string) ([]layer, error) {
+	ref, err := registry.ParseReference(artifact)
+	if err != nil {
+		return nil, errcode.InvalidReference.Errorf("failed to parse artifact reference %q: %w", artifact, err)
+	}
+	var layers []layer
+	for scope, executor := range s.candidates(ref) {
+		if len(layers) > 0 && !s.layered[executor] {
+			continue
+		}
+		if slices.ContainsFunc(layers, func(l layer) bool { return l.executor == executor }) {
+			continue
+		}
+		layers = append(layers, layer{scope: scope, executor: executor})
+	}
+	if len(layers) == 0 {
+		return nil, errcode.ScopeNotMatched.Errorf("no executor configured for the artifact %q", artifact)
+	}
+	return layers, nil
+}
+
+// tracedMatchLayers calls matchLayers within a span.
+func (s *ScopedExecutor) tracedMatchLayers(ctx context.Context, artifact string) ([]layer, error) {
+	_, span := tracing.StartSpan(ctx, "ScopedExecutor.matchLayers", attribute.String("ratify.artifact", artifact))
+	layers, err := s.matchLayers(artifact)
+	if err == nil {
+		span.SetAttributes(attribute.Int("ratify.layers", len(layers)))
+	}
+	tracing.EndSpan(span, err)
+	return layers, err
+}
+
+// validateLayer validates the artifact with a single layer. The failure of an
+// audit-only layer is flagged instead of failing the validation.
+func (s *ScopedExecutor) validateLayer(ctx context.Context, l layer, opts ValidateOptions) (*ValidationResult, error) {
+	result, err := s.validate(ctx, l.executor, opts)
+	if err != nil {
+		if s.isAuditOnlyErr(ctx, l, err) {
+			return auditOnlyFailure([]string{l.scope}, err), nil
+		}
+		return nil, err
+	}
+	validationResult := &ValidationResult{
+		ValidationResult: result,
+	}
+	if s.auditOnly[l.executor] {
+		validationResult.AuditOnlyScopes = []string{l.scope}
+		validationResult.WouldHaveFailed = !result.Succeeded
+		result.Succeeded = true
+	}
+	return validationResult, nil
+}
+
+// validateLayers validates the artifact with every layer concurrently. The
+// validation succeeds only if all layers that are not audit-only pass. Each
+// layer is reported as an artifact of the subject with the reports of the
+// layer nested.
+func (s *ScopedExecutor) validateLayers(ctx context.Context, layers []layer, opts ValidateOptions) (*ValidationResult, error) {
+	subject := opts.Subject
+	// Resolve the subject with an enforced layer if any, so that an
+	// unavailable store of an audit-only layer does not fail the validation.
+	resolver := layers[0]
+	if idx := slices.IndexFunc(layers, func(l layer) bool { return !s.auditOnly[l.executor] }); idx >= 0 {
+		resolver = layers[idx]
+	}
+	desc, err := resolver.executor.Store.Resolve(ctx, subject)
+	if err != nil {
+		err = fmt.Errorf("failed to resolve artifact %q: %w", subject, err)
+		if s.isAuditOnlyErr(ctx, resolver, err) {
+			scopes := make([]string, len(layers))
+			for idx, l := range layers {
+				scopes[idx] = l.scope
+			}
+			return auditOnlyFailure(scopes, err), nil
+		}
+		return nil, err
+	}
+
+	logger.GetLogger(ctx, logOpt).Debugf("validating artifact %s with %d layered executors", subject, len(layers))
+	results := make([]layerResult, len(layers))
+	var wg sync.WaitGroup
+	for idx, l := range layers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := s.validate(ctx, l.executor, opts)
+			results[idx] = layerResult{layer: l, result: result, err: err}
+		}()
+	}
+	wg.Wait()
+
+	var errs, auditOnlyErrs []error
+	validationResult := &ValidationResult{}
+	succeeded := true
+	reports := make([]*ratify.ValidationReport, 0, len(results))
+	for _, r := range results {
+		auditOnly := s.auditOnly[r.executor]
+		if r.err != nil {
+			err := fmt.Errorf("failed to validate with the executor of scope %q: %w", r.scope, r.err)
+			if !s.isAuditOnlyErr(ctx, r.layer, err) {
+				errs = append(errs, err)
+				continue
+			}
+			// Report the audit-only layer as failed with the error.
+			validationResult.AuditOnlyScopes = append(validationResult.AuditOnlyScopes, r.scope)
+			validationResult.WouldHaveFailed = true
+			auditOnlyErrs = append(auditOnlyErrs, err)
+			reports = append(reports, &ratify.ValidationReport{
+				Subject:  subject,
+				Artifact: desc,
+				Results: []*ratify.VerificationResult{{
+					Description: fmt.Sprintf("executor of scope %q could not complete validation in audit-only mode", r.scope),
+					Err:         err,
+				}},
+			})
+			continue
+		}
+		verification := &ratify.VerificationResult{
+			Description: fmt.Sprintf("executor of scope %q passed validation", r.scope),
+		}
+		if auditOnly {
+			validationResult.AuditOnlyScopes = append(validationResult.AuditOnlyScopes, r.scope)
+		}
+		if !r.result.Succeeded {
+			verification.Description = fmt.Sprintf("executor of scope %q failed validation", r.scope)
+			verification.Err = errcode.VerificationFailed.Errorf("executor of scope %q failed validation", r.scope)
+			if auditOnly {
+				verification.Description += " in audit-only mode"
+				validationResult.WouldHaveFailed = true
+			} else {
+				succeeded = false
+			}
+		}
+		reports = append(reports, &ratify.ValidationReport{
+			Subject:         subject,
+			Artifact:        desc,
+			Results:         []*ratify.VerificationResult{verification},
+			ArtifactReports: r.result.ArtifactReports,
+		})
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	validationResult.AuditOnlyErr = errors.Join(auditOnlyErrs...)
+	validationResult.ValidationResult = &ratify.ValidationResult{
+		Succeeded:       succeeded,
+		ArtifactReports: reports,
+	}
+	return validationResult, nil
+}
+
+// isAuditOnlyErr reports whether err of the layer is flagged instead of
+// failing the validation, i.e. the layer is audit-only and err is not caused
+// by the cancellation of ctx.
+func (s *ScopedExecutor) isAuditOnlyErr(ctx context.Context, l layer, err error) bool {
+	return err != nil && s.auditOnly[l.executor] && ctx.Err() == nil
+}
+
+// auditOnlyFailure returns the result of a validation that the audit-only
+// executors of the scopes could not complete with err. It succeeds and is
+// flagged as would have failed.
+func auditOnlyFailure(scopes []string, err error) *ValidationResult {
+	return &ValidationResult{
+		ValidationResult: &ratify.ValidationResult{
+			Succeeded:       true,
+			ArtifactReports: []*ratify.ValidationReport{},
+		},
+		AuditOnlyScopes: scopes,
+		WouldHaveFailed: true,
+		AuditOnlyErr:    err,
+	}
+}
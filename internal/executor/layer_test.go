@@ -0,0 +1,537 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/errcode"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// unavailableStore fails every request as if the registry is unavailable.
+type unavailableStore struct {
+	mockStore
+}
+
+func (s *unavailableStore) Resolve(_ context.Context, ref string) (ocispec.Descriptor, error) {
+	return ocispec.Descriptor{}, errcode.RegistryUnavailable.Errorf("failed to resolve %s: service unavailable", ref)
+}
+
+func (s *unavailableStore) ListReferrers(_ context.Context, ref string, _ []string, _ func(referrers []ocispec.Descriptor) error) error {
+	return errcode.RegistryUnavailable.Errorf("failed to list referrers of %s: service unavailable", ref)
+}
+
+// newLayeredExecutor registers the executors for their scopes and marks the
+// executors in layered as layered.
+func newLayeredExecutor(t *testing.T, scopes map[string]*ratify.Executor, layered ...*ratify.Executor) *ScopedExecutor {
+	t.Helper()
+	scopedExecutor := &ScopedExecutor{
+		layered:        map[*ratify.Executor]bool{},
+		auditOnly:      map[*ratify.Executor]bool{},
+		scopedCacheTTL: map[*ratify.Executor]CacheTTL{},
+		fingerprints:   map[*ratify.Executor]string{},
+	}
+	for _, executor := range layered {
+		scopedExecutor.layered[executor] = true
+	}
+	for scope, executor := range scopes {
+		if err := scopedExecutor.registerExecutor(scope, executor); err != nil {
+			t.Fatalf("failed to register executor for scope %q: %v", scope, err)
+		}
+	}
+	return scopedExecutor
+}
+
+func TestMatchLayers(t *testing.T) {
+	team := &ratify.Executor{}
+	registryBaseline := &ratify.Executor{}
+	wildcard := &ratify.Executor{}
+	baseline := &ratify.Executor{}
+	scopedExecutor := newLayeredExecutor(t, map[string]*ratify.Executor{
+		"registry.example.com/team-a/**": team,
+		"registry.example.com":           registryBaseline,
+		"*.example.com":                  wildcard,
+		"*":                              baseline,
+	}, registryBaseline, baseline)
+
+	tests := []struct {
+		name           string
+		artifact       string
+		expectedScopes []string
+		expectedError  errcode.Code
+	}{
+		{
+			name:           "Most specific executor with layered executors",
+			artifact:       "registry.example.com/team-a/app:v1",
+			expectedScopes: []string{"registry.example.com/team-a/**", "registry.example.com", "*"},
+		},
+		{
+			name:           "Layered executor as the most specific executor",
+			artifact:       "registry.example.com/team-b/app:v1",
+			expectedScopes: []string{"registry.example.com", "*"},
+		},
+		{
+			name:           "Executors that are not layered are skipped",
+			artifact:       "other.example.com/app:v1",
+			expectedScopes: []string{"*.example.com", "*"},
+		},
+		{
+			name:           "Catch-all executor only",
+			artifact:       "unknown.io/app:v1",
+			expectedScopes: []string{"*"},
+		},
+		{
+			name:          "Invalid artifact",
+			artifact:      "invalid-artifact",
+			expectedError: errcode.InvalidReference,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			layers, err := scopedExecutor.matchLayers(test.artifact)
+			if code := errcode.Classify(err); code != test.expectedError {
+				t.Fatalf("expected error code %q, got %q: %v", test.expectedError, code, err)
+			}
+			var scopes []string
+			for _, l := range layers {
+				scopes = append(scopes, l.scope)
+			}
+			if !slices.Equal(scopes, test.expectedScopes) {
+				t.Errorf("expected scopes %v, got %v", test.expectedScopes, scopes)
+			}
+		})
+	}
+}
+
+func TestMatchLayers_SameExecutor(t *testing.T) {
+	baseline := &ratify.Executor{}
+	scopedExecutor := newLayeredExecutor(t, map[string]*ratify.Executor{
+		"registry.example.com": baseline,
+		"*":                    baseline,
+	}, baseline)
+
+	layers, err := scopedExecutor.matchLayers("registry.example.com/app:v1")
+	if err != nil {
+		t.Fatalf("failed to match layers: %v", err)
+	}
+	if len(layers) != 1 || layers[0].scope != "registry.example.com" {
+		t.Errorf("expected the executor to be evaluated once, got %+v", layers)
+	}
+}
+
+func TestRegisterExecutor_SameScope(t *testing.T) {
+	scopes := []string{
+		"*",
+		"*.example.com",
+		"registry.example.com",
+		"registry.example.com/team-a/app",
+		"registry.example.com/team-a/*",
+	}
+	tests := []struct {
+		name            string
+		firstLayered    bool
+		secondLayered   bool
+		expectedErr     string
+		expectedRouted  int
+		expectedLayered int
+	}{
+		{
+			name:        "Executors that are not layered",
+			expectedErr: "only layered executors can be registered on the same scope",
+		},
+		{
+			name:            "Layered executor after an executor that is not layered",
+			secondLayered:   true,
+			expectedRouted:  0,
+			expectedLayered: 2,
+		},
+		{
+			name:            "Executor that is not layered after a layered executor",
+			firstLayered:    true,
+			expectedRouted:  1,
+			expectedLayered: 2,
+		},
+		{
+			name:            "Layered executors",
+			firstLayered:    true,
+			secondLayered:   true,
+			expectedRouted:  0,
+			expectedLayered: 2,
+		},
+	}
+
+	for _, test := range tests {
+		for _, scope := range scopes {
+			t.Run(test.name+"_"+scope, func(t *testing.T) {
+				executors := []*ratify.Executor{{}, {}}
+				scopedExecutor := &ScopedExecutor{layered: map[*ratify.Executor]bool{
+					executors[0]: test.firstLayered,
+					executors[1]: test.secondLayered,
+				}}
+				if err := scopedExecutor.registerExecutor(scope, executors[0]); err != nil {
+					t.Fatalf("failed to register executor for scope %q: %v", scope, err)
+				}
+				err := scopedExecutor.registerExecutor(scope, executors[1])
+				if test.expectedErr != "" {
+					if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+						t.Errorf("expected error containing %q, got: %v", test.expectedErr, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("failed to register executor for scope %q: %v", scope, err)
+				}
+				if err := scopedExecutor.registerExecutor(scope, executors[1]); err == nil {
+					t.Error("expected error registering the same executor twice, got nil")
+				}
+
+				layers, err := scopedExecutor.matchLayers("registry.example.com/team-a/app:v1")
+				if err != nil {
+					t.Fatalf("failed to match layers: %v", err)
+				}
+				if len(layers) != test.expectedLayered {
+					t.Fatalf("expected %d layers, got %d", test.expectedLayered, len(layers))
+				}
+				if layers[0].executor != executors[test.expectedRouted] {
+					t.Errorf("expected executor %d to be routed to", test.expectedRouted)
+				}
+				for _, l := range layers {
+					if l.scope != scope {
+						t.Errorf("expected layer of scope %q, got %q", scope, l.scope)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestRegisterRepositoryPattern_LayeredOverlap(t *testing.T) {
+	e1 := &ratify.Executor{}
+	e2 := &ratify.Executor{}
+	scopedExecutor := &ScopedExecutor{layered: map[*ratify.Executor]bool{e1: true, e2: true}}
+	if err := scopedExecutor.registerExecutor("registry.example.com/team-*/a*p", e1); err != nil {
+		t.Fatalf("failed to register executor: %v", err)
+	}
+	if err := scopedExecutor.registerExecutor("registry.example.com/team-*/ap*", e2); err != nil {
+		t.Fatalf("expected overlapping patterns of layered executors to be accepted, got: %v", err)
+	}
+	layers, err := scopedExecutor.matchLayers("registry.example.com/team-a/app:v1")
+	if err != nil {
+		t.Fatalf("failed to match layers: %v", err)
+	}
+	if len(layers) != 2 {
+		t.Errorf("expected both layered executors to be evaluated, got %d", len(layers))
+	}
+}
+
+func TestValidateArtifactWithOptions_LayeredSameScope(t *testing.T) {
+	subjectDigest := digest.FromString("subject")
+	subject := "registry.example.com/app@" + subjectDigest.String()
+	tests := []struct {
+		name              string
+		firstSigned       bool
+		secondSigned      bool
+		expectedSucceeded bool
+	}{
+		{
+			name:              "All executors pass",
+			firstSigned:       true,
+			secondSigned:      true,
+			expectedSucceeded: true,
+		},
+		{
+			name:              "First executor fails",
+			secondSigned:      true,
+			expectedSucceeded: false,
+		},
+		{
+			name:              "Second executor fails",
+			firstSigned:       true,
+			expectedSucceeded: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var firstSigned, secondSigned []digest.Digest
+			if test.firstSigned {
+				firstSigned = append(firstSigned, subjectDigest)
+			}
+			if test.secondSigned {
+				secondSigned = append(secondSigned, subjectDigest)
+			}
+			first := newSignedExecutor(t, firstSigned...)
+			second := newSignedExecutor(t, secondSigned...)
+			scopedExecutor := newLayeredExecutor(t, nil, first, second)
+			for _, executor := range []*ratify.Executor{first, second} {
+				if err := scopedExecutor.registerExecutor("registry.example.com", executor); err != nil {
+					t.Fatalf("failed to register executor: %v", err)
+				}
+			}
+
+			result, err := scopedExecutor.ValidateArtifactWithOptions(context.Background(), ValidateOptions{
+				ValidateArtifactOptions: ratify.ValidateArtifactOptions{
+					Subject: subject,
+				},
+			})
+			if err != nil {
+				t.Fatalf("failed to validate artifact: %v", err)
+			}
+			if result.Succeeded != test.expectedSucceeded {
+				t.Errorf("expected succeeded %t, got %t", test.expectedSucceeded, result.Succeeded)
+			}
+			if len(result.ArtifactReports) != 2 {
+				t.Errorf("expected a report per executor, got %d", len(result.ArtifactReports))
+			}
+		})
+	}
+}
+
+func TestValidateArtifactWithOptions_Layered(t *testing.T) {
+	subjectDigest := digest.FromString("subject")
+	subject := "registry.example.com/team-a/app@" + subjectDigest.String()
+	tests := []struct {
+		name              string
+		teamSigned        bool
+		baselineSigned    bool
+		expectedSucceeded bool
+		expectedFailed    []string
+	}{
+		{
+			name:              "All layers pass",
+			teamSigned:        true,
+			baselineSigned:    true,
+			expectedSucceeded: true,
+		},
+		{
+			name:              "Baseline fails",
+			teamSigned:        true,
+			baselineSigned:    false,
+			expectedSucceeded: false,
+			expectedFailed:    []string{"*"},
+		},
+		{
+			name:              "Team layer fails",
+			teamSigned:        false,
+			baselineSigned:    true,
+			expectedSucceeded: false,
+			expectedFailed:    []string{"registry.example.com/team-a/*"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var teamSigned, baselineSigned []digest.Digest
+			if test.teamSigned {
+				teamSigned = append(teamSigned, subjectDigest)
+			}
+			if test.baselineSigned {
+				baselineSigned = append(baselineSigned, subjectDigest)
+			}
+			baseline := newSignedExecutor(t, baselineSigned...)
+			scopedExecutor := newLayeredExecutor(t, map[string]*ratify.Executor{
+				"registry.example.com/team-a/*": newSignedExecutor(t, teamSigned...),
+				"*":                             baseline,
+			}, baseline)
+
+			result, err := scopedExecutor.ValidateArtifactWithOptions(context.Background(), ValidateOptions{
+				ValidateArtifactOptions: ratify.ValidateArtifactOptions{
+					Subject: subject,
+				},
+			})
+			if err != nil {
+				t.Fatalf("failed to validate artifact: %v", err)
+			}
+			if result.Succeeded != test.expectedSucceeded {
+				t.Errorf("expected succeeded %t, got %t", test.expectedSucceeded, result.Succeeded)
+			}
+			if len(result.ArtifactReports) != 2 {
+				t.Fatalf("expected a report per layer, got %d", len(result.ArtifactReports))
+			}
+			var failed []string
+			for _, report := range result.ArtifactReports {
+				if report.Subject != subject || report.Artifact.Digest != subjectDigest {
+					t.Errorf("unexpected layer report of %s (%s)", report.Subject, report.Artifact.Digest)
+				}
+				if len(report.Results) != 1 {
+					t.Fatalf("expected a single result per layer, got %d", len(report.Results))
+				}
+				if result := report.Results[0]; result.Err != nil {
+					scope, _, _ := strings.Cut(strings.TrimPrefix(result.Description, "executor of scope "), " failed")
+					failed = append(failed, strings.Trim(scope, `"`))
+				} else if len(report.ArtifactReports) == 0 {
+					t.Errorf("expected the reports of the passed layer to be nested")
+				}
+			}
+			if !slices.Equal(failed, test.expectedFailed) {
+				t.Errorf("expected failed layers %v, got %v", test.expectedFailed, failed)
+			}
+		})
+	}
+}
+
+func TestValidateArtifactWithOptions_AuditOnly(t *testing.T) {
+	subjectDigest := digest.FromString("subject")
+	subject := "registry.example.com/team-a/app@" + subjectDigest.String()
+	tests := []struct {
+		name                    string
+		teamSigned              bool
+		teamUnavailable         bool
+		baselineSigned          bool
+		layered                 bool
+		expectedSucceeded       bool
+		expectedWouldHaveFailed bool
+	}{
+		{
+			name:                    "Audit-only executor passes",
+			teamSigned:              true,
+			expectedSucceeded:       true,
+			expectedWouldHaveFailed: false,
+		},
+		{
+			name:                    "Audit-only executor fails",
+			teamSigned:              false,
+			expectedSucceeded:       true,
+			expectedWouldHaveFailed: true,
+		},
+		{
+			name:                    "Audit-only layer fails",
+			teamSigned:              false,
+			baselineSigned:          true,
+			layered:                 true,
+			expectedSucceeded:       true,
+			expectedWouldHaveFailed: true,
+		},
+		{
+			name:                    "Enforced layer fails",
+			teamSigned:              true,
+			baselineSigned:          false,
+			layered:                 true,
+			expectedSucceeded:       false,
+			expectedWouldHaveFailed: false,
+		},
+
+		{
+			name:                    "Audit-only executor cannot complete",
+			teamUnavailable:         true,
+			expectedSucceeded:       true,
+			expectedWouldHaveFailed: true,
+		},
+		{
+			name:                    "Audit-only layer cannot complete",
+			teamUnavailable:         true,
+			baselineSigned:          true,
+			layered:                 true,
+			expectedSucceeded:       true,
+			expectedWouldHaveFailed: true,
+		},
+		{
+			name:                    "Enforced layer fails while audit-only layer cannot complete",
+			teamUnavailable:         true,
+			baselineSigned:          false,
+			layered:                 true,
+			expectedSucceeded:       false,
+			expectedWouldHaveFailed: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var teamSigned, baselineSigned []digest.Digest
+			if test.teamSigned {
+				teamSigned = append(teamSigned, subjectDigest)
+			}
+			if test.baselineSigned {
+				baselineSigned = append(baselineSigned, subjectDigest)
+			}
+			team := newSignedExecutor(t, teamSigned...)
+			if test.teamUnavailable {
+				team.Store = &unavailableStore{}
+			}
+			scopes := map[string]*ratify.Executor{
+				"registry.example.com/team-a/*": team,
+			}
+			var layered []*ratify.Executor
+			if test.layered {
+				baseline := newSignedExecutor(t, baselineSigned...)
+				scopes["*"] = baseline
+				layered = append(layered, baseline)
+			}
+			scopedExecutor := newLayeredExecutor(t, scopes, layered...)
+			scopedExecutor.auditOnly[team] = true
+
+			result, err := scopedExecutor.ValidateArtifactWithOptions(context.Background(), ValidateOptions{
+				ValidateArtifactOptions: ratify.ValidateArtifactOptions{
+					Subject: subject,
+				},
+			})
+			if err != nil {
+				t.Fatalf("failed to validate artifact: %v", err)
+			}
+			if result.Succeeded != test.expectedSucceeded {
+				t.Errorf("expected succeeded %t, got %t", test.expectedSucceeded, result.Succeeded)
+			}
+			if result.WouldHaveFailed != test.expectedWouldHaveFailed {
+				t.Errorf("expected would have failed %t, got %t", test.expectedWouldHaveFailed, result.WouldHaveFailed)
+			}
+			if !slices.Equal(result.AuditOnlyScopes, []string{"registry.example.com/team-a/*"}) {
+				t.Errorf("expected audit-only scopes of the team executor, got %v", result.AuditOnlyScopes)
+			}
+			if (result.AuditOnlyErr != nil) != test.teamUnavailable {
+				t.Errorf("expected audit-only error %t, got %v", test.teamUnavailable, result.AuditOnlyErr)
+			}
+			if test.teamUnavailable && errcode.Classify(result.AuditOnlyErr) != errcode.RegistryUnavailable {
+				t.Errorf("expected %s audit-only error, got %v", errcode.RegistryUnavailable, result.AuditOnlyErr)
+			}
+		})
+	}
+}
+
+func TestLayeredCacheTTLAndFingerprint(t *testing.T) {
+	team := &ratify.Executor{}
+	baseline := &ratify.Executor{}
+	scopedExecutor := newLayeredExecutor(t, map[string]*ratify.Executor{
+		"registry.example.com/team-a/*": team,
+		"registry.example.com":          baseline,
+	}, baseline)
+	scopedExecutor.cacheTTL = CacheTTL{Success: time.Hour}
+	scopedExecutor.scopedCacheTTL[team] = CacheTTL{Success: 2 * time.Hour, Failure: time.Minute}
+	scopedExecutor.scopedCacheTTL[baseline] = CacheTTL{Success: 10 * time.Minute}
+	scopedExecutor.fingerprints[team] = "team"
+	scopedExecutor.fingerprints[baseline] = "baseline"
+
+	expectedTTL := CacheTTL{Success: 10 * time.Minute, Failure: time.Minute}
+	if ttl := scopedExecutor.CacheTTL("registry.example.com/team-a/app:v1"); ttl != expectedTTL {
+		t.Errorf("expected cache ttl %+v, got %+v", expectedTTL, ttl)
+	}
+
+	layeredFingerprint := scopedExecutor.Fingerprint("registry.example.com/team-a/app:v1")
+	baselineFingerprint := scopedExecutor.Fingerprint("registry.example.com/team-b/app:v1")
+	if baselineFingerprint != "baseline" {
+		t.Errorf("expected fingerprint of the baseline, got %q", baselineFingerprint)
+	}
+	if layeredFingerprint == "team" || layeredFingerprint == "baseline" {
+		t.Errorf("expected combined fingerprint of the layers, got %q", layeredFingerprint)
+	}
+}
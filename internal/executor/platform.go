@@ -0,0 +1,113 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/notaryproject/ratify/v2/internal/errcode"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+)
+
+// mediaTypeDockerManifestList is the media type of a Docker manifest list,
+// which is resolved the same way as an OCI image index.
+const mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+
+// ParsePlatform parses a platform in the format "os/arch[/variant]", e.g.
+// "linux/amd64" or "linux/arm64/v8".
+func ParsePlatform(value string) (*ocispec.Platform, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("invalid platform %q: expected format os/arch[/variant]", value)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("invalid platform %q: expected format os/arch[/variant]", value)
+		}
+	}
+	platform := &ocispec.Platform{
+		OS:           parts[0],
+		Architecture: parts[1],
+	}
+	if len(parts) == 3 {
+		platform.Variant = parts[2]
+	}
+	return platform, nil
+}
+
+// ResolvePlatform retrieves the descriptor for the specified artifact like
+// [ScopedExecutor.Resolve]. If platform is provided and the artifact is an
+// image index or a manifest list, the descriptor of the manifest matching the
+// platform is returned instead.
+func (s *ScopedExecutor) ResolvePlatform(ctx context.Context, artifact string, platform *ocispec.Platform) (ocispec.Descriptor, error) {
+	executor, err := s.tracedMatchExecutor(ctx, artifact)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to match executor for artifact %q: %w", artifact, err)
+	}
+	desc, err := executor.Store.Resolve(ctx, artifact)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if platform == nil || (desc.MediaType != ocispec.MediaTypeImageIndex && desc.MediaType != mediaTypeDockerManifestList) {
+		return desc, nil
+	}
+
+	ref, err := registry.ParseReference(artifact)
+	if err != nil {
+		return ocispec.Descriptor{}, errcode.InvalidReference.Errorf("failed to parse artifact reference %q: %w", artifact, err)
+	}
+	repo := ref.Registry + "/" + ref.Repository
+	manifest, err := executor.Store.FetchManifest(ctx, repo, desc)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to fetch index of artifact %q: %w", artifact, err)
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(manifest, &index); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to decode index of artifact %q: %w", artifact, err)
+	}
+	for _, child := range index.Manifests {
+		if matchPlatform(child.Platform, platform) {
+			return child, nil
+		}
+	}
+	return ocispec.Descriptor{}, errcode.SubjectNotFound.Errorf("no manifest found for platform %s in the index of artifact %q", FormatPlatform(platform), artifact)
+}
+
+// matchPlatform reports whether got satisfies want. The variant is only
+// compared if want specifies one.
+func matchPlatform(got, want *ocispec.Platform) bool {
+	if got == nil {
+		return false
+	}
+	if got.OS != want.OS || got.Architecture != want.Architecture {
+		return false
+	}
+	return want.Variant == "" || got.Variant == want.Variant
+}
+
+// FormatPlatform renders the platform in the format accepted by
+// [ParsePlatform].
+func FormatPlatform(platform *ocispec.Platform) string {
+	value := platform.OS + "/" + platform.Architecture
+	if platform.Variant != "" {
+		value += "/" + platform.Variant
+	}
+	return value
+}
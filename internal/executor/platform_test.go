@@ -0,0 +1,163 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/errcode"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+var (
+	indexDesc = ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Digest:    digest.FromString("index"),
+	}
+	amd64Desc = ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromString("amd64"),
+		Platform:  &ocispec.Platform{OS: "linux", Architecture: "amd64"},
+	}
+	arm64Desc = ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromString("arm64"),
+		Platform:  &ocispec.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"},
+	}
+)
+
+// indexStore resolves every reference to an image index of amd64Desc and
+// arm64Desc.
+type indexStore struct {
+	mockStore
+}
+
+func (s *indexStore) Resolve(_ context.Context, _ string) (ocispec.Descriptor, error) {
+	return indexDesc, nil
+}
+
+func (s *indexStore) FetchManifest(_ context.Context, _ string, _ ocispec.Descriptor) ([]byte, error) {
+	return json.Marshal(ocispec.Index{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{amd64Desc, arm64Desc},
+	})
+}
+
+func TestParsePlatform(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         string
+		expected      *ocispec.Platform
+		expectedError bool
+	}{
+		{
+			name:     "os and arch",
+			value:    "linux/amd64",
+			expected: &ocispec.Platform{OS: "linux", Architecture: "amd64"},
+		},
+		{
+			name:     "os, arch and variant",
+			value:    "linux/arm64/v8",
+			expected: &ocispec.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"},
+		},
+		{
+			name:          "missing arch",
+			value:         "linux",
+			expectedError: true,
+		},
+		{
+			name:          "empty arch",
+			value:         "linux/",
+			expectedError: true,
+		},
+		{
+			name:          "too many parts",
+			value:         "linux/arm64/v8/extra",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			platform, err := ParsePlatform(test.value)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if !reflect.DeepEqual(platform, test.expected) {
+				t.Errorf("expected platform: %+v, got: %+v", test.expected, platform)
+			}
+		})
+	}
+}
+
+func TestResolvePlatform(t *testing.T) {
+	scopedExecutor := &ScopedExecutor{
+		registry: map[string]*ratify.Executor{
+			"registry.example.com": {
+				Store: &indexStore{},
+			},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		platform      *ocispec.Platform
+		expected      ocispec.Descriptor
+		expectedError errcode.Code
+	}{
+		{
+			name:     "no platform",
+			expected: indexDesc,
+		},
+		{
+			name:     "matching platform",
+			platform: &ocispec.Platform{OS: "linux", Architecture: "amd64"},
+			expected: amd64Desc,
+		},
+		{
+			name:     "matching platform without variant",
+			platform: &ocispec.Platform{OS: "linux", Architecture: "arm64"},
+			expected: arm64Desc,
+		},
+		{
+			name:          "mismatched variant",
+			platform:      &ocispec.Platform{OS: "linux", Architecture: "arm64", Variant: "v7"},
+			expectedError: errcode.SubjectNotFound,
+		},
+		{
+			name:          "unknown platform",
+			platform:      &ocispec.Platform{OS: "windows", Architecture: "amd64"},
+			expectedError: errcode.SubjectNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			desc, err := scopedExecutor.ResolvePlatform(context.Background(), "registry.example.com/foo:v1", test.platform)
+			if code := errcode.Classify(err); code != test.expectedError {
+				t.Fatalf("expected error code: %q, got: %q (%v)", test.expectedError, code, err)
+			}
+			if !reflect.DeepEqual(desc, test.expected) {
+				t.Errorf("expected descriptor: %+v, got: %+v", test.expected, desc)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/notaryproject/ratify-go"
+	"oras.land/oras-go/v2/registry"
+)
+
+// multiLevelWildcard is the last component of a repository pattern that
+// matches any number of nested repository components.
+const multiLevelWildcard = "**"
+
+// repositoryPattern is a repository scope containing wildcards, e.g.
+// "registry.example.com/team-a/*" or "registry.example.com/team-a/**".
+type repositoryPattern struct {
+	// scope is the scope the pattern is parsed from.
+	scope string
+
+	// components are the glob patterns of the repository components, not
+	// including the trailing multi-level wildcard.
+	components []string
+
+	// multiLevel indicates the pattern ends with "**" and matches one or more
+	// repository components after components.
+	multiLevel bool
+
+	// prefixLength is the length of the scope before the first wildcard and
+	// literalLength is the number of characters of the scope that are not
+	// wildcards. They define the precedence of the pattern.
+	prefixLength  int
+	literalLength int
+
+	executor *ratify.Executor
+}
+
+// parseRepositoryPattern parses a repository scope containing wildcards. A
+// "*" matches any characters within a repository component and a trailing
+// "**" component matches one or more repository components. The registry of
+// the scope cannot contain wildcards.
+func parseRepositoryPattern(scope string, executor *ratify.Executor) (*repositoryPattern, error) {
+	registryName, repository, _ := strings.Cut(scope, "/")
+	if strings.Contains(registryName, "*") {
+		return nil, fmt.Errorf("invalid scope %q: registry of a repository scope cannot contain wildcard", scope)
+	}
+	pattern := &repositoryPattern{
+		scope:         scope,
+		components:    strings.Split(repository, "/"),
+		prefixLength:  strings.Index(scope, "*"),
+		literalLength: len(scope) - strings.Count(scope, "*"),
+		executor:      executor,
+	}
+	if last := len(pattern.components) - 1; pattern.components[last] == multiLevelWildcard {
+		pattern.components = pattern.components[:last]
+		pattern.multiLevel = true
+	}
+	for _, component := range pattern.components {
+		if strings.Contains(component, multiLevelWildcard) {
+			return nil, fmt.Errorf("invalid scope %q: %q can only be the last component of the scope", scope, multiLevelWildcard)
+		}
+	}
+
+	// validate the scope with the wildcards replaced by a valid component.
+	ref, err := registry.ParseReference(strings.ReplaceAll(scope, "*", "x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid scope %q: %w", scope, err)
+	}
+	if ref.Reference != "" {
+		return nil, fmt.Errorf("invalid scope %q: scope cannot contain a tag or digest", scope)
+	}
+	return pattern, nil
+}
+
+// match reports whether the repository, not including the registry, matches
+// the pattern.
+func (p *repositoryPattern) match(repository string) bool {
+	components := strings.Split(repository, "/")
+	if p.multiLevel {
+		if len(components) <= len(p.components) {
+			return false
+		}
+		components = components[:len(p.components)]
+	} else if len(components) != len(p.components) {
+		return false
+	}
+	for i, component := range components {
+		if ok, _ := path.Match(p.components[i], component); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// compare returns a negative number if p takes precedence over q, a positive
+// number if q takes precedence over p, and 0 if they have the same
+// precedence. Patterns with a longer prefix before the first wildcard take
+// precedence, followed by patterns with more literal characters and then
+// patterns without a trailing multi-level wildcard.
+func (p *repositoryPattern) compare(q *repositoryPattern) int {
+	if p.prefixLength != q.prefixLength {
+		return q.prefixLength - p.prefixLength
+	}
+	if p.literalLength != q.literalLength {
+		return q.literalLength - p.literalLength
+	}
+	switch {
+	case p.multiLevel == q.multiLevel:
+		return 0
+	case q.multiLevel:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// overlaps reports whether there is a repository matching both p and q. The
+// patterns must have the same registry.
+func (p *repositoryPattern) overlaps(q *repositoryPattern) bool {
+	switch {
+	case !p.multiLevel && !q.multiLevel && len(p.components) != len(q.components):
+		return false
+	case !p.multiLevel && q.multiLevel && len(p.components) <= len(q.components):
+		return false
+	case p.multiLevel && !q.multiLevel && len(q.components) <= len(p.components):
+		return false
+	}
+	for i := range min(len(p.components), len(q.components)) {
+		if !globsOverlap(p.components[i], q.components[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// globsOverlap reports whether there is a string matching both glob patterns
+// p and q, where "*" is the only special character.
+func globsOverlap(p, q string) bool {
+	// overlap[i][j] reports whether p[i:] and q[j:] overlap.
+	overlap := make([][]bool, len(p)+1)
+	for i := range overlap {
+		overlap[i] = make([]bool, len(q)+1)
+	}
+	overlap[len(p)][len(q)] = true
+	for i := len(p); i >= 0; i-- {
+		for j := len(q); j >= 0; j-- {
+			switch {
+			case i == len(p) && j == len(q):
+			case i < len(p) && p[i] == '*':
+				// the wildcard of p matches nothing or the next character of q.
+				overlap[i][j] = overlap[i+1][j] || (j < len(q) && overlap[i][j+1])
+			case j < len(q) && q[j] == '*':
+				overlap[i][j] = overlap[i][j+1] || (i < len(p) && overlap[i+1][j])
+			case i < len(p) && j < len(q):
+				overlap[i][j] = p[i] == q[j] && overlap[i+1][j+1]
+			}
+		}
+	}
+	return overlap[0][0]
+}
+
+// componentScopes returns the scopes inherited by the stores and verifiers of
+// an executor registered for the scopes. They do not support repository
+// patterns, so a pattern is widened to the registry of its scope. This does
+// not route other repositories to them, as only the artifacts matching the
+// pattern are routed to the executor.
+func componentScopes(scopes []string) []string {
+	componentScopes := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if strings.Contains(scope, "/") && strings.Contains(scope, "*") {
+			scope, _, _ = strings.Cut(scope, "/")
+		}
+		if !slices.Contains(componentScopes, scope) {
+			componentScopes = append(componentScopes, scope)
+		}
+	}
+	return componentScopes
+}
@@ -0,0 +1,340 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/ratify-go"
+)
+
+func TestRepositoryPatternMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		scope      string
+		repository string
+		want       bool
+	}{
+		{
+			name:       "Single-level pattern matches direct child",
+			scope:      "registry.example.com/team-a/*",
+			repository: "team-a/app",
+			want:       true,
+		},
+		{
+			name:       "Single-level pattern does not match nested child",
+			scope:      "registry.example.com/team-a/*",
+			repository: "team-a/app/sub",
+			want:       false,
+		},
+		{
+			name:       "Single-level pattern does not match prefix",
+			scope:      "registry.example.com/team-a/*",
+			repository: "team-a",
+			want:       false,
+		},
+		{
+			name:       "Multi-level pattern matches direct child",
+			scope:      "registry.example.com/team-a/**",
+			repository: "team-a/app",
+			want:       true,
+		},
+		{
+			name:       "Multi-level pattern matches nested child",
+			scope:      "registry.example.com/team-a/**",
+			repository: "team-a/app/sub",
+			want:       true,
+		},
+		{
+			name:       "Multi-level pattern does not match prefix",
+			scope:      "registry.example.com/team-a/**",
+			repository: "team-a",
+			want:       false,
+		},
+		{
+			name:       "Multi-level pattern does not match sibling",
+			scope:      "registry.example.com/team-a/**",
+			repository: "team-b/app",
+			want:       false,
+		},
+		{
+			name:       "Component glob matches",
+			scope:      "registry.example.com/team-*/app",
+			repository: "team-b/app",
+			want:       true,
+		},
+		{
+			name:       "Component glob does not match across components",
+			scope:      "registry.example.com/team-*/app",
+			repository: "team-b/c/app",
+			want:       false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pattern, err := parseRepositoryPattern(test.scope, nil)
+			if err != nil {
+				t.Fatalf("failed to parse pattern: %v", err)
+			}
+			if got := pattern.match(test.repository); got != test.want {
+				t.Errorf("expected match: %v, got: %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestRepositoryPatternCompare(t *testing.T) {
+	tests := []struct {
+		name string
+		p    string
+		q    string
+		want int
+	}{
+		{
+			name: "Longer prefix takes precedence",
+			p:    "registry.example.com/team-a/*",
+			q:    "registry.example.com/*/app",
+			want: -1,
+		},
+		{
+			name: "More literal characters take precedence",
+			p:    "registry.example.com/team-*/*",
+			q:    "registry.example.com/team-*/app",
+			want: 1,
+		},
+		{
+			name: "Single-level pattern takes precedence",
+			p:    "registry.example.com/team-a/*",
+			q:    "registry.example.com/team-a/**",
+			want: -1,
+		},
+		{
+			name: "Same precedence",
+			p:    "registry.example.com/team-*/a*p",
+			q:    "registry.example.com/team-*/ap*",
+			want: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := parseRepositoryPattern(test.p, nil)
+			if err != nil {
+				t.Fatalf("failed to parse pattern: %v", err)
+			}
+			q, err := parseRepositoryPattern(test.q, nil)
+			if err != nil {
+				t.Fatalf("failed to parse pattern: %v", err)
+			}
+			got := p.compare(q)
+			if (got < 0) != (test.want < 0) || (got > 0) != (test.want > 0) {
+				t.Errorf("expected compare: %d, got: %d", test.want, got)
+			}
+		})
+	}
+}
+
+func TestRepositoryPatternOverlaps(t *testing.T) {
+	tests := []struct {
+		name string
+		p    string
+		q    string
+		want bool
+	}{
+		{
+			name: "Single-level patterns overlap",
+			p:    "registry.example.com/team-*/app",
+			q:    "registry.example.com/*-a/app",
+			want: true,
+		},
+		{
+			name: "Single-level patterns with different lengths",
+			p:    "registry.example.com/team-a/*",
+			q:    "registry.example.com/team-a/*/*",
+			want: false,
+		},
+		{
+			name: "Single-level patterns with disjoint components",
+			p:    "registry.example.com/team-a/*",
+			q:    "registry.example.com/team-b/*",
+			want: false,
+		},
+		{
+			name: "Multi-level pattern overlaps nested pattern",
+			p:    "registry.example.com/team-a/**",
+			q:    "registry.example.com/*/app/*",
+			want: true,
+		},
+		{
+			name: "Multi-level pattern does not overlap its prefix",
+			p:    "registry.example.com/team-a/app/**",
+			q:    "registry.example.com/team-*/*",
+			want: false,
+		},
+		{
+			name: "Multi-level patterns overlap",
+			p:    "registry.example.com/team-a/**",
+			q:    "registry.example.com/*/b/**",
+			want: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := parseRepositoryPattern(test.p, nil)
+			if err != nil {
+				t.Fatalf("failed to parse pattern: %v", err)
+			}
+			q, err := parseRepositoryPattern(test.q, nil)
+			if err != nil {
+				t.Fatalf("failed to parse pattern: %v", err)
+			}
+			if got := p.overlaps(q); got != test.want {
+				t.Errorf("expected overlaps: %v, got: %v", test.want, got)
+			}
+			if got := q.overlaps(p); got != test.want {
+				t.Errorf("expected reversed overlaps: %v, got: %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestGlobsOverlap(t *testing.T) {
+	tests := []struct {
+		p    string
+		q    string
+		want bool
+	}{
+		{p: "app", q: "app", want: true},
+		{p: "app", q: "api", want: false},
+		{p: "*", q: "app", want: true},
+		{p: "a*", q: "*b", want: true},
+		{p: "a*", q: "b*", want: false},
+		{p: "*a", q: "*b", want: false},
+		{p: "a*c", q: "ab*", want: true},
+		{p: "a*c", q: "*b*d", want: false},
+		{p: "*", q: "*", want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.p+"_"+test.q, func(t *testing.T) {
+			if got := globsOverlap(test.p, test.q); got != test.want {
+				t.Errorf("expected overlap: %v, got: %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestRegisterRepositoryPattern(t *testing.T) {
+	e1 := &ratify.Executor{}
+	e2 := &ratify.Executor{}
+	tests := []struct {
+		name        string
+		scopes      map[string]*ratify.Executor
+		scope       string
+		executor    *ratify.Executor
+		expectedErr string
+	}{
+		{
+			name:        "Duplicate scope",
+			scopes:      map[string]*ratify.Executor{"registry.example.com/team-a/*": e1},
+			scope:       "registry.example.com/team-a/*",
+			executor:    e1,
+			expectedErr: `executor already registered for scope "registry.example.com/team-a/*"`,
+		},
+		{
+			name:        "Overlapping scopes of different executors",
+			scopes:      map[string]*ratify.Executor{"registry.example.com/team-*/a*p": e1},
+			scope:       "registry.example.com/team-*/ap*",
+			executor:    e2,
+			expectedErr: `scope "registry.example.com/team-*/ap*" overlaps with scope "registry.example.com/team-*/a*p"`,
+		},
+		{
+			name:     "Overlapping scopes of the same executor",
+			scopes:   map[string]*ratify.Executor{"registry.example.com/team-*/a*p": e1},
+			scope:    "registry.example.com/team-*/ap*",
+			executor: e1,
+		},
+		{
+			name:     "Overlapping scopes with different precedence",
+			scopes:   map[string]*ratify.Executor{"registry.example.com/team-a/**": e1},
+			scope:    "registry.example.com/team-a/*",
+			executor: e2,
+		},
+		{
+			name:     "Overlapping scopes of different registries",
+			scopes:   map[string]*ratify.Executor{"registry.example.com/team-*/a*p": e1},
+			scope:    "other.example.com/team-*/ap*",
+			executor: e2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scopedExecutor := &ScopedExecutor{}
+			for scope, executor := range test.scopes {
+				if err := scopedExecutor.registerExecutor(scope, executor); err != nil {
+					t.Fatalf("failed to register executor for scope %q: %v", scope, err)
+				}
+			}
+			err := scopedExecutor.registerExecutor(test.scope, test.executor)
+			if test.expectedErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+				t.Errorf("expected error containing %q, got: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestComponentScopes(t *testing.T) {
+	tests := []struct {
+		name     string
+		scopes   []string
+		expected []string
+	}{
+		{
+			name:     "Registry and repository scopes",
+			scopes:   []string{"registry.example.com", "*.example.com", "other.example.com/app"},
+			expected: []string{"registry.example.com", "*.example.com", "other.example.com/app"},
+		},
+		{
+			name:     "Repository patterns",
+			scopes:   []string{"registry.example.com/team-a/*", "registry.example.com/team-b/**", "other.example.com/app*"},
+			expected: []string{"registry.example.com", "other.example.com"},
+		},
+		{
+			name:     "Repository pattern of a registry scope",
+			scopes:   []string{"registry.example.com", "registry.example.com/team-a/*"},
+			expected: []string{"registry.example.com"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if scopes := componentScopes(test.scopes); !slices.Equal(scopes, test.expected) {
+				t.Errorf("expected scopes %v, got %v", test.expected, scopes)
+			}
+		})
+	}
+}
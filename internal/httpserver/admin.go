@@ -0,0 +1,141 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/notaryproject/ratify/v2/internal/logger"
+)
+
+const bearerPrefix = "Bearer "
+
+// purgeRequest is the request body of the cache purge API. Exactly one of the
+// fields must be set.
+type purgeRequest struct {
+	// Reference purges the entries of the exact artifact reference.
+	Reference string `json:"reference,omitempty"`
+
+	// RepositoryPrefix purges the entries of all artifact references starting
+	// with the prefix, e.g. "registry.example.com/team-a/".
+	RepositoryPrefix string `json:"repositoryPrefix,omitempty"`
+
+	// All purges all entries.
+	All bool `json:"all,omitempty"`
+}
+
+// validate checks that exactly one purge target is set.
+func (p *purgeRequest) validate() error {
+	targets := 0
+	if p.Reference != "" {
+		targets++
+	}
+	if p.RepositoryPrefix != "" {
+		targets++
+	}
+	if p.All {
+		targets++
+	}
+	if targets != 1 {
+		return errors.New("exactly one of reference, repositoryPrefix or all must be set")
+	}
+	return nil
+}
+
+// purgeCache handles the request to purge the verify, mutate and validation
+// cache entries.
+func (s *server) purgeCache(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+	if err := s.authorizeAdmin(r); err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return err
+	}
+
+	var req purgeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return fmt.Errorf("failed to decode purge request: %w", err)
+	}
+	if err := req.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	var err error
+	switch {
+	case req.Reference != "":
+		logger.GetLogger(ctx, logOpt).Infof("purging cache entries of reference %s", req.Reference)
+		err = errors.Join(
+			s.verifyCache.Delete(ctx, verifyKey(req.Reference)),
+			s.verifyCache.DeleteByPrefix(ctx, verifyKey(req.Reference)+"?"),
+			s.mutateCache.Delete(ctx, mutateKey(req.Reference)),
+			s.mutateCache.DeleteByPrefix(ctx, mutateKey(req.Reference)+"?"),
+			s.validationCache.DeleteByPrefix(ctx, validationKeyPrefix(req.Reference)+"?"),
+		)
+	case req.RepositoryPrefix != "":
+		logger.GetLogger(ctx, logOpt).Infof("purging cache entries with repository prefix %s", req.RepositoryPrefix)
+		err = errors.Join(
+			s.verifyCache.DeleteByPrefix(ctx, verifyKey(req.RepositoryPrefix)),
+			s.mutateCache.DeleteByPrefix(ctx, mutateKey(req.RepositoryPrefix)),
+			s.validationCache.DeleteByPrefix(ctx, validationKeyPrefix(req.RepositoryPrefix)),
+		)
+	default:
+		logger.GetLogger(ctx, logOpt).Info("purging all cache entries")
+		err = errors.Join(
+			s.verifyCache.DeleteByPrefix(ctx, verifyKey("")),
+			s.mutateCache.DeleteByPrefix(ctx, mutateKey("")),
+			s.validationCache.DeleteByPrefix(ctx, validationKeyPrefix("")),
+		)
+	}
+	if err != nil {
+		http.Error(w, "failed to purge cache", http.StatusInternalServerError)
+		return fmt.Errorf("failed to purge cache: %w", err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// authorizeAdmin checks the bearer token of the request against the token in
+// AdminTokenFile. The file is read on every request so that the token can be
+// rotated without restarting the server.
+func (s *server) authorizeAdmin(r *http.Request) error {
+	expected, err := os.ReadFile(s.AdminTokenFile)
+	if err != nil {
+		return fmt.Errorf("failed to read admin token file: %w", err)
+	}
+	expected = bytes.TrimSpace(expected)
+	if len(expected) == 0 {
+		return errors.New("admin token is empty")
+	}
+
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, bearerPrefix) {
+		return errors.New("missing bearer token")
+	}
+	token := []byte(strings.TrimSpace(strings.TrimPrefix(header, bearerPrefix)))
+	if subtle.ConstantTimeCompare(token, expected) != 1 {
+		return errors.New("invalid bearer token")
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const testAdminToken = "test-admin-token"
+
+func TestPurgeCache(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte(testAdminToken+"\n"), 0600); err != nil {
+		t.Fatalf("failed to write token file: %v", err)
+	}
+
+	tests := []struct {
+		name                   string
+		token                  string
+		requestBody            string
+		expectedStatusCode     int
+		expectedVerifyKeys     []string
+		expectedMutateKeys     []string
+		expectedValidationKeys []string
+	}{
+		{
+			name:                   "missing token",
+			requestBody:            `{"all": true}`,
+			expectedStatusCode:     http.StatusUnauthorized,
+			expectedVerifyKeys:     []string{"verify_registry.io/team-a/app:v1", "verify_registry.io/team-a/app:v2", "verify_registry.io/team-b/app:v1"},
+			expectedMutateKeys:     []string{"mutate_registry.io/team-a/app:v1", "mutate_registry.io/team-b/app:v1"},
+			expectedValidationKeys: []string{"validations_registry.io/team-a/app:v1?", "validations_registry.io/team-a/app:v1?platform=linux%2Famd64", "validations_registry.io/team-b/app:v1?"},
+		},
+		{
+			name:                   "invalid token",
+			token:                  "invalid",
+			requestBody:            `{"all": true}`,
+			expectedStatusCode:     http.StatusUnauthorized,
+			expectedVerifyKeys:     []string{"verify_registry.io/team-a/app:v1", "verify_registry.io/team-a/app:v2", "verify_registry.io/team-b/app:v1"},
+			expectedMutateKeys:     []string{"mutate_registry.io/team-a/app:v1", "mutate_registry.io/team-b/app:v1"},
+			expectedValidationKeys: []string{"validations_registry.io/team-a/app:v1?", "validations_registry.io/team-a/app:v1?platform=linux%2Famd64", "validations_registry.io/team-b/app:v1?"},
+		},
+		{
+			name:                   "invalid request body",
+			token:                  testAdminToken,
+			requestBody:            `{invalid-json}`,
+			expectedStatusCode:     http.StatusBadRequest,
+			expectedVerifyKeys:     []string{"verify_registry.io/team-a/app:v1", "verify_registry.io/team-a/app:v2", "verify_registry.io/team-b/app:v1"},
+			expectedMutateKeys:     []string{"mutate_registry.io/team-a/app:v1", "mutate_registry.io/team-b/app:v1"},
+			expectedValidationKeys: []string{"validations_registry.io/team-a/app:v1?", "validations_registry.io/team-a/app:v1?platform=linux%2Famd64", "validations_registry.io/team-b/app:v1?"},
+		},
+		{
+			name:                   "multiple targets",
+			token:                  testAdminToken,
+			requestBody:            `{"reference": "registry.io/team-a/app:v1", "all": true}`,
+			expectedStatusCode:     http.StatusBadRequest,
+			expectedVerifyKeys:     []string{"verify_registry.io/team-a/app:v1", "verify_registry.io/team-a/app:v2", "verify_registry.io/team-b/app:v1"},
+			expectedMutateKeys:     []string{"mutate_registry.io/team-a/app:v1", "mutate_registry.io/team-b/app:v1"},
+			expectedValidationKeys: []string{"validations_registry.io/team-a/app:v1?", "validations_registry.io/team-a/app:v1?platform=linux%2Famd64", "validations_registry.io/team-b/app:v1?"},
+		},
+		{
+			name:                   "purge by reference",
+			token:                  testAdminToken,
+			requestBody:            `{"reference": "registry.io/team-a/app:v1"}`,
+			expectedStatusCode:     http.StatusNoContent,
+			expectedVerifyKeys:     []string{"verify_registry.io/team-a/app:v2", "verify_registry.io/team-b/app:v1"},
+			expectedMutateKeys:     []string{"mutate_registry.io/team-b/app:v1"},
+			expectedValidationKeys: []string{"validations_registry.io/team-b/app:v1?"},
+		},
+		{
+			name:                   "purge by repository prefix",
+			token:                  testAdminToken,
+			requestBody:            `{"repositoryPrefix": "registry.io/team-a/"}`,
+			expectedStatusCode:     http.StatusNoContent,
+			expectedVerifyKeys:     []string{"verify_registry.io/team-b/app:v1"},
+			expectedMutateKeys:     []string{"mutate_registry.io/team-b/app:v1"},
+			expectedValidationKeys: []string{"validations_registry.io/team-b/app:v1?"},
+		},
+		{
+			name:                   "purge all",
+			token:                  testAdminToken,
+			requestBody:            `{"all": true}`,
+			expectedStatusCode:     http.StatusNoContent,
+			expectedVerifyKeys:     []string{},
+			expectedMutateKeys:     []string{},
+			expectedValidationKeys: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verifyCache := &mockResultCache{entries: map[string]*result{
+				"verify_registry.io/team-a/app:v1": {Succeeded: true},
+				"verify_registry.io/team-a/app:v2": {Succeeded: true},
+				"verify_registry.io/team-b/app:v1": {Succeeded: true},
+			}}
+			mutateCache := &mockCache{entries: map[string]string{
+				"mutate_registry.io/team-a/app:v1": "registry.io/team-a/app@sha256:a",
+				"mutate_registry.io/team-b/app:v1": "registry.io/team-b/app@sha256:b",
+			}}
+			validationCache := &mockTypedCache[*validationResponse]{entries: map[string]*validationResponse{
+				"validations_registry.io/team-a/app:v1?":                       {Succeeded: true},
+				"validations_registry.io/team-a/app:v1?platform=linux%2Famd64": {Succeeded: true},
+				"validations_registry.io/team-b/app:v1?":                       {Succeeded: true},
+			}}
+			server := &server{
+				verifyCache:     verifyCache,
+				mutateCache:     mutateCache,
+				validationCache: validationCache,
+				ServerOptions: ServerOptions{
+					AdminTokenFile: tokenFile,
+				},
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/ratify/admin/v2/cache/purge", strings.NewReader(test.requestBody))
+			if test.token != "" {
+				req.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			w := httptest.NewRecorder()
+			_ = server.purgeCache(context.Background(), w, req)
+
+			if w.Code != test.expectedStatusCode {
+				t.Errorf("expected status code %d, got %d", test.expectedStatusCode, w.Code)
+			}
+			if keys := sortedKeys(verifyCache.entries); strings.Join(keys, ",") != strings.Join(test.expectedVerifyKeys, ",") {
+				t.Errorf("expected verify cache keys %v, got %v", test.expectedVerifyKeys, keys)
+			}
+			if keys := sortedKeys(mutateCache.entries); strings.Join(keys, ",") != strings.Join(test.expectedMutateKeys, ",") {
+				t.Errorf("expected mutate cache keys %v, got %v", test.expectedMutateKeys, keys)
+			}
+			if keys := sortedKeys(validationCache.entries); strings.Join(keys, ",") != strings.Join(test.expectedValidationKeys, ",") {
+				t.Errorf("expected validation cache keys %v, got %v", test.expectedValidationKeys, keys)
+			}
+		})
+	}
+}
+
+func sortedKeys[T any](entries map[string]T) []string {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
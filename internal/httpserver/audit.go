@@ -0,0 +1,195 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/notaryproject/ratify/v2/internal/audit"
+	"github.com/notaryproject/ratify/v2/internal/executor"
+	"github.com/notaryproject/ratify/v2/internal/logger"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/externaldata"
+	"oras.land/oras-go/v2/registry"
+)
+
+// auditVerifications logs the verification decisions returned to Gatekeeper
+// to the audit log. cacheHits holds the keys served from the cache. Records
+// are written asynchronously so that the audit log does not add latency to
+// the admission path.
+func (s *server) auditVerifications(ctx context.Context, items []externaldata.Item, cacheHits *sync.Map) {
+	if s.auditor == nil {
+		return
+	}
+	now := time.Now().UTC()
+	traceID := logger.GetTraceID(ctx)
+	for _, item := range items {
+		record := &audit.Record{
+			Time:    now,
+			Key:     item.Key,
+			Subject: item.Key,
+			TraceID: traceID,
+		}
+		if req, err := parseKey(item.Key); err == nil {
+			record.Subject = req.Reference
+			if platform := req.platform(s.defaultPlatform); platform != nil {
+				record.Platform = executor.FormatPlatform(platform)
+			}
+		}
+		_, record.CacheHit = cacheHits.Load(item.Key)
+
+		switch value := item.Value.(type) {
+		case *validationResponse:
+			s.auditValidationResponse(record, value)
+		case *result:
+			s.auditResult(record, value)
+		}
+		if item.Error != "" {
+			record.Succeeded = false
+			if record.Error == "" {
+				record.Error = item.Error
+			}
+		}
+		s.auditor.Log(record)
+	}
+}
+
+// auditValidationResponse fills the record from a v2 item value.
+func (s *server) auditValidationResponse(record *audit.Record, resp *validationResponse) {
+	record.ResolvedDigest = resp.ResolvedDigest
+	record.MatchedScope = resp.MatchedScope
+	record.Succeeded = resp.Succeeded && resp.Error == nil
+	if resp.Unmatched != nil {
+		record.Unmatched = resp.Unmatched.Mode
+	}
+	if resp.AuditOnly != nil {
+		record.AuditOnlyScopes = resp.AuditOnly.Scopes
+		record.WouldHaveFailed = resp.AuditOnly.WouldHaveFailed
+		if resp.AuditOnly.Error != nil {
+			record.AuditOnlyError = resp.AuditOnly.Error.String()
+		}
+	}
+	if resp.Error != nil {
+		record.ErrorCode = string(resp.Error.Code)
+		record.Error = resp.Error.Message
+	}
+	var collect func(reports []*detailedValidationReport)
+	collect = func(reports []*detailedValidationReport) {
+		for _, report := range reports {
+			if report == nil {
+				continue
+			}
+			for _, result := range report.Results {
+				if result == nil {
+					continue
+				}
+				outcome := audit.VerifierOutcome{
+					Subject:      report.Subject,
+					Artifact:     report.Artifact.Digest.String(),
+					ArtifactType: report.Artifact.ArtifactType,
+					Verifier:     result.VerifierName,
+					VerifierType: result.VerifierType,
+					Succeeded:    result.Error == nil,
+				}
+				if result.Error != nil {
+					outcome.Error = result.Error.Message
+				}
+				record.Verifiers = append(record.Verifiers, outcome)
+			}
+			collect(report.ArtifactReports)
+		}
+	}
+	collect(resp.ArtifactReports)
+}
+
+// auditResult fills the record from a v1 item value. The v1 value carries
+// neither the resolved digest nor the matched scope, so the digest is taken
+// from the subject or the reports, and the scope is matched against the
+// current executor.
+func (s *server) auditResult(record *audit.Record, res *result) {
+	record.Succeeded = res.Succeeded && res.Error == nil
+	if res.Unmatched != nil {
+		record.Unmatched = res.Unmatched.Mode
+	}
+	if res.AuditOnly != nil {
+		record.AuditOnlyScopes = res.AuditOnly.Scopes
+		record.WouldHaveFailed = res.AuditOnly.WouldHaveFailed
+		if res.AuditOnly.Error != nil {
+			record.AuditOnlyError = res.AuditOnly.Error.String()
+		}
+	}
+	if res.Error != nil {
+		record.ErrorCode = string(res.Error.Code)
+		record.Error = res.Error.Message
+	}
+	record.ResolvedDigest = resolvedDigest(record.Subject, res.ArtifactReports)
+	if scopedExecutor := s.getExecutor(); scopedExecutor != nil {
+		if scope, err := scopedExecutor.MatchScope(record.Subject); err == nil {
+			record.MatchedScope = scope
+		}
+	}
+	var collect func(reports []*validationReport)
+	collect = func(reports []*validationReport) {
+		for _, report := range reports {
+			if report == nil {
+				continue
+			}
+			for _, result := range report.Results {
+				if result == nil {
+					continue
+				}
+				outcome := audit.VerifierOutcome{
+					Subject:   report.Subject,
+					Artifact:  report.Artifact,
+					Verifier:  result.VerifierName,
+					Succeeded: result.Error == nil && result.ErrorReason == "",
+				}
+				if result.Error != nil {
+					outcome.Error = result.Error.Message
+				} else {
+					outcome.Error = result.ErrorReason
+				}
+				record.Verifiers = append(record.Verifiers, outcome)
+			}
+			collect(report.ArtifactReports)
+		}
+	}
+	collect(res.ArtifactReports)
+}
+
+// resolvedDigest returns the digest of the subject if it is referenced by
+// digest, or else the digest of the subject of the top-level reports, which
+// are produced for the resolved subject. It returns an empty string if the
+// digest is unknown, e.g. the subject is a tag without referrers.
+func resolvedDigest(subject string, reports []*validationReport) string {
+	if ref, err := registry.ParseReference(subject); err == nil {
+		if digest, err := ref.Digest(); err == nil {
+			return digest.String()
+		}
+	}
+	for _, report := range reports {
+		if report == nil {
+			continue
+		}
+		if ref, err := registry.ParseReference(report.Subject); err == nil {
+			if digest, err := ref.Digest(); err == nil {
+				return digest.String()
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,142 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/notaryproject/ratify/v2/internal/audit"
+	"github.com/notaryproject/ratify/v2/internal/errcode"
+	"github.com/notaryproject/ratify/v2/internal/executor"
+)
+
+type recordingSink struct {
+	mu      sync.Mutex
+	records []*audit.Record
+}
+
+func (s *recordingSink) Write(_ context.Context, records []*audit.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, records...)
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	return nil
+}
+
+func TestVerify_Audit(t *testing.T) {
+	scopedExecutor := newSignedExecutor(t)
+
+	for _, schema := range []string{responseSchemaV1, responseSchemaV2} {
+		t.Run(schema, func(t *testing.T) {
+			sink := &recordingSink{}
+			server := &server{
+				getExecutor: func() *executor.ScopedExecutor {
+					return scopedExecutor
+				},
+				verifyCache:     &mockTypedCache[*result]{entries: make(map[string]*result)},
+				validationCache: &mockTypedCache[*validationResponse]{entries: make(map[string]*validationResponse)},
+				sfGroup:         new(flightGroup),
+				auditor:         audit.NewWithSinks(10, sink),
+				ServerOptions: ServerOptions{
+					ResponseSchema: schema,
+				},
+			}
+
+			// The second request is served from the cache.
+			for range 2 {
+				body := `{"request": {"keys": ["registry.example.com/test/image:v1", "unknown.example.org/test/image:v1"]}}`
+				req := httptest.NewRequest(http.MethodPost, "/verify", strings.NewReader(body))
+				w := httptest.NewRecorder()
+				if err := server.verify(context.Background(), w, req); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if err := server.auditor.Close(context.Background()); err != nil {
+				t.Fatalf("failed to close audit logger: %v", err)
+			}
+
+			if len(sink.records) != 4 {
+				t.Fatalf("expected 4 audit records, got %d", len(sink.records))
+			}
+			for idx, record := range sink.records {
+				expectedCacheHit := idx >= 2
+				if record.Time.IsZero() {
+					t.Errorf("expected time to be set in record %d", idx)
+				}
+				if idx%2 == 1 {
+					if record.Succeeded || record.ErrorCode != string(errcode.ScopeNotMatched) || record.Subject != "unknown.example.org/test/image:v1" {
+						t.Errorf("expected a denied scope not matched record, got %+v", record)
+					}
+					continue
+				}
+				if !record.Succeeded || record.CacheHit != expectedCacheHit {
+					t.Errorf("expected a succeeded record with cache hit %t, got %+v", expectedCacheHit, record)
+				}
+				if record.ResolvedDigest != signedSubjectDesc.Digest.String() || record.MatchedScope != "registry.example.com" {
+					t.Errorf("expected resolved digest and matched scope, got %+v", record)
+				}
+				if len(record.Verifiers) != 1 || !record.Verifiers[0].Succeeded || record.Verifiers[0].Verifier == "" {
+					t.Errorf("expected 1 succeeded verifier outcome, got %+v", record.Verifiers)
+				}
+			}
+		})
+	}
+}
+
+func TestResolvedDigest(t *testing.T) {
+	const digest = "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	tests := []struct {
+		name     string
+		subject  string
+		reports  []*validationReport
+		expected string
+	}{
+		{
+			name:     "subject by digest",
+			subject:  "registry.example.com/test/image@" + digest,
+			expected: digest,
+		},
+		{
+			name:    "subject by tag with reports",
+			subject: "registry.example.com/test/image:v1",
+			reports: []*validationReport{
+				nil,
+				{Subject: "registry.example.com/test/image@" + digest},
+			},
+			expected: digest,
+		},
+		{
+			name:    "subject by tag without reports",
+			subject: "registry.example.com/test/image:v1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolvedDigest(tt.subject, tt.reports); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
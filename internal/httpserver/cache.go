@@ -0,0 +1,152 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/cache"
+	"github.com/notaryproject/ratify/v2/internal/cache/redis"
+	"github.com/notaryproject/ratify/v2/internal/cache/ristretto"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// Backends of the caches.
+const (
+	// cacheBackendMemory keeps the cache in the memory of each replica.
+	cacheBackendMemory = "memory"
+	// cacheBackendRedis shares the cache between replicas through a Redis
+	// server.
+	cacheBackendRedis = "redis"
+)
+
+const (
+	defaultRedisNamespace = "ratify"
+	redisConnectTimeout   = 5 * time.Second
+)
+
+// CacheOptions holds the configuration of the cache backends.
+type CacheOptions struct {
+	// VerifyBackend is the backend of the verify and validations caches,
+	// either "memory" or "redis".
+	// Optional. Defaults to "memory".
+	VerifyBackend string
+
+	// MutateBackend is the backend of the mutate cache, either "memory" or
+	// "redis".
+	// Optional. Defaults to "memory".
+	MutateBackend string
+
+	// CredentialBackend is the backend of the registry credential cache,
+	// either "memory" or "redis".
+	// Optional. Defaults to "memory".
+	CredentialBackend string
+
+	// Redis holds the options to connect to the Redis server.
+	// Required if any backend is "redis".
+	Redis redis.Options
+
+	// RedisNamespace prefixes the keys stored in Redis. Replicas sharing the
+	// caches must use the same namespace.
+	// Optional. Defaults to "ratify".
+	RedisNamespace string
+}
+
+// usesRedis returns true if any cache is backed by Redis.
+func (o *CacheOptions) usesRedis() bool {
+	return o.VerifyBackend == cacheBackendRedis || o.MutateBackend == cacheBackendRedis || o.CredentialBackend == cacheBackendRedis
+}
+
+// validate checks the backends of the caches.
+func (o *CacheOptions) validate() error {
+	for name, backend := range map[string]string{
+		"verify":     o.VerifyBackend,
+		"mutate":     o.MutateBackend,
+		"credential": o.CredentialBackend,
+	} {
+		switch backend {
+		case "", cacheBackendMemory, cacheBackendRedis:
+		default:
+			return fmt.Errorf("unsupported %s cache backend %q, must be %s or %s", name, backend, cacheBackendMemory, cacheBackendRedis)
+		}
+	}
+	return nil
+}
+
+// setupCaches creates the verify, validations and mutate caches of the server
+// and the shared registry credential cache from the configured backends.
+func (s *server) setupCaches() error {
+	if err := s.Cache.validate(); err != nil {
+		return err
+	}
+	if s.Cache.RedisNamespace == "" {
+		s.Cache.RedisNamespace = defaultRedisNamespace
+	}
+	if s.Cache.usesRedis() && s.redisClient == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), redisConnectTimeout)
+		defer cancel()
+		client, err := redis.NewClient(ctx, s.Cache.Redis)
+		if err != nil {
+			return err
+		}
+		s.redisClient = client
+	}
+
+	var err error
+	if s.mutateCache, err = newCache[string](s.Cache.MutateBackend, s.redisClient, s.Cache.RedisNamespace+":mutate"); err != nil {
+		return fmt.Errorf("failed to create mutate cache: %w", err)
+	}
+	if s.verifyCache, err = newCache[*result](s.Cache.VerifyBackend, s.redisClient, s.Cache.RedisNamespace+":verify"); err != nil {
+		return fmt.Errorf("failed to create verify cache: %w", err)
+	}
+	if s.validationCache, err = newCache[*validationResponse](s.Cache.VerifyBackend, s.redisClient, s.Cache.RedisNamespace+":validation"); err != nil {
+		return fmt.Errorf("failed to create validation cache: %w", err)
+	}
+
+	// The credential providers are created with the executors, so the shared
+	// cache is configured globally rather than per provider.
+	var credentialCache cache.Cache[ratify.RegistryCredential]
+	if s.Cache.CredentialBackend == cacheBackendRedis {
+		if credentialCache, err = redis.NewCache[ratify.RegistryCredential](s.redisClient, s.Cache.RedisNamespace+":credential", 0); err != nil {
+			return fmt.Errorf("failed to create credential cache: %w", err)
+		}
+	}
+	credentialprovider.SetSharedCache(credentialCache)
+	return nil
+}
+
+// newCache creates a cache with the default TTL on the specified backend.
+func newCache[T any](backend string, client goredis.UniversalClient, namespace string) (cache.Cache[T], error) {
+	if backend == cacheBackendRedis {
+		return redis.NewCache[T](client, namespace, defaultCacheTTL)
+	}
+	return ristretto.NewCache[T](defaultCacheTTL)
+}
+
+// closeCaches releases the connections of the cache backends.
+func (s *server) closeCaches() {
+	if s.redisClient == nil {
+		return
+	}
+	if err := s.redisClient.Close(); err != nil {
+		logrus.Errorf("failed to close redis client: %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/cache"
+	"github.com/notaryproject/ratify/v2/internal/cache/redis"
+	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+)
+
+func TestSetupCaches(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+
+	tests := []struct {
+		name        string
+		opts        CacheOptions
+		expectError bool
+		expectRedis bool
+		expectKeys  []string
+	}{
+		{
+			name: "memory caches by default",
+		},
+		{
+			name:        "unsupported backend",
+			opts:        CacheOptions{MutateBackend: "memcached"},
+			expectError: true,
+		},
+		{
+			name:        "redis address missing",
+			opts:        CacheOptions{VerifyBackend: cacheBackendRedis},
+			expectError: true,
+		},
+		{
+			name: "redis unreachable",
+			opts: CacheOptions{
+				VerifyBackend: cacheBackendRedis,
+				Redis:         redis.Options{Address: "127.0.0.1:1"},
+			},
+			expectError: true,
+		},
+		{
+			name: "redis caches with default namespace",
+			opts: CacheOptions{
+				VerifyBackend:     cacheBackendRedis,
+				MutateBackend:     cacheBackendRedis,
+				CredentialBackend: cacheBackendRedis,
+				Redis:             redis.Options{Address: redisServer.Addr()},
+			},
+			expectRedis: true,
+			expectKeys:  []string{"ratify:mutate:key", "ratify:validation:key", "ratify:verify:key"},
+		},
+		{
+			name: "redis verify cache with custom namespace",
+			opts: CacheOptions{
+				VerifyBackend:  cacheBackendRedis,
+				Redis:          redis.Options{Address: redisServer.Addr()},
+				RedisNamespace: "cluster-a",
+			},
+			expectRedis: true,
+			expectKeys:  []string{"cluster-a:validation:key", "cluster-a:verify:key"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redisServer.FlushAll()
+			defer credentialprovider.SetSharedCache(nil)
+
+			s := &server{ServerOptions: ServerOptions{Cache: tt.opts}}
+			err := s.setupCaches()
+			if (err != nil) != tt.expectError {
+				t.Fatalf("setupCaches() error = %v, expectError %v", err, tt.expectError)
+			}
+			if err != nil {
+				return
+			}
+			defer s.closeCaches()
+			if (s.redisClient != nil) != tt.expectRedis {
+				t.Fatalf("expected redis client %v, got %v", tt.expectRedis, s.redisClient)
+			}
+
+			ctx := context.Background()
+			if err := s.verifyCache.Set(ctx, "key", &result{Succeeded: true}, 0); err != nil {
+				t.Fatalf("failed to set verify cache: %v", err)
+			}
+			if err := s.mutateCache.Set(ctx, "key", "digest", 0); err != nil {
+				t.Fatalf("failed to set mutate cache: %v", err)
+			}
+			if err := s.validationCache.Set(ctx, "key", &validationResponse{Succeeded: true}, 0); err != nil {
+				t.Fatalf("failed to set validation cache: %v", err)
+			}
+			if _, ok := s.verifyCache.(cache.TTLReader[*result]); !ok {
+				t.Error("expected verify cache to support refresh-ahead")
+			}
+			// Keys are sorted by miniredis.
+			if keys := redisServer.Keys(); !reflect.DeepEqual(keys, tt.expectKeys) && (len(keys) > 0 || len(tt.expectKeys) > 0) {
+				t.Errorf("keys stored in redis = %v, want %v", keys, tt.expectKeys)
+			}
+		})
+	}
+}
+
+func TestSetupCaches_SharedCredentialCache(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+	defer credentialprovider.SetSharedCache(nil)
+
+	s := &server{ServerOptions: ServerOptions{Cache: CacheOptions{
+		CredentialBackend: cacheBackendRedis,
+		Redis:             redis.Options{Address: redisServer.Addr()},
+	}}}
+	if err := s.setupCaches(); err != nil {
+		t.Fatalf("setupCaches() error = %v", err)
+	}
+	defer s.closeCaches()
+
+	provider, err := credentialprovider.NewCachedProvider("mock", "identity", credentialSourceFunc(func(context.Context, string) (credentialprovider.CredentialWithTTL, error) {
+		return credentialprovider.CredentialWithTTL{
+			Credential: ratify.RegistryCredential{Username: "user", Password: "pass"},
+			TTL:        defaultCacheTTL,
+		}, nil
+	}))
+	if err != nil {
+		t.Fatalf("failed to create cached provider: %v", err)
+	}
+	if _, err := provider.Get(context.Background(), "registry.example.com"); err != nil {
+		t.Fatalf("failed to get credential: %v", err)
+	}
+	if !redisServer.Exists("ratify:credential:mock:identity:registry.example.com") {
+		t.Errorf("expected credential to be stored in redis, got keys %v", redisServer.Keys())
+	}
+}
+
+type credentialSourceFunc func(ctx context.Context, serverAddress string) (credentialprovider.CredentialWithTTL, error)
+
+func (f credentialSourceFunc) GetWithTTL(ctx context.Context, serverAddress string) (credentialprovider.CredentialWithTTL, error) {
+	return f(ctx, serverAddress)
+}
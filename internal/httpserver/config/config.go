@@ -46,6 +46,11 @@ type Watcher struct {
 	watcher            *fsnotify.Watcher
 	executor           atomic.Pointer[executor.ScopedExecutor]
 	executorConfigPath string
+	// generation counts the loads of the configuration.
+	generation atomic.Uint64
+
+	errMutex sync.RWMutex
+	err      error
 }
 
 // NewWatcher creates a new Watcher instance.
@@ -78,6 +83,7 @@ func (w *Watcher) loadExecutor() error {
 	if err = json.Unmarshal(body, opts); err != nil {
 		return fmt.Errorf("failed to unmarshal configuration: %w", err)
 	}
+	opts.Generation = w.generation.Add(1)
 	e, err := executor.NewScopedExecutor(opts)
 	if err != nil {
 		return fmt.Errorf("failed to create executor: %w", err)
@@ -86,12 +92,36 @@ func (w *Watcher) loadExecutor() error {
 	return nil
 }
 
+// Reload reloads the executor from the configuration file. The previously
+// loaded executor is kept if the reload fails, and the error is reported by
+// Err until the next successful reload.
+func (w *Watcher) Reload() error {
+	err := w.loadExecutor()
+	w.setErr(err)
+	return err
+}
+
 // GetExecutor returns the current executor instance.
 // It is safe to call this method concurrently.
 func (w *Watcher) GetExecutor() *executor.ScopedExecutor {
 	return w.executor.Load()
 }
 
+// Err returns the error of the last attempt to reload the executor
+// configuration. It returns nil if the last reload succeeded. The previously
+// loaded executor keeps serving requests while Err returns a non-nil error.
+func (w *Watcher) Err() error {
+	w.errMutex.RLock()
+	defer w.errMutex.RUnlock()
+	return w.err
+}
+
+func (w *Watcher) setErr(err error) {
+	w.errMutex.Lock()
+	defer w.errMutex.Unlock()
+	w.err = err
+}
+
 // Start begins watching the executor configuration file for changes.
 func (w *Watcher) Start() error {
 	logrus.Infof("Starting executor configuration watcher at %s", w.executorConfigPath)
@@ -113,7 +143,7 @@ func (w *Watcher) Start() error {
 							logrus.Errorf("error re-watching file: %v", err)
 						}
 					}
-					if err := w.loadExecutor(); err != nil {
+					if err := w.Reload(); err != nil {
 						logrus.Errorf("failed to reload config: %v", err)
 					}
 				}
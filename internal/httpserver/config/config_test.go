@@ -194,3 +194,56 @@ func TestGetExecutor(t *testing.T) {
 		assert.NotNil(t, executor)
 	})
 }
+
+func TestWatcherErr(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(configPath, []byte(validConfig), 0600)
+	assert.NoError(t, err)
+
+	watcher, err := NewWatcher(configPath)
+	assert.NoError(t, err)
+	assert.NoError(t, watcher.Err())
+
+	err = watcher.Start()
+	assert.NoError(t, err)
+	defer watcher.Stop()
+
+	// Write an invalid configuration and wait for the reload to fail.
+	err = os.WriteFile(configPath, []byte("{invalid"), 0600)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return watcher.Err() != nil
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.NotNil(t, watcher.GetExecutor())
+
+	// Restore a valid configuration and wait for the reload to succeed.
+	err = os.WriteFile(configPath, []byte(validConfig), 0600)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return watcher.Err() == nil
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestWatcherReload(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(configPath, []byte(validConfig), 0600)
+	assert.NoError(t, err)
+
+	watcher, err := NewWatcher(configPath)
+	assert.NoError(t, err)
+	previous := watcher.GetExecutor()
+
+	// Reload without watching the file so that only the explicit reload
+	// applies the changes.
+	err = os.WriteFile(configPath, []byte("{invalid"), 0600)
+	assert.NoError(t, err)
+	assert.Error(t, watcher.Reload())
+	assert.Error(t, watcher.Err())
+	assert.Same(t, previous, watcher.GetExecutor())
+
+	err = os.WriteFile(configPath, []byte(validConfig), 0600)
+	assert.NoError(t, err)
+	assert.NoError(t, watcher.Reload())
+	assert.NoError(t, watcher.Err())
+	assert.NotSame(t, previous, watcher.GetExecutor())
+}
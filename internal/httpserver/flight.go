@@ -0,0 +1,146 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// flightGroup deduplicates concurrent calls with the same key like a
+// singleflight group, except that a call runs on a context detached from the
+// cancellation of its callers. The call is cancelled once the latest deadline
+// of the callers that joined it has passed, so that a caller that gives up
+// early neither fails the other callers nor loses the result, which the call
+// can still cache. The zero value is ready to use.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// flightCall is an in-flight or completed call of a flightGroup.
+type flightCall struct {
+	done chan struct{}
+	val  any
+	err  error
+
+	// The fields below are guarded by the mutex of the group.
+
+	// dups is the number of callers that joined the call after it started.
+	dups int
+	// deadline is the latest deadline of the callers. It is only meaningful
+	// if timer is set, and timer is nil if any caller has no deadline.
+	deadline time.Time
+	timer    *time.Timer
+	cancel   context.CancelFunc
+}
+
+// Do executes fn once for all concurrent callers with the same key and
+// returns its results, or the error of ctx if ctx is done first. The context
+// passed to fn keeps the values of the context of the first caller. shared
+// reports whether the results were given to multiple callers.
+func (g *flightGroup) Do(ctx context.Context, key string, fn func(context.Context) (any, error)) (v any, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	c, ok := g.calls[key]
+	if ok {
+		c.dups++
+		c.extendDeadline(ctx)
+		g.mu.Unlock()
+	} else {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		c = &flightCall{
+			done:   make(chan struct{}),
+			cancel: cancel,
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			c.deadline = deadline
+			c.timer = time.AfterFunc(time.Until(deadline), func() {
+				g.expire(key, c)
+			})
+		}
+		g.calls[key] = c
+		g.mu.Unlock()
+		go g.run(callCtx, key, c, fn)
+	}
+
+	select {
+	case <-c.done:
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		return c.val, c.err, c.dups > 0
+	case <-ctx.Done():
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		return nil, fmt.Errorf("failed to wait for the shared call of %s: %w", key, ctx.Err()), c.dups > 0
+	}
+}
+
+// run executes the call and releases its waiters.
+func (g *flightGroup) run(ctx context.Context, key string, c *flightCall, fn func(context.Context) (any, error)) {
+	defer c.cancel()
+	c.val, c.err = fn(ctx)
+
+	g.mu.Lock()
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	g.mu.Unlock()
+	close(c.done)
+}
+
+// extendDeadline extends the deadline of the call to the deadline of ctx if it
+// is later. A context without deadline leaves the call unbounded. The group
+// mutex must be held.
+func (c *flightCall) extendDeadline(ctx context.Context) {
+	if c.timer == nil {
+		return
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		c.timer.Stop()
+		c.timer = nil
+		return
+	}
+	if deadline.After(c.deadline) {
+		// The timer re-arms itself until the extended deadline on expiry.
+		c.deadline = deadline
+	}
+}
+
+// expire cancels the call once its deadline has passed. Callers arriving
+// afterwards start a new call.
+func (g *flightGroup) expire(key string, c *flightCall) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if c.timer == nil || g.calls[key] != c {
+		// The call completed or became unbounded.
+		return
+	}
+	if remaining := time.Until(c.deadline); remaining > 0 {
+		c.timer.Reset(remaining)
+		return
+	}
+	delete(g.calls, key)
+	c.cancel()
+}
@@ -0,0 +1,192 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFlightGroup_Do(t *testing.T) {
+	var g flightGroup
+	var calls atomic.Int32
+	v, err, shared := g.Do(context.Background(), "key", func(context.Context) (any, error) {
+		calls.Add(1)
+		return "value", nil
+	})
+	if err != nil || v != "value" || shared {
+		t.Fatalf("Do() = %v, %v, %t, want value, nil, false", v, err, shared)
+	}
+
+	// The call is not reused once completed.
+	if _, _, _ = g.Do(context.Background(), "key", func(context.Context) (any, error) {
+		calls.Add(1)
+		return "value", nil
+	}); calls.Load() != 2 {
+		t.Errorf("expected 2 calls, got %d", calls.Load())
+	}
+}
+
+func TestFlightGroup_FirstCallerCancelled(t *testing.T) {
+	var g flightGroup
+	started := make(chan struct{})
+	release := make(chan struct{})
+	completed := make(chan error, 1)
+	fn := func(ctx context.Context) (any, error) {
+		close(started)
+		<-release
+		// The result can still be cached after the first caller left.
+		completed <- ctx.Err()
+		return "value", nil
+	}
+
+	firstCtx, cancelFirst := context.WithTimeout(context.Background(), time.Minute)
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err, _ := g.Do(firstCtx, "key", fn)
+		firstErr <- err
+	}()
+	<-started
+
+	lateResult := make(chan any, 1)
+	go func() {
+		lateCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		v, err, shared := g.Do(lateCtx, "key", func(context.Context) (any, error) {
+			t.Error("expected the late caller to join the in-flight call")
+			return nil, nil
+		})
+		if err != nil || !shared {
+			t.Errorf("late caller got %v, shared %t", err, shared)
+		}
+		lateResult <- v
+	}()
+
+	// Wait for the late caller to join before cancelling the first one.
+	waitFor(t, func() bool {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		return g.calls["key"] != nil && g.calls["key"].dups == 1
+	})
+	cancelFirst()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the first caller to be cancelled, got %v", err)
+	}
+
+	close(release)
+	if err := <-completed; err != nil {
+		t.Errorf("expected the call to complete on a live context, got %v", err)
+	}
+	if v := <-lateResult; v != "value" {
+		t.Errorf("expected the late caller to get the result, got %v", v)
+	}
+}
+
+func TestFlightGroup_Deadline(t *testing.T) {
+	var g flightGroup
+	callErr := make(chan error, 1)
+	fn := func(ctx context.Context) (any, error) {
+		<-ctx.Done()
+		callErr <- ctx.Err()
+		return nil, ctx.Err()
+	}
+
+	start := time.Now()
+	shortCtx, cancelShort := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelShort()
+	longCtx, cancelLong := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancelLong()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _, _ = g.Do(shortCtx, "key", fn)
+	}()
+	waitFor(t, func() bool {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		return g.calls["key"] != nil
+	})
+	// The call and the caller expire at the same time.
+	if _, err, _ := g.Do(longCtx, "key", fn); !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the call to expire, got %v", err)
+	}
+	<-done
+
+	// The call is bounded by the latest deadline of its callers.
+	if err := <-callErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the call to be cancelled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("expected the call to run until the latest deadline, cancelled after %v", elapsed)
+	}
+	waitFor(t, func() bool {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		return len(g.calls) == 0
+	})
+}
+
+func TestFlightGroup_NoDeadline(t *testing.T) {
+	var g flightGroup
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	release := make(chan struct{})
+	callErr := make(chan error, 1)
+	fn := func(ctx context.Context) (any, error) {
+		<-release
+		callErr <- ctx.Err()
+		return nil, nil
+	}
+
+	go func() {
+		_, _, _ = g.Do(ctx, "key", fn)
+	}()
+	waitFor(t, func() bool {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		return g.calls["key"] != nil
+	})
+	// A caller without deadline leaves the call unbounded.
+	go func() {
+		_, _, _ = g.Do(context.Background(), "key", fn)
+	}()
+	waitFor(t, func() bool {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		return g.calls["key"].dups == 1
+	})
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	if err := <-callErr; err != nil {
+		t.Errorf("expected the call not to be cancelled, got %v", err)
+	}
+}
+
+// waitFor polls condition until it holds or the test times out.
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -22,136 +22,473 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/errcode"
+	"github.com/notaryproject/ratify/v2/internal/executor"
+	"github.com/notaryproject/ratify/v2/internal/logger"
+	"github.com/notaryproject/ratify/v2/internal/metrics"
+	"github.com/notaryproject/ratify/v2/internal/tracing"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/externaldata"
-	"github.com/sirupsen/logrus"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"go.opentelemetry.io/otel/attribute"
 	"oras.land/oras-go/v2/registry"
 )
 
+var logOpt = logger.Option{
+	ComponentType: logger.Server,
+}
+
 // verify handles the verification request from Gatekeeper.
 func (s *server) verify(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	start := time.Now()
+	defer func() {
+		metrics.ReportVerificationRequest(ctx, time.Since(start))
+	}()
 	defer r.Body.Close()
-	body, err := io.ReadAll(r.Body)
+	providerRequest, err := s.readProviderRequest(w, r, false)
 	if err != nil {
-		return fmt.Errorf("failed to read request body: %w", err)
+		return err
 	}
 
-	var providerRequest externaldata.ProviderRequest
-	if err = json.Unmarshal(body, &providerRequest); err != nil {
-		return fmt.Errorf("failed to unmarshal request body to provider request: %w", err)
+	var cacheHits sync.Map
+	results := s.processKeys(ctx, providerRequest.Request.Keys, func(ctx context.Context, key string) externaldata.Item {
+		item, cacheHit := s.validateArtifact(ctx, key)
+		if cacheHit {
+			cacheHits.Store(key, true)
+		}
+		return item
+	}, failedVerifyItem)
+	s.auditVerifications(ctx, results, &cacheHits)
+
+	return sendResponse(results, w, http.StatusOK, false)
+}
+
+// mutate handles the mutation request from Gatekeeper.
+func (s *server) mutate(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	start := time.Now()
+	defer func() {
+		metrics.ReportMutationRequest(ctx, time.Since(start))
+	}()
+	defer r.Body.Close()
+	providerRequest, err := s.readProviderRequest(w, r, true)
+	if err != nil {
+		return err
 	}
+	results := s.processKeys(ctx, providerRequest.Request.Keys, s.resolveReference, failedMutateItem)
+
+	return sendResponse(results, w, http.StatusOK, true)
+}
+
+// validateArtifact validates the artifact of the verify key and renders the
+// result as an [externaldata.Item]. The platform hinted by the key, or
+// DefaultPlatform if the key has no hint, selects the manifest validated by
+// executors in the platform index mode. It also reports whether the result
+// was served from the cache.
+func (s *server) validateArtifact(ctx context.Context, verifyReq string) (item externaldata.Item, cacheHit bool) {
+	ctx, span := tracing.StartSpan(ctx, "validateArtifact", attribute.String("ratify.artifact", verifyReq))
+	defer func() {
+		span.SetAttributes(attribute.Bool("ratify.failed", item.Error != ""))
+		span.End()
+	}()
 
-	results := make([]externaldata.Item, len(providerRequest.Request.Keys))
-	for idx, artifact := range providerRequest.Request.Keys {
-		results[idx] = externaldata.Item{
-			Key: artifact,
+	req, err := parseKey(verifyReq)
+	if err != nil {
+		return failedVerifyItem(verifyReq, errcode.InvalidReference.Wrap(err)), false
+	}
+	artifact := req.Reference
+	platform := req.platform(s.defaultPlatform)
+	item = externaldata.Item{
+		Key: verifyReq,
+	}
+	if s.ResponseSchema == responseSchemaV2 {
+		item = s.validateArtifactV2(ctx, item, artifact, platform)
+		return item, item.Value.(*validationResponse).CacheHit
+	}
+	if decision := s.decideUnmatched(ctx, artifact); decision != nil {
+		span.SetAttributes(attribute.String("ratify.unmatched", decision.Mode))
+		item.Value = &result{
+			Succeeded:       decision.Mode == unmatchedScopeAllow,
+			ArtifactReports: []*validationReport{},
+			Unmatched:       decision,
 		}
-		key := verifyKey(artifact)
+		return item, false
+	}
+	key := s.verifyCacheKey(artifact, platform)
 
-		// Fetch the cache value first.
-		result, err := s.verifyCache.Get(ctx, key)
-		if err == nil && result != nil {
-			results[idx].Value = result
-			continue
+	// Fetch the cache value first.
+	result, remaining, err := s.getVerifyCache(ctx, key)
+	hit := err == nil && result != nil
+	metrics.ReportCacheCount(ctx, metrics.CacheVerify, hit)
+	span.SetAttributes(attribute.Bool("ratify.cache_hit", hit))
+	if hit {
+		if s.needsRefresh(remaining) {
+			span.SetAttributes(attribute.Bool("ratify.cache_refresh", true))
+			s.refreshVerifyCache(ctx, key, artifact, platform)
 		}
+		item.Value = result
+		if result.Error != nil {
+			item.Error = result.Error.String()
+		}
+		return item, true
+	}
 
-		// Cache is missed, block multiple goroutines from validating the same
-		// artifact.
-		val, err, _ := s.sfGroup.Do(key, func() (any, error) {
-			executor := s.getExecutor()
-			if executor == nil {
-				return nil, errors.New("no valid executor configured")
-			}
-			result, err := executor.ValidateArtifact(ctx, artifact)
-			if err != nil {
-				return nil, err
+	// Cache is missed, block multiple goroutines from validating the same
+	// artifact. The validation outlives this request if others wait for it.
+	val, err, shared := s.sfGroup.Do(ctx, key, func(ctx context.Context) (any, error) {
+		return s.validateAndCache(ctx, key, artifact, platform, true)
+	})
+	metrics.ReportSingleflightCount(ctx, metrics.CacheVerify, shared)
+	if err != nil {
+		return failedVerifyItem(verifyReq, err), false
+	}
+	item.Value = val
+	return item, false
+}
+
+// validateAndCache validates the artifact and caches the rendered result
+// under key. Errors not caused by the cancellation of ctx are negatively
+// cached if cacheErrors is set and the executor configures a failure TTL.
+func (s *server) validateAndCache(ctx context.Context, key, artifact string, platform *ocispec.Platform, cacheErrors bool) (*result, error) {
+	scopedExecutor := s.getExecutor()
+	if scopedExecutor == nil {
+		return nil, errcode.ExecutorNotConfigured.Wrap(errors.New("no valid executor configured"))
+	}
+	release, err := s.limiter.acquire(ctx, artifact)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	cacheTTL := scopedExecutor.CacheTTL(artifact)
+	result, err := scopedExecutor.ValidateArtifactWithOptions(ctx, executor.ValidateOptions{
+		ValidateArtifactOptions: ratify.ValidateArtifactOptions{
+			Subject: artifact,
+		},
+		Platform: platform,
+	})
+	if err != nil {
+		// Negatively cache the error only if it is not caused by the
+		// cancellation of the request.
+		if cacheErrors && cacheTTL.Failure > 0 && ctx.Err() == nil {
+			if cacheErr := s.verifyCache.Set(ctx, key, convertFailure(err), s.verifyCacheTTL(cacheTTL.Failure)); cacheErr != nil {
+				logger.GetLogger(ctx, logOpt).Warnf("failed to set verify cache for image %s: %v", artifact, cacheErr)
 			}
-			renderedResult := convertResult(result)
-			if err = s.verifyCache.Set(ctx, key, renderedResult, 0); err != nil {
-				logrus.Warnf("failed to set verify cache for image %s: %v", artifact, err)
+		}
+		return nil, err
+	}
+	renderedResult := convertResult(result.ValidationResult)
+	renderedResult.AuditOnly = convertAuditOnly(result)
+	ttl := cacheTTL.Success
+	if !renderedResult.Succeeded && cacheTTL.Failure > 0 {
+		ttl = cacheTTL.Failure
+	}
+	if err = s.verifyCache.Set(ctx, key, renderedResult, s.verifyCacheTTL(ttl)); err != nil {
+		logger.GetLogger(ctx, logOpt).Warnf("failed to set verify cache for image %s: %v", artifact, err)
+	}
+	return renderedResult, nil
+}
+
+// validateArtifactV2 validates the artifact like the validations API and
+// renders the [validationResponse] as the item value of the v2 response
+// schema. The results are shared with the validations API cache.
+func (s *server) validateArtifactV2(ctx context.Context, item externaldata.Item, artifact string, platform *ocispec.Platform) externaldata.Item {
+	start := time.Now()
+	req := &validationRequest{
+		Subject: artifact,
+	}
+	if platform != nil {
+		req.Platform = executor.FormatPlatform(platform)
+	}
+	resp := s.validateSubject(ctx, req, platform)
+	resp.SchemaVersion = responseSchemaV2
+	resp.DurationMs = milliseconds(time.Since(start))
+	item.Value = resp
+	if resp.Error != nil {
+		item.Error = resp.Error.String()
+	}
+	return item
+}
+
+// processKeys applies process to each key with at most MaxConcurrentKeys keys
+// processed concurrently. The results are returned in the order of the keys.
+//
+// Keys are processed with a deadline that reserves ResponseReserve before the
+// deadline of ctx so that the response can be written in time. Keys that are
+// not processed when the reserved deadline is reached are reported by fail
+// with a retryable timeout error while keys that are already processed keep
+// their results.
+func (s *server) processKeys(ctx context.Context, keys []string, process func(context.Context, string) externaldata.Item, fail func(string, error) externaldata.Item) []externaldata.Item {
+	keyCtx, cancel := s.withResponseReserve(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	results := make([]externaldata.Item, len(keys))
+	processed := make([]bool, len(keys))
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, max(s.MaxConcurrentKeys, 1))
+		for idx, key := range keys {
+			select {
+			case sem <- struct{}{}:
+			case <-keyCtx.Done():
+				// Stop scheduling keys that cannot complete in time.
+				wg.Wait()
+				return
 			}
-			return renderedResult, nil
-		})
-		if err != nil {
-			results[idx].Error = err.Error()
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				item := process(keyCtx, key)
+
+				mu.Lock()
+				defer mu.Unlock()
+				if !processed[idx] {
+					results[idx] = item
+					processed[idx] = true
+				}
+			}()
 		}
-		results[idx].Value = val
+		wg.Wait()
+	}()
+
+	select {
+	case <-finished:
+	case <-keyCtx.Done():
 	}
 
-	return sendResponse(results, w, http.StatusOK, false)
+	mu.Lock()
+	defer mu.Unlock()
+	for idx, key := range keys {
+		if !processed[idx] {
+			results[idx] = fail(key, errcode.Timeout.Errorf("processing of key %q did not complete before the deadline", key))
+			processed[idx] = true
+		}
+	}
+	// Return a copy so that the response is not affected by keys completing
+	// after the deadline.
+	return slices.Clone(results)
 }
 
-// mutate handles the mutation request from Gatekeeper.
-func (s *server) mutate(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-	defer r.Body.Close()
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read request body: %w", err)
+// withResponseReserve returns a context whose deadline is ResponseReserve
+// before the deadline of ctx. The context is returned as is if it has no
+// deadline or the remaining time is shorter than the reserve.
+func (s *server) withResponseReserve(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok || s.ResponseReserve <= 0 || time.Until(deadline) <= s.ResponseReserve {
+		return context.WithCancel(ctx)
 	}
+	return context.WithDeadline(ctx, deadline.Add(-s.ResponseReserve))
+}
 
-	var providerRequest externaldata.ProviderRequest
-	if err = json.Unmarshal(body, &providerRequest); err != nil {
-		return fmt.Errorf("failed to unmarshal request body to provider request: %w", err)
+// failedVerifyItem renders a failed validation of the verify key.
+func failedVerifyItem(key string, err error) externaldata.Item {
+	return externaldata.Item{
+		Key:   key,
+		Value: convertFailure(err),
+		Error: itemError(err),
+	}
+}
+
+// failedMutateItem renders a failed resolution of the mutate key. The value is
+// the unmodified reference.
+func failedMutateItem(key string, err error) externaldata.Item {
+	reference := key
+	if req, parseErr := parseKey(key); parseErr == nil {
+		reference = req.Reference
 	}
-	results := make([]externaldata.Item, len(providerRequest.Request.Keys))
-	for idx, key := range providerRequest.Request.Keys {
-		results[idx] = s.resolveReference(ctx, key)
+	return externaldata.Item{
+		Key:   key,
+		Value: reference,
+		Error: itemError(err),
 	}
+}
 
-	return sendResponse(results, w, http.StatusOK, true)
+// keyRequest is the platform-aware encoding of a verify or mutate key. Keys
+// starting with "{" are decoded as a JSON object of this type, e.g.
+//
+//	{"reference": "registry.example.com/app:v1", "os": "linux", "arch": "arm64"}
+//
+// while all other keys are plain references. If the reference is a tag of an
+// image index, the mutation resolves it to the digest of the manifest of the
+// platform, and the verification validates the manifest of the platform if
+// the executor is in the platform index mode.
+type keyRequest struct {
+	// Reference is the artifact reference to resolve. Required.
+	Reference string `json:"reference"`
+
+	// OS is the operating system of the platform, e.g. "linux". Optional.
+	OS string `json:"os,omitempty"`
+
+	// Arch is the CPU architecture of the platform, e.g. "amd64". Optional.
+	Arch string `json:"arch,omitempty"`
+
+	// Variant is the variant of the CPU architecture, e.g. "v8". Optional.
+	Variant string `json:"variant,omitempty"`
+}
+
+// parseKey decodes the key as described by [keyRequest].
+func parseKey(key string) (*keyRequest, error) {
+	if !strings.HasPrefix(strings.TrimSpace(key), "{") {
+		return &keyRequest{Reference: key}, nil
+	}
+	var req keyRequest
+	if err := json.Unmarshal([]byte(key), &req); err != nil {
+		return nil, fmt.Errorf("failed to decode key: %w", err)
+	}
+	if req.Reference == "" {
+		return nil, errors.New("reference must be set in the key")
+	}
+	if (req.OS == "") != (req.Arch == "") || (req.Variant != "" && req.Arch == "") {
+		return nil, errors.New("os and arch must be set together in the key")
+	}
+	return &req, nil
 }
 
-func (s *server) resolveReference(ctx context.Context, reference string) externaldata.Item {
-	item := externaldata.Item{
-		Key:   reference,
+// platform returns the platform hint of the request, or fallback if not set.
+func (r *keyRequest) platform(fallback *ocispec.Platform) *ocispec.Platform {
+	if r.OS == "" {
+		return fallback
+	}
+	return &ocispec.Platform{
+		OS:           r.OS,
+		Architecture: r.Arch,
+		Variant:      r.Variant,
+	}
+}
+
+// resolveReference resolves the reference of the mutate key to a digest. The
+// tag of an image index is resolved to the manifest of the platform hinted by
+// the key, or of DefaultPlatform if the key has no hint.
+func (s *server) resolveReference(ctx context.Context, mutateReq string) (item externaldata.Item) {
+	ctx, span := tracing.StartSpan(ctx, "resolveReference", attribute.String("ratify.artifact", mutateReq))
+	defer func() {
+		span.SetAttributes(attribute.Bool("ratify.failed", item.Error != ""))
+		span.End()
+	}()
+
+	req, err := parseKey(mutateReq)
+	if err != nil {
+		return failedMutateItem(mutateReq, errcode.InvalidReference.Wrap(err))
+	}
+	reference := req.Reference
+	item = externaldata.Item{
+		Key:   mutateReq,
 		Value: reference,
 	}
 
 	ref, err := registry.ParseReference(reference)
 	if err != nil {
-		item.Error = fmt.Sprintf("failed to parse reference: %v", err)
-		return item
+		return failedMutateItem(mutateReq, errcode.InvalidReference.Errorf("failed to parse reference: %w", err))
 	}
 	if _, err = ref.Digest(); err == nil {
 		item.Value = ref.String()
 		return item
 	}
+	platform := req.platform(s.defaultPlatform)
+	if platform != nil {
+		span.SetAttributes(attribute.String("ratify.platform", executor.FormatPlatform(platform)))
+	}
 
 	// Fetch the cache value first.
-	key := mutateKey(reference)
+	key := withPlatform(mutateKey(reference), platform)
 	result, err := s.mutateCache.Get(ctx, key)
-	if err == nil && result != "" {
+	hit := err == nil && result != ""
+	metrics.ReportCacheCount(ctx, metrics.CacheMutate, hit)
+	span.SetAttributes(attribute.Bool("ratify.cache_hit", hit))
+	if hit {
 		item.Value = result
 		return item
 	}
 
 	// Cache is missed, block multiple goroutines from resolving the same
-	// reference.
-	val, err, _ := s.sfGroup.Do(key, func() (any, error) {
+	// reference. The resolution outlives this request if others wait for it.
+	val, err, shared := s.sfGroup.Do(ctx, key, func(ctx context.Context) (any, error) {
 		executor := s.getExecutor()
 		if executor == nil {
-			return "", errors.New("no valid executor configured")
+			return "", errcode.ExecutorNotConfigured.Wrap(errors.New("no valid executor configured"))
+		}
+		release, err := s.limiter.acquire(ctx, reference)
+		if err != nil {
+			return "", err
 		}
-		desc, err := executor.Resolve(ctx, ref.String())
+		defer release()
+		desc, err := executor.ResolvePlatform(ctx, ref.String(), platform)
 		if err != nil {
 			return "", err
 		}
 		ref.Reference = desc.Digest.String()
 		resolvedRef := ref.String()
 
-		if err = s.mutateCache.Set(ctx, key, resolvedRef, 0); err != nil {
-			logrus.Warnf("failed to set mutate cache for image %s: %v", reference, err)
+		if err = s.mutateCache.Set(ctx, key, resolvedRef, executor.CacheTTL(reference).Success); err != nil {
+			logger.GetLogger(ctx, logOpt).Warnf("failed to set mutate cache for image %s: %v", reference, err)
 		}
 		return resolvedRef, nil
 	})
+	metrics.ReportSingleflightCount(ctx, metrics.CacheMutate, shared)
 	if err != nil {
-		item.Error = err.Error()
-	} else {
-		item.Value = val
+		return failedMutateItem(mutateReq, err)
 	}
+	item.Value = val
 	return item
 }
 
+// readProviderRequest reads the provider request from the body of r. Requests
+// whose body exceeds MaxRequestBodyBytes or with more than MaxKeys keys are
+// rejected with 413 and a system error, so that Gatekeeper reports the reason
+// instead of timing out.
+func (s *server) readProviderRequest(w http.ResponseWriter, r *http.Request, isMutation bool) (*externaldata.ProviderRequest, error) {
+	body, err := io.ReadAll(s.limitBody(w, r))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			err = errcode.RequestTooLarge.Errorf("request body exceeds %d bytes", maxBytesErr.Limit)
+			return nil, errors.Join(sendSystemError(w, http.StatusRequestEntityTooLarge, isMutation, err), err)
+		}
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	var providerRequest externaldata.ProviderRequest
+	if err = json.Unmarshal(body, &providerRequest); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal request body to provider request: %w", err)
+	}
+	if s.MaxKeys > 0 && len(providerRequest.Request.Keys) > s.MaxKeys {
+		err = errcode.RequestTooLarge.Errorf("request has %d keys, exceeding the limit of %d", len(providerRequest.Request.Keys), s.MaxKeys)
+		return nil, errors.Join(sendSystemError(w, http.StatusRequestEntityTooLarge, isMutation, err), err)
+	}
+	return &providerRequest, nil
+}
+
+// limitBody returns the body of r limited to MaxRequestBodyBytes.
+func (s *server) limitBody(w http.ResponseWriter, r *http.Request) io.Reader {
+	if s.MaxRequestBodyBytes <= 0 {
+		return r.Body
+	}
+	return http.MaxBytesReader(w, r.Body, s.MaxRequestBodyBytes)
+}
+
+// sendSystemError rejects the whole provider request with err.
+func sendSystemError(w http.ResponseWriter, respCode int, isMutation bool, err error) error {
+	response := externaldata.ProviderResponse{
+		APIVersion: "externaldata.gatekeeper.sh/v1beta1",
+		Kind:       "ProviderResponse",
+		Response: externaldata.Response{
+			Idempotent:  isMutation,
+			SystemError: itemError(err),
+		},
+	}
+	w.WriteHeader(respCode)
+	return json.NewEncoder(w).Encode(response)
+}
+
 func sendResponse(results []externaldata.Item, w http.ResponseWriter, respCode int, isMutation bool) error {
 	response := externaldata.ProviderResponse{
 		APIVersion: "externaldata.gatekeeper.sh/v1beta1",
@@ -169,10 +506,54 @@ func sendResponse(results []externaldata.Item, w http.ResponseWriter, respCode i
 	return json.NewEncoder(w).Encode(response)
 }
 
+// itemError renders err for the Error field of an [externaldata.Item]. The
+// message is prefixed with the error code so that policies can match on a
+// stable identifier.
+func itemError(err error) string {
+	return convertError(err, errcode.Unknown).String()
+}
+
 func mutateKey(key string) string {
 	return fmt.Sprintf("%s_%s", mutatePath, key)
 }
 
+// withPlatform appends the platform to the cache key. The key is returned as
+// is if platform is nil.
+func withPlatform(key string, platform *ocispec.Platform) string {
+	if platform == nil {
+		return key
+	}
+	return fmt.Sprintf("%s?platform=%s", key, executor.FormatPlatform(platform))
+}
+
 func verifyKey(key string) string {
 	return fmt.Sprintf("%s_%s", verifyPath, key)
 }
+
+// verifyCacheKey returns the verify cache key of the artifact validated for
+// the platform. The key includes the fingerprint of the executor
+// configuration, so that results cached before a configuration change are not
+// served afterwards.
+func (s *server) verifyCacheKey(artifact string, platform *ocispec.Platform) string {
+	key := withPlatform(verifyKey(artifact), platform)
+	fingerprint := s.configFingerprint(artifact)
+	if fingerprint == "" {
+		return key
+	}
+	separator := "?"
+	if platform != nil {
+		separator = "&"
+	}
+	return key + separator + "config=" + fingerprint
+}
+
+// configFingerprint returns the fingerprint of the configuration of the
+// executor the artifact is validated with, or an empty string if no executor
+// is loaded.
+func (s *server) configFingerprint(artifact string) string {
+	scopedExecutor := s.getExecutor()
+	if scopedExecutor == nil {
+		return ""
+	}
+	return scopedExecutor.Fingerprint(artifact)
+}
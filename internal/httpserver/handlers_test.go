@@ -18,24 +18,132 @@ package httpserver
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/cache/ristretto"
+	"github.com/notaryproject/ratify/v2/internal/errcode"
 	"github.com/notaryproject/ratify/v2/internal/executor"
+	"github.com/notaryproject/ratify/v2/internal/store"
+	"github.com/notaryproject/ratify/v2/internal/verifier"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/externaldata"
-	"golang.org/x/sync/singleflight"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
+const (
+	indexStoreType       = "mock-index-store"
+	signedIndexStoreType = "mock-signed-index-store"
+	unavailableStoreType = "mock-unavailable-store"
+	indexDigest          = "sha256:cbbf2f9a99b47fc460d422812b6a5adff7dfee951d8fa2e4a98caa0382cfbdbf"
+	amd64Digest          = "sha256:a11ce5c38d4f4ad49dec8a4ecbb8e5b2f42e1f4e8aea71a2a6dd0a0f3b8b8f84"
+	arm64Digest          = "sha256:b0a2d8c8a4b4c0ac4e9e3a2b4d3e8f1f6a1a5a9c1f4d0e2f3b6c7d8e9f0a1b2c"
+)
+
+// indexStore resolves every reference to an image index of a linux/amd64
+// and a linux/arm64 manifest.
+type indexStore struct {
+	mockStore
+}
+
+func (s *indexStore) Resolve(_ context.Context, _ string) (ocispec.Descriptor, error) {
+	return ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Digest:    digest.Digest(indexDigest),
+	}, nil
+}
+
+func (s *indexStore) FetchManifest(_ context.Context, _ string, _ ocispec.Descriptor) ([]byte, error) {
+	return json.Marshal(ocispec.Index{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{
+			{
+				MediaType: ocispec.MediaTypeImageManifest,
+				Digest:    digest.Digest(amd64Digest),
+				Platform:  &ocispec.Platform{OS: "linux", Architecture: "amd64"},
+			},
+			{
+				MediaType: ocispec.MediaTypeImageManifest,
+				Digest:    digest.Digest(arm64Digest),
+				Platform:  &ocispec.Platform{OS: "linux", Architecture: "arm64"},
+			},
+		},
+	})
+}
+
+func newIndexStore(_ *store.NewOptions) (ratify.Store, error) {
+	return &indexStore{}, nil
+}
+
+// signedIndexStore serves the index of indexStore whose child manifests can
+// be resolved by digest. Only the index has a signature referrer.
+type signedIndexStore struct {
+	indexStore
+}
+
+func (s *signedIndexStore) Resolve(ctx context.Context, ref string) (ocispec.Descriptor, error) {
+	for _, child := range []string{amd64Digest, arm64Digest} {
+		if strings.HasSuffix(ref, "@"+child) {
+			return ocispec.Descriptor{
+				MediaType: ocispec.MediaTypeImageManifest,
+				Digest:    digest.Digest(child),
+			}, nil
+		}
+	}
+	return s.indexStore.Resolve(ctx, ref)
+}
+
+func (s *signedIndexStore) ListReferrers(_ context.Context, ref string, _ []string, fn func(referrers []ocispec.Descriptor) error) error {
+	if !strings.HasSuffix(ref, "@"+indexDigest) {
+		return nil
+	}
+	return fn([]ocispec.Descriptor{signatureDesc})
+}
+
+func newSignedIndexStore(_ *store.NewOptions) (ratify.Store, error) {
+	return &signedIndexStore{}, nil
+}
+
+// unavailableStore fails every request as if the registry is unavailable.
+type unavailableStore struct {
+	mockStore
+}
+
+func (s *unavailableStore) Resolve(_ context.Context, ref string) (ocispec.Descriptor, error) {
+	return ocispec.Descriptor{}, errcode.RegistryUnavailable.Errorf("failed to resolve %s: service unavailable", ref)
+}
+
+func (s *unavailableStore) ListReferrers(_ context.Context, ref string, _ []string, _ func(referrers []ocispec.Descriptor) error) error {
+	return errcode.RegistryUnavailable.Errorf("failed to list referrers of %s: service unavailable", ref)
+}
+
+func newUnavailableStore(_ *store.NewOptions) (ratify.Store, error) {
+	return &unavailableStore{}, nil
+}
+
+func init() {
+	store.RegisterStoreFactory(indexStoreType, newIndexStore)
+	store.RegisterStoreFactory(signedIndexStoreType, newSignedIndexStore)
+	store.RegisterStoreFactory(unavailableStoreType, newUnavailableStore)
+}
+
 type mockCache struct {
+	mu      sync.Mutex
 	entries map[string]string
 }
 
 func (c *mockCache) Get(_ context.Context, key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if val, ok := c.entries[key]; ok {
 		return val, nil
 	}
@@ -43,20 +151,38 @@ func (c *mockCache) Get(_ context.Context, key string) (string, error) {
 }
 
 func (c *mockCache) Set(_ context.Context, key string, value string, _ time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.entries[key] = value
 	return nil
 }
 
 func (c *mockCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	delete(c.entries, key)
 	return nil
 }
 
+func (c *mockCache) DeleteByPrefix(_ context.Context, prefix string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+	return nil
+}
+
 type mockResultCache struct {
+	mu      sync.Mutex
 	entries map[string]*result
 }
 
 func (c *mockResultCache) Get(_ context.Context, key string) (*result, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if val, ok := c.entries[key]; ok {
 		return val, nil
 	}
@@ -64,22 +190,37 @@ func (c *mockResultCache) Get(_ context.Context, key string) (*result, error) {
 }
 
 func (c *mockResultCache) Set(_ context.Context, key string, value *result, _ time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.entries[key] = value
 	return nil
 }
 
 func (c *mockResultCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	delete(c.entries, key)
 	return nil
 }
 
+func (c *mockResultCache) DeleteByPrefix(_ context.Context, prefix string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+	return nil
+}
+
 func TestVerify(t *testing.T) {
 	server := &server{
 		getExecutor: func() *executor.ScopedExecutor {
 			return &executor.ScopedExecutor{}
 		},
 		verifyCache: &mockResultCache{entries: make(map[string]*result)},
-		sfGroup:     new(singleflight.Group),
+		sfGroup:     new(flightGroup),
 	}
 
 	tests := []struct {
@@ -100,9 +241,19 @@ func TestVerify(t *testing.T) {
 			expectedError: false,
 			expectedItems: []externaldata.Item{
 				{
-					Key:   "artifact1",
-					Value: nil,
-					Error: "failed to match executor for artifact \"artifact1\": failed to parse artifact reference \"artifact1\": invalid reference: missing registry or repository",
+					Key: "artifact1",
+					Value: map[string]interface{}{
+						"succeeded":       false,
+						"artifactReports": nil,
+						"error": map[string]interface{}{
+							"code":        "INVALID_REFERENCE",
+							"category":    "invalidInput",
+							"retryable":   false,
+							"remediation": errcode.InvalidReference.Remediation(),
+							"message":     "failed to match executor for artifact \"artifact1\": failed to parse artifact reference \"artifact1\": invalid reference: missing registry or repository",
+						},
+					},
+					Error: "INVALID_REFERENCE: failed to match executor for artifact \"artifact1\": failed to parse artifact reference \"artifact1\": invalid reference: missing registry or repository",
 				},
 			},
 		},
@@ -119,9 +270,48 @@ func TestVerify(t *testing.T) {
 			expectedError: false,
 			expectedItems: []externaldata.Item{
 				{
-					Key:   "artifact1",
-					Value: nil,
-					Error: "no valid executor configured",
+					Key: "artifact1",
+					Value: map[string]interface{}{
+						"succeeded":       false,
+						"artifactReports": nil,
+						"error": map[string]interface{}{
+							"code":        "EXECUTOR_NOT_CONFIGURED",
+							"category":    "configuration",
+							"retryable":   true,
+							"remediation": errcode.ExecutorNotConfigured.Remediation(),
+							"message":     "no valid executor configured",
+						},
+					},
+					Error: "EXECUTOR_NOT_CONFIGURED: no valid executor configured",
+				},
+			},
+		},
+		{
+			name: "Failed validation of a platform-hinted key",
+			requestBody: `{
+				"request": {
+					"keys": ["{\"reference\": \"registry.example.com/app:v1\", \"os\": \"linux\", \"arch\": \"arm64\"}"]
+				}
+			}`,
+			getExecutorFunc: func() *executor.ScopedExecutor {
+				return nil // Simulate failure to get executor
+			},
+			expectedError: false,
+			expectedItems: []externaldata.Item{
+				{
+					Key: `{"reference": "registry.example.com/app:v1", "os": "linux", "arch": "arm64"}`,
+					Value: map[string]interface{}{
+						"succeeded":       false,
+						"artifactReports": nil,
+						"error": map[string]interface{}{
+							"code":        "EXECUTOR_NOT_CONFIGURED",
+							"category":    "configuration",
+							"retryable":   true,
+							"remediation": errcode.ExecutorNotConfigured.Remediation(),
+							"message":     "no valid executor configured",
+						},
+					},
+					Error: "EXECUTOR_NOT_CONFIGURED: no valid executor configured",
 				},
 			},
 		},
@@ -146,6 +336,61 @@ func TestVerify(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Valid request with negative cache hit",
+			requestBody: `{
+				"request": {
+					"keys": ["artifact1"]
+				}
+			}`,
+			cacheEntries: map[string]*result{
+				"verify_artifact1": convertFailure(errcode.RegistryUnavailable.Errorf("too many requests")),
+			},
+			expectedError: false,
+			expectedItems: []externaldata.Item{
+				{
+					Key: "artifact1",
+					Value: map[string]interface{}{
+						"succeeded":       false,
+						"artifactReports": nil,
+						"error": map[string]interface{}{
+							"code":        "REGISTRY_UNAVAILABLE",
+							"category":    "registry",
+							"retryable":   true,
+							"remediation": errcode.RegistryUnavailable.Remediation(),
+							"message":     "too many requests",
+						},
+					},
+					Error: "REGISTRY_UNAVAILABLE: too many requests",
+				},
+			},
+		},
+		{
+			name: "Invalid key",
+			requestBody: `{
+				"request": {
+					"keys": ["{\"os\": \"linux\", \"arch\": \"arm64\"}"]
+				}
+			}`,
+			expectedError: false,
+			expectedItems: []externaldata.Item{
+				{
+					Key: `{"os": "linux", "arch": "arm64"}`,
+					Value: map[string]interface{}{
+						"succeeded":       false,
+						"artifactReports": nil,
+						"error": map[string]interface{}{
+							"code":        "INVALID_REFERENCE",
+							"category":    "invalidInput",
+							"retryable":   false,
+							"remediation": errcode.InvalidReference.Remediation(),
+							"message":     "reference must be set in the key",
+						},
+					},
+					Error: "INVALID_REFERENCE: reference must be set in the key",
+				},
+			},
+		},
 		{
 			name:          "Invalid JSON",
 			requestBody:   `{invalid-json}`,
@@ -223,7 +468,7 @@ func TestMutate(t *testing.T) {
 				{
 					Key:   "testrepo",
 					Value: "testrepo",
-					Error: "failed to parse reference: invalid reference: missing registry or repository",
+					Error: "INVALID_REFERENCE: failed to parse reference: invalid reference: missing registry or repository",
 				},
 			},
 		},
@@ -257,7 +502,7 @@ func TestMutate(t *testing.T) {
 				{
 					Key:   "testrepo/testimage:v1",
 					Value: "testrepo/testimage:v1",
-					Error: "failed to match executor for artifact \"testrepo/testimage:v1\": no executor configured for the artifact \"testrepo/testimage:v1\"",
+					Error: "SCOPE_NOT_MATCHED: failed to match executor for artifact \"testrepo/testimage:v1\": no executor configured for the artifact \"testrepo/testimage:v1\"",
 				},
 			},
 		},
@@ -273,7 +518,7 @@ func TestMutate(t *testing.T) {
 					return &executor.ScopedExecutor{}
 				},
 				mutateCache: &mockCache{entries: make(map[string]string)},
-				sfGroup:     new(singleflight.Group),
+				sfGroup:     new(flightGroup),
 			}
 			if test.cacheEntries != nil {
 				server.mutateCache = &mockCache{entries: test.cacheEntries}
@@ -298,3 +543,707 @@ func TestMutate(t *testing.T) {
 		})
 	}
 }
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		name          string
+		key           string
+		expected      *keyRequest
+		expectedError bool
+	}{
+		{
+			name:     "plain reference",
+			key:      "registry.example.com/app:v1",
+			expected: &keyRequest{Reference: "registry.example.com/app:v1"},
+		},
+		{
+			name:     "platform hint",
+			key:      `{"reference": "registry.example.com/app:v1", "os": "linux", "arch": "arm64", "variant": "v8"}`,
+			expected: &keyRequest{Reference: "registry.example.com/app:v1", OS: "linux", Arch: "arm64", Variant: "v8"},
+		},
+		{
+			name:     "no platform hint",
+			key:      `{"reference": "registry.example.com/app:v1"}`,
+			expected: &keyRequest{Reference: "registry.example.com/app:v1"},
+		},
+		{
+			name:          "invalid JSON",
+			key:           `{"reference": }`,
+			expectedError: true,
+		},
+		{
+			name:          "missing reference",
+			key:           `{"os": "linux", "arch": "arm64"}`,
+			expectedError: true,
+		},
+		{
+			name:          "missing arch",
+			key:           `{"reference": "registry.example.com/app:v1", "os": "linux"}`,
+			expectedError: true,
+		},
+		{
+			name:          "variant without arch",
+			key:           `{"reference": "registry.example.com/app:v1", "variant": "v8"}`,
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := parseKey(test.key)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if !reflect.DeepEqual(req, test.expected) {
+				t.Errorf("expected request: %+v, got: %+v", test.expected, req)
+			}
+		})
+	}
+}
+
+func TestMutate_Platform(t *testing.T) {
+	scopedExecutor, err := executor.NewScopedExecutor(&executor.Options{
+		Executors: []*executor.ScopedOptions{
+			{
+				Scopes: []string{"registry.example.com"},
+				Verifiers: []*verifier.NewOptions{
+					{
+						Name: mockVerifierName,
+						Type: mockVerifierType,
+					},
+				},
+				Stores: []*store.NewOptions{
+					{
+						Type: indexStoreType,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create executor: %v", err)
+	}
+	const (
+		tagRef     = "registry.example.com/app:v1"
+		indexRef   = "registry.example.com/app@" + indexDigest
+		amd64Ref   = "registry.example.com/app@" + amd64Digest
+		arm64Ref   = "registry.example.com/app@" + arm64Digest
+		arm64Key   = `{"reference": "registry.example.com/app:v1", "os": "linux", "arch": "arm64"}`
+		s390xKey   = `{"reference": "registry.example.com/app:v1", "os": "linux", "arch": "s390x"}`
+		invalidKey = `{"os": "linux"}`
+	)
+
+	tests := []struct {
+		name            string
+		defaultPlatform *ocispec.Platform
+		key             string
+		expectedItem    externaldata.Item
+		expectedCache   string
+	}{
+		{
+			name:          "no hint and no default platform",
+			key:           tagRef,
+			expectedItem:  externaldata.Item{Key: tagRef, Value: indexRef},
+			expectedCache: "mutate_" + tagRef,
+		},
+		{
+			name:            "no hint with default platform",
+			defaultPlatform: &ocispec.Platform{OS: "linux", Architecture: "amd64"},
+			key:             tagRef,
+			expectedItem:    externaldata.Item{Key: tagRef, Value: amd64Ref},
+			expectedCache:   "mutate_" + tagRef + "?platform=linux/amd64",
+		},
+		{
+			name:            "hint takes precedence over default platform",
+			defaultPlatform: &ocispec.Platform{OS: "linux", Architecture: "amd64"},
+			key:             arm64Key,
+			expectedItem:    externaldata.Item{Key: arm64Key, Value: arm64Ref},
+			expectedCache:   "mutate_" + tagRef + "?platform=linux/arm64",
+		},
+		{
+			name: "unknown platform",
+			key:  s390xKey,
+			expectedItem: externaldata.Item{
+				Key:   s390xKey,
+				Value: tagRef,
+				Error: "SUBJECT_NOT_FOUND: no manifest found for platform linux/s390x in the index of artifact \"registry.example.com/app:v1\"",
+			},
+		},
+		{
+			name: "invalid key",
+			key:  invalidKey,
+			expectedItem: externaldata.Item{
+				Key:   invalidKey,
+				Value: invalidKey,
+				Error: "INVALID_REFERENCE: reference must be set in the key",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mutateCache := &mockCache{entries: make(map[string]string)}
+			server := &server{
+				getExecutor: func() *executor.ScopedExecutor {
+					return scopedExecutor
+				},
+				mutateCache:     mutateCache,
+				sfGroup:         new(flightGroup),
+				defaultPlatform: test.defaultPlatform,
+			}
+			item := server.resolveReference(context.Background(), test.key)
+			if !reflect.DeepEqual(item, test.expectedItem) {
+				t.Errorf("expected item: %+v, got: %+v", test.expectedItem, item)
+			}
+			if test.expectedCache == "" {
+				return
+			}
+			if cached := mutateCache.entries[test.expectedCache]; cached != test.expectedItem.Value {
+				t.Errorf("expected cache entry %s to be %v, got %q", test.expectedCache, test.expectedItem.Value, cached)
+			}
+		})
+	}
+}
+
+func TestVerify_ResponseSchemaV2(t *testing.T) {
+	scopedExecutor := newSignedExecutor(t)
+	server := &server{
+		getExecutor: func() *executor.ScopedExecutor {
+			return scopedExecutor
+		},
+		validationCache: &mockTypedCache[*validationResponse]{entries: make(map[string]*validationResponse)},
+		sfGroup:         new(flightGroup),
+		ServerOptions: ServerOptions{
+			ResponseSchema: responseSchemaV2,
+		},
+	}
+
+	for _, expectedCacheHit := range []bool{false, true} {
+		body := `{"request": {"keys": ["registry.example.com/test/image:v1", "unknown.example.org/test/image:v1"]}}`
+		req := httptest.NewRequest(http.MethodPost, "/verify", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		if err := server.verify(context.Background(), w, req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// Decode the items as Rego policies would see them.
+		var response struct {
+			Response struct {
+				Items []struct {
+					Key   string         `json:"key"`
+					Value map[string]any `json:"value"`
+					Error string         `json:"error"`
+				} `json:"items"`
+			} `json:"response"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(response.Response.Items) != 2 {
+			t.Fatalf("expected 2 items, got %d", len(response.Response.Items))
+		}
+
+		value := response.Response.Items[0].Value
+		if value["schemaVersion"] != responseSchemaV2 || value["succeeded"] != true {
+			t.Errorf("expected a succeeded v2 result, got %v", value)
+		}
+		if value["resolvedDigest"] != signedSubjectDesc.Digest.String() || value["matchedScope"] != "registry.example.com" {
+			t.Errorf("expected resolved digest and matched scope, got %v", value)
+		}
+		if value["cacheHit"] != expectedCacheHit {
+			t.Errorf("expected cache hit %t, got %v", expectedCacheHit, value["cacheHit"])
+		}
+		reports, _ := value["artifactReports"].([]any)
+		if len(reports) != 1 {
+			t.Fatalf("expected 1 artifact report, got %v", value["artifactReports"])
+		}
+		results, _ := reports[0].(map[string]any)["results"].([]any)
+		if len(results) != 1 {
+			t.Fatalf("expected 1 verification result, got %v", reports[0])
+		}
+		verificationResult := results[0].(map[string]any)
+		if verificationResult["verifierType"] != signatureVerifierType {
+			t.Errorf("expected verifier type %s, got %v", signatureVerifierType, verificationResult["verifierType"])
+		}
+		if detail, ok := verificationResult["detail"].(map[string]any); !ok || detail["issuer"] != "test" {
+			t.Errorf("expected detail as a JSON object, got %v", verificationResult["detail"])
+		}
+
+		failed := response.Response.Items[1]
+		if !strings.HasPrefix(failed.Error, string(errcode.ScopeNotMatched)) || failed.Value["schemaVersion"] != responseSchemaV2 {
+			t.Errorf("expected a v2 scope not matched failure, got %+v", failed)
+		}
+	}
+}
+
+func TestValidateArtifact_IndexModeParity(t *testing.T) {
+	const arm64Key = `{"reference": "registry.example.com/app:v1", "os": "linux", "arch": "arm64"}`
+	tests := []struct {
+		indexMode       string
+		expectSucceeded bool
+	}{
+		{indexMode: string(executor.IndexModeIndexOnly), expectSucceeded: true},
+		{indexMode: string(executor.IndexModePlatform), expectSucceeded: false},
+		{indexMode: string(executor.IndexModeChildrenAll), expectSucceeded: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.indexMode, func(t *testing.T) {
+			opts := signedExecutorOptions()
+			opts.Executors[0].Stores[0].Type = signedIndexStoreType
+			opts.Executors[0].IndexMode = test.indexMode
+			scopedExecutor, err := executor.NewScopedExecutor(opts)
+			if err != nil {
+				t.Fatalf("failed to create executor: %v", err)
+			}
+			for _, schema := range []string{responseSchemaV1, responseSchemaV2} {
+				server := &server{
+					getExecutor:     func() *executor.ScopedExecutor { return scopedExecutor },
+					verifyCache:     &mockResultCache{entries: make(map[string]*result)},
+					validationCache: &mockTypedCache[*validationResponse]{entries: make(map[string]*validationResponse)},
+					sfGroup:         new(flightGroup),
+				}
+				server.ResponseSchema = schema
+
+				item, _ := server.validateArtifact(context.Background(), arm64Key)
+				if item.Error != "" {
+					t.Fatalf("%s: expected no error, got %q", schema, item.Error)
+				}
+				var succeeded bool
+				switch value := item.Value.(type) {
+				case *result:
+					succeeded = value.Succeeded
+				case *validationResponse:
+					succeeded = value.Succeeded
+					if value.ResolvedDigest != indexDigest {
+						t.Errorf("%s: expected resolved digest %s, got %s", schema, indexDigest, value.ResolvedDigest)
+					}
+				default:
+					t.Fatalf("%s: unexpected item value %T", schema, item.Value)
+				}
+				if succeeded != test.expectSucceeded {
+					t.Errorf("%s: expected succeeded %t, got %t", schema, test.expectSucceeded, succeeded)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateArtifact_AuditOnlyUnavailable(t *testing.T) {
+	opts := signedExecutorOptions()
+	opts.Executors[0].Stores[0].Type = unavailableStoreType
+	opts.Executors[0].AuditOnly = true
+	scopedExecutor, err := executor.NewScopedExecutor(opts)
+	if err != nil {
+		t.Fatalf("failed to create executor: %v", err)
+	}
+
+	for _, schema := range []string{responseSchemaV1, responseSchemaV2} {
+		t.Run(schema, func(t *testing.T) {
+			server := &server{
+				getExecutor:     func() *executor.ScopedExecutor { return scopedExecutor },
+				verifyCache:     &mockResultCache{entries: make(map[string]*result)},
+				validationCache: &mockTypedCache[*validationResponse]{entries: make(map[string]*validationResponse)},
+				sfGroup:         new(flightGroup),
+			}
+			server.ResponseSchema = schema
+
+			item, _ := server.validateArtifact(context.Background(), signedSubject)
+			if item.Error != "" {
+				t.Fatalf("expected no error, got %q", item.Error)
+			}
+			var succeeded bool
+			var outcome *auditOnlyOutcome
+			switch value := item.Value.(type) {
+			case *result:
+				succeeded, outcome = value.Succeeded, value.AuditOnly
+			case *validationResponse:
+				succeeded, outcome = value.Succeeded, value.AuditOnly
+			default:
+				t.Fatalf("unexpected item value %T", item.Value)
+			}
+			if !succeeded {
+				t.Error("expected the audit-only executor not to fail the validation")
+			}
+			if outcome == nil || !outcome.WouldHaveFailed || outcome.Error == nil || outcome.Error.Code != errcode.RegistryUnavailable {
+				t.Errorf("expected a would have failed outcome with a %s error, got %+v", errcode.RegistryUnavailable, outcome)
+			}
+		})
+	}
+}
+
+func TestValidateArtifact_ConfigChange(t *testing.T) {
+	var current atomic.Pointer[executor.ScopedExecutor]
+	current.Store(newSignedExecutor(t))
+	verifyCache, err := ristretto.NewCache[*result](time.Minute)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	server := &server{
+		getExecutor:     current.Load,
+		verifyCache:     verifyCache,
+		validationCache: &mockTypedCache[*validationResponse]{entries: make(map[string]*validationResponse)},
+		sfGroup:         new(flightGroup),
+	}
+	changedOpts := signedExecutorOptions()
+	changedOpts.Cache = &executor.CacheOptions{TTL: "1m"}
+	changed, err := executor.NewScopedExecutor(changedOpts)
+	if err != nil {
+		t.Fatalf("failed to create executor: %v", err)
+	}
+
+	for _, schema := range []string{responseSchemaV1, responseSchemaV2} {
+		t.Run(schema, func(t *testing.T) {
+			current.Store(newSignedExecutor(t))
+			server.ResponseSchema = schema
+			steps := []struct {
+				executor       *executor.ScopedExecutor
+				expectCacheHit bool
+			}{
+				{executor: current.Load(), expectCacheHit: false},
+				{executor: current.Load(), expectCacheHit: true},
+				// An executor of the same configuration and generation keeps
+				// the cache.
+				{executor: newSignedExecutor(t), expectCacheHit: true},
+				// A configuration change invalidates it.
+				{executor: changed, expectCacheHit: false},
+				{executor: changed, expectCacheHit: true},
+			}
+			for i, step := range steps {
+				current.Store(step.executor)
+				if _, cacheHit := server.validateArtifact(context.Background(), signedSubject); cacheHit != step.expectCacheHit {
+					t.Errorf("step %d: expected cache hit %t, got %t", i, step.expectCacheHit, cacheHit)
+				}
+			}
+		})
+	}
+}
+
+// trustStoreVerifierType is the type of [trustStoreVerifier].
+const trustStoreVerifierType = "mock-trust-store-verifier"
+
+// trustedIssuer is the issuer trusted by the trust store verifiers created
+// next, standing in for trust material referenced by the options.
+var trustedIssuer atomic.Value
+
+// trustStoreVerifier accepts signatures only if the issuer trusted when it was
+// created is the issuer of the signatures.
+type trustStoreVerifier struct {
+	mockVerifier
+	trusted bool
+}
+
+func (v *trustStoreVerifier) Type() string {
+	return trustStoreVerifierType
+}
+
+func (v *trustStoreVerifier) Verify(_ context.Context, _ *ratify.VerifyOptions) (*ratify.VerificationResult, error) {
+	result := &ratify.VerificationResult{Verifier: v}
+	if !v.trusted {
+		result.Err = errors.New("signature issuer is not trusted")
+	}
+	return result, nil
+}
+
+func init() {
+	trustedIssuer.Store("test")
+	verifier.RegisterVerifierFactory(trustStoreVerifierType, func(_ *verifier.NewOptions, _ []string) (ratify.Verifier, error) {
+		return &trustStoreVerifier{trusted: trustedIssuer.Load() == "test"}, nil
+	})
+}
+
+func TestValidateArtifact_TrustMaterialChange(t *testing.T) {
+	t.Cleanup(func() { trustedIssuer.Store("test") })
+	newExecutor := func(generation uint64) *executor.ScopedExecutor {
+		opts := signedExecutorOptions()
+		opts.Executors[0].Verifiers[0].Type = trustStoreVerifierType
+		opts.Generation = generation
+		scopedExecutor, err := executor.NewScopedExecutor(opts)
+		if err != nil {
+			t.Fatalf("failed to create executor: %v", err)
+		}
+		return scopedExecutor
+	}
+
+	for _, schema := range []string{responseSchemaV1, responseSchemaV2} {
+		t.Run(schema, func(t *testing.T) {
+			trustedIssuer.Store("test")
+			var current atomic.Pointer[executor.ScopedExecutor]
+			current.Store(newExecutor(1))
+			verifyCache, err := ristretto.NewCache[*result](time.Minute)
+			if err != nil {
+				t.Fatalf("failed to create cache: %v", err)
+			}
+			server := &server{
+				getExecutor:     current.Load,
+				verifyCache:     verifyCache,
+				validationCache: &mockTypedCache[*validationResponse]{entries: make(map[string]*validationResponse)},
+				sfGroup:         new(flightGroup),
+			}
+			server.ResponseSchema = schema
+
+			validate := func() (bool, bool) {
+				item, cacheHit := server.validateArtifact(context.Background(), signedSubject)
+				switch value := item.Value.(type) {
+				case *result:
+					return value.Succeeded, cacheHit
+				case *validationResponse:
+					return value.Succeeded, cacheHit
+				}
+				t.Fatalf("unexpected item value %T", item.Value)
+				return false, false
+			}
+			if succeeded, _ := validate(); !succeeded {
+				t.Fatal("expected the trusted issuer to pass")
+			}
+			if _, cacheHit := validate(); !cacheHit {
+				t.Fatal("expected the result to be cached")
+			}
+
+			// The issuer is no longer trusted, e.g. its certificate was
+			// removed, and the executor is reloaded with identical options.
+			trustedIssuer.Store("revoked")
+			current.Store(newExecutor(2))
+			succeeded, cacheHit := validate()
+			if cacheHit {
+				t.Error("expected a cache miss after the executor was reloaded")
+			}
+			if succeeded {
+				t.Error("expected the untrusted issuer to fail")
+			}
+		})
+	}
+}
+
+func TestValidateArtifact_UnmatchedScope(t *testing.T) {
+	scopedExecutor := newSignedExecutor(t)
+	tests := []struct {
+		mode            string
+		expectError     bool
+		expectSucceeded bool
+	}{
+		{mode: unmatchedScopeError, expectError: true},
+		{mode: unmatchedScopeDeny, expectSucceeded: false},
+		{mode: unmatchedScopeAllow, expectSucceeded: true},
+	}
+	for _, schema := range []string{responseSchemaV1, responseSchemaV2} {
+		for _, test := range tests {
+			t.Run(schema+"_"+test.mode, func(t *testing.T) {
+				server := &server{
+					getExecutor:     func() *executor.ScopedExecutor { return scopedExecutor },
+					verifyCache:     &mockResultCache{entries: make(map[string]*result)},
+					validationCache: &mockTypedCache[*validationResponse]{entries: make(map[string]*validationResponse)},
+					sfGroup:         new(flightGroup),
+				}
+				server.ResponseSchema = schema
+				server.UnmatchedScope = test.mode
+
+				item, _ := server.validateArtifact(context.Background(), "unknown.example.com/app:v1")
+				if test.expectError {
+					if !strings.Contains(item.Error, string(errcode.ScopeNotMatched)) {
+						t.Errorf("expected %s error, got %q", errcode.ScopeNotMatched, item.Error)
+					}
+					return
+				}
+				if item.Error != "" {
+					t.Fatalf("expected no error, got %q", item.Error)
+				}
+				var succeeded bool
+				var decision *unmatchedDecision
+				switch value := item.Value.(type) {
+				case *result:
+					succeeded, decision = value.Succeeded, value.Unmatched
+				case *validationResponse:
+					succeeded, decision = value.Succeeded, value.Unmatched
+				default:
+					t.Fatalf("unexpected item value %T", item.Value)
+				}
+				if succeeded != test.expectSucceeded {
+					t.Errorf("expected succeeded %t, got %t", test.expectSucceeded, succeeded)
+				}
+				if decision == nil || decision.Mode != test.mode || decision.Reason == "" {
+					t.Errorf("expected unmatched decision %q with a reason, got %+v", test.mode, decision)
+				}
+			})
+		}
+	}
+}
+
+func TestRequestLimits(t *testing.T) {
+	scopedExecutor := newSignedExecutor(t)
+
+	tests := []struct {
+		name                string
+		requestBody         string
+		isMutation          bool
+		options             ServerOptions
+		limiter             *inFlightLimiter
+		expectedStatusCode  int
+		expectedSystemError errcode.Code
+		expectedItemError   errcode.Code
+	}{
+		{
+			name:               "within limits",
+			requestBody:        `{"request": {"keys": ["registry.example.com/test/image:v1"]}}`,
+			options:            ServerOptions{MaxRequestBodyBytes: 1024, MaxKeys: 1},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:                "verify body too large",
+			requestBody:         `{"request": {"keys": ["registry.example.com/test/image:v1"]}}`,
+			options:             ServerOptions{MaxRequestBodyBytes: 16},
+			expectedStatusCode:  http.StatusRequestEntityTooLarge,
+			expectedSystemError: errcode.RequestTooLarge,
+		},
+		{
+			name:                "mutate too many keys",
+			requestBody:         `{"request": {"keys": ["registry.example.com/test/image:v1", "registry.example.com/test/image:v2"]}}`,
+			isMutation:          true,
+			options:             ServerOptions{MaxKeys: 1},
+			expectedStatusCode:  http.StatusRequestEntityTooLarge,
+			expectedSystemError: errcode.RequestTooLarge,
+		},
+		{
+			name:               "verify overloaded",
+			requestBody:        `{"request": {"keys": ["registry.example.com/test/image:v1"]}}`,
+			limiter:            newSaturatedLimiter(),
+			expectedStatusCode: http.StatusOK,
+			expectedItemError:  errcode.Overloaded,
+		},
+		{
+			name:               "mutate overloaded",
+			requestBody:        `{"request": {"keys": ["registry.example.com/test/image:v1"]}}`,
+			isMutation:         true,
+			limiter:            newSaturatedLimiter(),
+			expectedStatusCode: http.StatusOK,
+			expectedItemError:  errcode.Overloaded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verifyCache := &mockResultCache{entries: make(map[string]*result)}
+			mutateCache := &mockCache{entries: make(map[string]string)}
+			server := &server{
+				getExecutor: func() *executor.ScopedExecutor {
+					return scopedExecutor
+				},
+				verifyCache:   verifyCache,
+				mutateCache:   mutateCache,
+				sfGroup:       new(flightGroup),
+				limiter:       test.limiter,
+				ServerOptions: test.options,
+			}
+			handler := server.verify
+			if test.isMutation {
+				handler = server.mutate
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.requestBody))
+			w := httptest.NewRecorder()
+			err := handler(context.Background(), w, req)
+			if (err != nil) != (test.expectedSystemError != "") {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if w.Code != test.expectedStatusCode {
+				t.Errorf("expected status code %d, got %d", test.expectedStatusCode, w.Code)
+			}
+
+			var response externaldata.ProviderResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !strings.HasPrefix(response.Response.SystemError, string(test.expectedSystemError)) {
+				t.Errorf("expected system error %q, got %q", test.expectedSystemError, response.Response.SystemError)
+			}
+			if test.expectedSystemError != "" {
+				return
+			}
+			if len(response.Response.Items) != 1 {
+				t.Fatalf("expected 1 item, got %d", len(response.Response.Items))
+			}
+			if itemErr := response.Response.Items[0].Error; !strings.HasPrefix(itemErr, string(test.expectedItemError)) || (test.expectedItemError == "") != (itemErr == "") {
+				t.Errorf("expected item error %q, got %q", test.expectedItemError, itemErr)
+			}
+			if test.expectedItemError != "" && (len(verifyCache.entries) != 0 || len(mutateCache.entries) != 0) {
+				t.Errorf("expected overload errors not to be cached")
+			}
+		})
+	}
+}
+
+func TestProcessKeys(t *testing.T) {
+	const maxConcurrentKeys = 2
+	server := &server{
+		ServerOptions: ServerOptions{
+			MaxConcurrentKeys: maxConcurrentKeys,
+		},
+	}
+
+	var running, maxRunning atomic.Int32
+	keys := []string{"key1", "key2", "key3", "key4", "key5"}
+	results := server.processKeys(context.Background(), keys, func(_ context.Context, key string) externaldata.Item {
+		current := running.Add(1)
+		defer running.Add(-1)
+		for {
+			observed := maxRunning.Load()
+			if current <= observed || maxRunning.CompareAndSwap(observed, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return externaldata.Item{Key: key, Value: key + "-value"}
+	}, failedVerifyItem)
+
+	if len(results) != len(keys) {
+		t.Fatalf("expected %d results, got %d", len(keys), len(results))
+	}
+	for idx, key := range keys {
+		if results[idx].Key != key || results[idx].Value != key+"-value" {
+			t.Errorf("expected result %d to be for key %s, got %+v", idx, key, results[idx])
+		}
+	}
+	if maxRunning.Load() > maxConcurrentKeys {
+		t.Errorf("expected at most %d keys processed concurrently, got %d", maxConcurrentKeys, maxRunning.Load())
+	}
+}
+
+func TestProcessKeys_Deadline(t *testing.T) {
+	server := &server{
+		ServerOptions: ServerOptions{
+			MaxConcurrentKeys: 2,
+			ResponseReserve:   100 * time.Millisecond,
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	keys := []string{"fast", "slow", "fast2"}
+	results := server.processKeys(ctx, keys, func(ctx context.Context, key string) externaldata.Item {
+		if key == "slow" {
+			<-ctx.Done()
+			time.Sleep(50 * time.Millisecond)
+		}
+		return externaldata.Item{Key: key, Value: key + "-value"}
+	}, failedVerifyItem)
+	if elapsed := time.Since(start); elapsed >= 300*time.Millisecond {
+		t.Errorf("expected results before the request deadline, took %v", elapsed)
+	}
+
+	for _, idx := range []int{0, 2} {
+		if results[idx].Value != keys[idx]+"-value" || results[idx].Error != "" {
+			t.Errorf("expected key %s to keep its result, got %+v", keys[idx], results[idx])
+		}
+	}
+	timedOut, ok := results[1].Value.(*result)
+	if !ok || timedOut.Error == nil {
+		t.Fatalf("expected key slow to time out, got %+v", results[1])
+	}
+	if timedOut.Error.Code != errcode.Timeout || !timedOut.Error.Retryable {
+		t.Errorf("expected a retryable timeout error, got %+v", timedOut.Error)
+	}
+	if !strings.HasPrefix(results[1].Error, string(errcode.Timeout)) {
+		t.Errorf("expected item error to start with %s, got %s", errcode.Timeout, results[1].Error)
+	}
+}
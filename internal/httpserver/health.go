@@ -0,0 +1,153 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	healthzPath = "/healthz"
+	readyzPath  = "/readyz"
+)
+
+// Status of the server or of an individual readiness check.
+const (
+	statusOK       = "ok"
+	statusDegraded = "degraded"
+	statusNotReady = "notReady"
+)
+
+// Names of the readiness checks.
+const (
+	checkExecutor    = "executor"
+	checkCertRotator = "certRotator"
+	checkTLSCerts    = "tlsCerts"
+	checkReload      = "executorReload"
+	checkDrain       = "drain"
+	checkPrewarm     = "prewarm"
+)
+
+// healthCheck is the outcome of a single readiness check.
+type healthCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// healthResponse is the response body of the readiness endpoint.
+type healthResponse struct {
+	Status string        `json:"status"`
+	Checks []healthCheck `json:"checks,omitempty"`
+}
+
+// registerHealthHandlers registers the liveness and readiness handlers on the
+// router.
+func (s *server) registerHealthHandlers(router *mux.Router) {
+	router.Methods(http.MethodGet).Path(healthzPath).HandlerFunc(s.healthz)
+	router.Methods(http.MethodGet).Path(readyzPath).HandlerFunc(s.readyz)
+}
+
+// healthz reports that the server is alive. It succeeds as long as the server
+// is able to handle requests.
+func (s *server) healthz(w http.ResponseWriter, _ *http.Request) {
+	writeHealthResponse(w, http.StatusOK, &healthResponse{Status: statusOK})
+}
+
+// readyz reports whether the server is ready to handle verify and mutate
+// requests. The server is not ready until an executor is loaded and, if TLS is
+// enabled, the cert rotator has signalled and the TLS certificates are loaded.
+// A ready server is reported as degraded if the last reload of the executor
+// failed, in which case the previously loaded executor is still in use. The
+// server is not ready once it starts draining on shutdown, and until the
+// initial pre-warm run processed PrewarmReadyPercent of its references.
+func (s *server) readyz(w http.ResponseWriter, _ *http.Request) {
+	resp := s.checkReadiness()
+	statusCode := http.StatusOK
+	if resp.Status == statusNotReady {
+		statusCode = http.StatusServiceUnavailable
+	}
+	writeHealthResponse(w, statusCode, resp)
+}
+
+// checkReadiness runs all readiness checks and aggregates their status.
+func (s *server) checkReadiness() *healthResponse {
+	resp := &healthResponse{Status: statusOK}
+	add := func(check healthCheck) {
+		resp.Checks = append(resp.Checks, check)
+		switch {
+		case check.Status == statusNotReady:
+			resp.Status = statusNotReady
+		case check.Status == statusDegraded && resp.Status == statusOK:
+			resp.Status = statusDegraded
+		}
+	}
+
+	if s.draining.Load() {
+		add(healthCheck{Name: checkDrain, Status: statusNotReady, Message: "server is shutting down"})
+	}
+
+	if s.getExecutor() == nil {
+		add(healthCheck{Name: checkExecutor, Status: statusNotReady, Message: "no executor is loaded"})
+	} else {
+		add(healthCheck{Name: checkExecutor, Status: statusOK})
+	}
+
+	if s.tlsEnabled() {
+		if s.CertRotatorReady != nil {
+			if s.certRotatorSignalled.Load() {
+				add(healthCheck{Name: checkCertRotator, Status: statusOK})
+			} else {
+				add(healthCheck{Name: checkCertRotator, Status: statusNotReady, Message: "cert rotator is not ready"})
+			}
+		}
+		if s.tlsCertsLoaded.Load() {
+			add(healthCheck{Name: checkTLSCerts, Status: statusOK})
+		} else {
+			add(healthCheck{Name: checkTLSCerts, Status: statusNotReady, Message: "TLS certificates are not loaded"})
+		}
+	}
+
+	if s.prewarmEnabled() && s.PrewarmReadyPercent > 0 {
+		if s.prewarmReady.Load() {
+			add(healthCheck{Name: checkPrewarm, Status: statusOK})
+		} else {
+			add(healthCheck{Name: checkPrewarm, Status: statusNotReady, Message: fmt.Sprintf("pre-warmed %d of %d references", s.prewarmDone.Load(), s.prewarmTotal.Load())})
+		}
+	}
+
+	if s.getReloadErr != nil {
+		if err := s.getReloadErr(); err != nil {
+			add(healthCheck{Name: checkReload, Status: statusDegraded, Message: err.Error()})
+		} else {
+			add(healthCheck{Name: checkReload, Status: statusOK})
+		}
+	}
+	return resp
+}
+
+func writeHealthResponse(w http.ResponseWriter, statusCode int, resp *healthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logrus.Warnf("failed to write health response: %v", err)
+	}
+}
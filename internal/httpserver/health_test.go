@@ -0,0 +1,173 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/notaryproject/ratify/v2/internal/executor"
+)
+
+func TestHealthz(t *testing.T) {
+	server := &server{
+		getExecutor: func() *executor.ScopedExecutor { return nil },
+	}
+	router := mux.NewRouter()
+	server.registerHealthHandlers(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, healthzPath, nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	tests := []struct {
+		name               string
+		executor           *executor.ScopedExecutor
+		reloadErr          error
+		tls                bool
+		certRotator        bool
+		rotatorSignalled   bool
+		tlsCertsLoaded     bool
+		draining           bool
+		prewarmPercent     int
+		prewarmReady       bool
+		expectedStatusCode int
+		expectedStatus     string
+	}{
+		{
+			name:               "executor not loaded",
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     statusNotReady,
+		},
+		{
+			name:               "executor loaded without TLS",
+			executor:           &executor.ScopedExecutor{},
+			expectedStatusCode: http.StatusOK,
+			expectedStatus:     statusOK,
+		},
+		{
+			name:               "cert rotator not signalled",
+			executor:           &executor.ScopedExecutor{},
+			tls:                true,
+			certRotator:        true,
+			tlsCertsLoaded:     true,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     statusNotReady,
+		},
+		{
+			name:               "TLS certs not loaded",
+			executor:           &executor.ScopedExecutor{},
+			tls:                true,
+			certRotator:        true,
+			rotatorSignalled:   true,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     statusNotReady,
+		},
+		{
+			name:               "TLS ready",
+			executor:           &executor.ScopedExecutor{},
+			tls:                true,
+			certRotator:        true,
+			rotatorSignalled:   true,
+			tlsCertsLoaded:     true,
+			expectedStatusCode: http.StatusOK,
+			expectedStatus:     statusOK,
+		},
+		{
+			name:               "last reload failed",
+			executor:           &executor.ScopedExecutor{},
+			reloadErr:          errors.New("invalid config"),
+			expectedStatusCode: http.StatusOK,
+			expectedStatus:     statusDegraded,
+		},
+		{
+			name:               "draining",
+			executor:           &executor.ScopedExecutor{},
+			draining:           true,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     statusNotReady,
+		},
+		{
+			name:               "pre-warming",
+			executor:           &executor.ScopedExecutor{},
+			prewarmPercent:     80,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     statusNotReady,
+		},
+		{
+			name:               "pre-warmed",
+			executor:           &executor.ScopedExecutor{},
+			prewarmPercent:     80,
+			prewarmReady:       true,
+			expectedStatusCode: http.StatusOK,
+			expectedStatus:     statusOK,
+		},
+		{
+			name:               "last reload failed without executor",
+			reloadErr:          errors.New("invalid config"),
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     statusNotReady,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &server{
+				getExecutor:  func() *executor.ScopedExecutor { return tt.executor },
+				getReloadErr: func() error { return tt.reloadErr },
+			}
+			if tt.tls {
+				server.CertFile = "tls.crt"
+				server.KeyFile = "tls.key"
+			}
+			if tt.certRotator {
+				server.CertRotatorReady = make(chan struct{})
+			}
+			server.certRotatorSignalled.Store(tt.rotatorSignalled)
+			server.tlsCertsLoaded.Store(tt.tlsCertsLoaded)
+			server.draining.Store(tt.draining)
+			if tt.prewarmPercent > 0 {
+				server.PrewarmReferences = []string{artifact1}
+				server.PrewarmReadyPercent = tt.prewarmPercent
+			}
+			server.prewarmReady.Store(tt.prewarmReady)
+
+			router := mux.NewRouter()
+			server.registerHealthHandlers(router)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, readyzPath, nil))
+
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("expected status code %d, got %d", tt.expectedStatusCode, w.Code)
+			}
+			var resp healthResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Status != tt.expectedStatus {
+				t.Errorf("expected status %q, got %q", tt.expectedStatus, resp.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/notaryproject/ratify/v2/internal/errcode"
+	"oras.land/oras-go/v2/registry"
+)
+
+// inFlightLimiter limits the number of in-flight validations globally and per
+// registry. Validations that cannot start immediately wait in a bounded queue
+// and are rejected with an [errcode.Overloaded] error if the queue is full.
+type inFlightLimiter struct {
+	// global is the semaphore of the global limit, or nil if unlimited.
+	global chan struct{}
+
+	// perRegistry is the default limit per registry and registryLimits
+	// overrides it for specific registries. Zero means unlimited.
+	perRegistry    int
+	registryLimits map[string]int
+
+	mu         sync.Mutex
+	registries map[string]chan struct{}
+
+	maxQueued int64
+	queued    atomic.Int64
+}
+
+// newInFlightLimiter creates a limiter. It returns nil if no limit is set.
+func newInFlightLimiter(maxInFlight, perRegistry int, registryLimits map[string]int, maxQueued int) *inFlightLimiter {
+	if maxInFlight <= 0 && perRegistry <= 0 && len(registryLimits) == 0 {
+		return nil
+	}
+	limiter := &inFlightLimiter{
+		perRegistry:    perRegistry,
+		registryLimits: registryLimits,
+		registries:     make(map[string]chan struct{}),
+		maxQueued:      int64(max(maxQueued, 0)),
+	}
+	if maxInFlight > 0 {
+		limiter.global = make(chan struct{}, maxInFlight)
+	}
+	return limiter
+}
+
+// acquire reserves an in-flight slot for the validation of the artifact. The
+// returned function must be called to release the slot. A nil limiter never
+// blocks.
+func (l *inFlightLimiter) acquire(ctx context.Context, artifact string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	var acquired []chan struct{}
+	release := func() {
+		for _, sem := range acquired {
+			<-sem
+		}
+	}
+	for _, sem := range []chan struct{}{l.registrySemaphore(artifact), l.global} {
+		if sem == nil {
+			continue
+		}
+		if err := l.wait(ctx, sem); err != nil {
+			release()
+			return nil, err
+		}
+		acquired = append(acquired, sem)
+	}
+	return release, nil
+}
+
+// wait acquires the semaphore, waiting in the queue if it is exhausted.
+func (l *inFlightLimiter) wait(ctx context.Context, sem chan struct{}) error {
+	select {
+	case sem <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if l.queued.Add(1) > l.maxQueued {
+		l.queued.Add(-1)
+		return errcode.Overloaded.Errorf("too many validations in flight and %d queued", l.maxQueued)
+	}
+	defer l.queued.Add(-1)
+	select {
+	case sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return errcode.Timeout.Errorf("timed out waiting for an in-flight slot: %w", ctx.Err())
+	}
+}
+
+// registrySemaphore returns the semaphore of the registry of the artifact, or
+// nil if the registry is unlimited.
+func (l *inFlightLimiter) registrySemaphore(artifact string) chan struct{} {
+	ref, err := registry.ParseReference(artifact)
+	if err != nil {
+		return nil
+	}
+	limit, ok := l.registryLimits[ref.Registry]
+	if !ok {
+		limit = l.perRegistry
+	}
+	if limit <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	sem, ok := l.registries[ref.Registry]
+	if !ok {
+		sem = make(chan struct{}, limit)
+		l.registries[ref.Registry] = sem
+	}
+	return sem
+}
@@ -0,0 +1,169 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/ratify/v2/internal/errcode"
+)
+
+// newSaturatedLimiter returns a limiter whose only in-flight slot is taken and
+// whose queue is disabled.
+func newSaturatedLimiter() *inFlightLimiter {
+	limiter := newInFlightLimiter(1, 0, nil, 0)
+	limiter.global <- struct{}{}
+	return limiter
+}
+
+func TestNewInFlightLimiter(t *testing.T) {
+	if limiter := newInFlightLimiter(0, 0, nil, 10); limiter != nil {
+		t.Errorf("expected nil limiter without limits")
+	}
+	var limiter *inFlightLimiter
+	release, err := limiter.acquire(context.Background(), "registry.example.com/test/image:v1")
+	if err != nil {
+		t.Fatalf("expected nil limiter to never fail, got %v", err)
+	}
+	release()
+}
+
+func TestInFlightLimiter_Acquire(t *testing.T) {
+	const (
+		artifactA = "registry-a.example.com/test/image:v1"
+		artifactB = "registry-b.example.com/test/image:v1"
+		artifactC = "registry-c.example.com/test/image:v1"
+	)
+
+	tests := []struct {
+		name         string
+		limiter      func() *inFlightLimiter
+		held         []string
+		artifact     string
+		expectedCode errcode.Code
+	}{
+		{
+			name:     "global slot available",
+			limiter:  func() *inFlightLimiter { return newInFlightLimiter(2, 0, nil, 0) },
+			held:     []string{artifactA},
+			artifact: artifactB,
+		},
+		{
+			name:         "global limit reached without queue",
+			limiter:      func() *inFlightLimiter { return newInFlightLimiter(1, 0, nil, 0) },
+			held:         []string{artifactA},
+			artifact:     artifactB,
+			expectedCode: errcode.Overloaded,
+		},
+		{
+			name:     "other registry not limited",
+			limiter:  func() *inFlightLimiter { return newInFlightLimiter(0, 1, nil, 0) },
+			held:     []string{artifactA},
+			artifact: artifactB,
+		},
+		{
+			name:         "registry limit reached",
+			limiter:      func() *inFlightLimiter { return newInFlightLimiter(0, 1, nil, 0) },
+			held:         []string{artifactA},
+			artifact:     artifactA,
+			expectedCode: errcode.Overloaded,
+		},
+		{
+			name: "registry override",
+			limiter: func() *inFlightLimiter {
+				return newInFlightLimiter(0, 1, map[string]int{"registry-a.example.com": 2}, 0)
+			},
+			held:     []string{artifactA},
+			artifact: artifactA,
+		},
+		{
+			name: "unlimited registry",
+			limiter: func() *inFlightLimiter {
+				return newInFlightLimiter(0, 0, map[string]int{"registry-a.example.com": 1}, 0)
+			},
+			held:     []string{artifactC},
+			artifact: artifactC,
+		},
+		{
+			name:         "queued until deadline",
+			limiter:      func() *inFlightLimiter { return newInFlightLimiter(1, 0, nil, 1) },
+			held:         []string{artifactA},
+			artifact:     artifactB,
+			expectedCode: errcode.Timeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := tt.limiter()
+			for _, artifact := range tt.held {
+				if _, err := limiter.acquire(context.Background(), artifact); err != nil {
+					t.Fatalf("failed to acquire slot for %s: %v", artifact, err)
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			release, err := limiter.acquire(ctx, tt.artifact)
+			if code := errcode.Classify(err); code != tt.expectedCode {
+				t.Fatalf("expected code %q, got %q (%v)", tt.expectedCode, code, err)
+			}
+			if err == nil {
+				release()
+			}
+		})
+	}
+}
+
+func TestInFlightLimiter_Queue(t *testing.T) {
+	limiter := newInFlightLimiter(1, 0, nil, 1)
+	release, err := limiter.acquire(context.Background(), "registry.example.com/test/image:v1")
+	if err != nil {
+		t.Fatalf("failed to acquire slot: %v", err)
+	}
+
+	queued := make(chan error, 1)
+	go func() {
+		release, err := limiter.acquire(context.Background(), "registry.example.com/test/image:v2")
+		if err == nil {
+			release()
+		}
+		queued <- err
+	}()
+	for limiter.queued.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The queue is full, so further validations are rejected immediately.
+	if _, err := limiter.acquire(context.Background(), "registry.example.com/test/image:v3"); errcode.Classify(err) != errcode.Overloaded {
+		t.Errorf("expected overloaded error, got %v", err)
+	}
+
+	release()
+	select {
+	case err := <-queued:
+		if err != nil {
+			t.Errorf("expected queued validation to acquire the released slot, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("queued validation did not acquire the released slot")
+	}
+	if limiter.queued.Load() != 0 {
+		t.Errorf("expected empty queue, got %d", limiter.queued.Load())
+	}
+}
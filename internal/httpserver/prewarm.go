@@ -0,0 +1,201 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/notaryproject/ratify/v2/internal/controller"
+	"github.com/notaryproject/ratify/v2/internal/executor"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultPrewarmConcurrency = 4
+	prewarmPollInterval       = time.Second
+	prewarmPodListTimeout     = time.Minute
+)
+
+// prewarmEnabled reports whether any source of references to pre-warm is
+// configured.
+func (s *server) prewarmEnabled() bool {
+	return s.PrewarmFile != "" || len(s.PrewarmReferences) > 0 || s.PrewarmPodImages
+}
+
+// runPrewarm pre-warms the caches whenever a new executor is loaded, i.e. on
+// startup and after every configuration reload, until ctx is done. A run is
+// canceled once a newer executor is loaded.
+func (s *server) runPrewarm(ctx context.Context) {
+	ticker := time.NewTicker(prewarmPollInterval)
+	defer ticker.Stop()
+
+	var loaded *executor.ScopedExecutor
+	var stopRun context.CancelFunc
+	defer func() {
+		if stopRun != nil {
+			stopRun()
+		}
+	}()
+	for {
+		if current := s.getExecutor(); current != nil && current != loaded {
+			loaded = current
+			if stopRun != nil {
+				stopRun()
+			}
+			runCtx, cancel := context.WithCancel(ctx)
+			stopRun = cancel
+			go s.prewarm(runCtx)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// prewarm validates the references to pre-warm with at most
+// PrewarmConcurrency references in flight, and resolves their tags unless the
+// mutation is disabled, filling the same caches as the verify and mutate
+// handlers.
+func (s *server) prewarm(ctx context.Context) {
+	start := time.Now()
+	references, err := s.prewarmReferences(ctx)
+	if err != nil {
+		// Pre-warm the references that could be collected.
+		logrus.Warnf("failed to collect references to pre-warm: %v", err)
+	}
+	s.prewarmTotal.Store(int64(len(references)))
+	s.prewarmDone.Store(0)
+	s.updatePrewarmReady()
+	logrus.Infof("pre-warming caches with %d references", len(references))
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failed := 0
+	sem := make(chan struct{}, s.PrewarmConcurrency)
+	for _, reference := range references {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			logrus.Infof("pre-warming canceled after %d of %d references", s.prewarmDone.Load(), len(references))
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := s.prewarmReference(ctx, reference); err != nil {
+				logrus.Debugf("failed to pre-warm %s: %v", reference, err)
+				mu.Lock()
+				failed++
+				mu.Unlock()
+			}
+			s.prewarmDone.Add(1)
+			s.updatePrewarmReady()
+		}()
+	}
+	wg.Wait()
+	s.prewarmReady.Store(true)
+	logrus.Infof("pre-warmed caches with %d references in %s, %d failed", len(references), time.Since(start), failed)
+}
+
+// prewarmReference resolves and validates the reference within VerifyTimeout.
+func (s *server) prewarmReference(ctx context.Context, reference string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.VerifyTimeout)
+	defer cancel()
+	var errs []error
+	if !s.DisableMutation {
+		if item := s.resolveReference(ctx, reference); item.Error != "" {
+			errs = append(errs, errors.New(item.Error))
+		}
+	}
+	if item, _ := s.validateArtifact(ctx, reference); item.Error != "" {
+		errs = append(errs, errors.New(item.Error))
+	}
+	return errors.Join(errs...)
+}
+
+// prewarmReferences collects the deduplicated references of all configured
+// sources. The references of the sources that could be read are returned
+// along with the errors of the others.
+func (s *server) prewarmReferences(ctx context.Context) ([]string, error) {
+	references := slices.Clone(s.PrewarmReferences)
+	var errs []error
+	if s.PrewarmFile != "" {
+		fileReferences, err := readPrewarmFile(s.PrewarmFile)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		references = append(references, fileReferences...)
+	}
+	if s.PrewarmPodImages {
+		listCtx, cancel := context.WithTimeout(ctx, prewarmPodListTimeout)
+		images, err := controller.GlobalPodImageLister.ListImages(listCtx)
+		cancel()
+		if err != nil {
+			errs = append(errs, err)
+		}
+		references = append(references, images...)
+	}
+	slices.Sort(references)
+	return slices.Compact(references), errors.Join(errs...)
+}
+
+// readPrewarmFile reads the references of the file, one per line. Blank lines
+// and lines starting with "#" are ignored.
+func readPrewarmFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pre-warm file: %w", err)
+	}
+	defer file.Close()
+
+	var references []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		references = append(references, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read pre-warm file: %w", err)
+	}
+	return references, nil
+}
+
+// updatePrewarmReady marks the pre-warming as ready once PrewarmReadyPercent
+// of the references are processed. The mark is never reset so that reloads do
+// not make a serving server unready.
+func (s *server) updatePrewarmReady() {
+	total := s.prewarmTotal.Load()
+	if total == 0 || s.prewarmDone.Load()*100 >= int64(s.PrewarmReadyPercent)*total {
+		s.prewarmReady.Store(true)
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/ratify/v2/internal/executor"
+)
+
+func TestReadPrewarmFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prewarm.txt")
+	content := "# hot images\nregistry.example.com/app:v1\n\n  registry.example.com/api:v2  \n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	references, err := readPrewarmFile(path)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	expected := []string{"registry.example.com/app:v1", "registry.example.com/api:v2"}
+	if !slices.Equal(references, expected) {
+		t.Errorf("expected %v, got %v", expected, references)
+	}
+
+	if _, err := readPrewarmFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestPrewarmReferences(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prewarm.txt")
+	if err := os.WriteFile(path, []byte("registry.example.com/b:v1\nregistry.example.com/a:v1\n"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	s := &server{
+		ServerOptions: ServerOptions{
+			PrewarmFile:       path,
+			PrewarmReferences: []string{"registry.example.com/a:v1", "registry.example.com/c:v1"},
+		},
+	}
+
+	references, err := s.prewarmReferences(context.Background())
+	if err != nil {
+		t.Fatalf("failed to collect references: %v", err)
+	}
+	expected := []string{"registry.example.com/a:v1", "registry.example.com/b:v1", "registry.example.com/c:v1"}
+	if !slices.Equal(references, expected) {
+		t.Errorf("expected %v, got %v", expected, references)
+	}
+}
+
+func TestPrewarm(t *testing.T) {
+	const signedArtifact = "registry.example.com/test/image:v1"
+	scopedExecutor := newSignedExecutor(t)
+	mutateCache := &mockCache{entries: make(map[string]string)}
+	verifyCache := &mockResultCache{entries: make(map[string]*result)}
+	s := &server{
+		getExecutor: func() *executor.ScopedExecutor {
+			return scopedExecutor
+		},
+		mutateCache: mutateCache,
+		verifyCache: verifyCache,
+		sfGroup:     new(flightGroup),
+		ServerOptions: ServerOptions{
+			VerifyTimeout:       time.Second,
+			PrewarmReferences:   []string{signedArtifact, "unknown.example.org/test/image:v1"},
+			PrewarmConcurrency:  2,
+			PrewarmReadyPercent: 100,
+		},
+	}
+
+	s.prewarm(context.Background())
+
+	if total, done := s.prewarmTotal.Load(), s.prewarmDone.Load(); total != 2 || done != 2 {
+		t.Errorf("expected 2 of 2 references processed, got %d of %d", done, total)
+	}
+	if !s.prewarmReady.Load() {
+		t.Error("expected pre-warming to be ready")
+	}
+	if cached, err := verifyCache.Get(context.Background(), s.verifyCacheKey(signedArtifact, nil)); err != nil || !cached.Succeeded {
+		t.Errorf("expected a succeeded verify cache entry, got %+v, %v", cached, err)
+	}
+	if resolved, err := mutateCache.Get(context.Background(), mutateKey(signedArtifact)); err != nil || resolved == "" {
+		t.Errorf("expected a mutate cache entry, got %q, %v", resolved, err)
+	}
+}
+
+func TestRunPrewarm_ExecutorReload(t *testing.T) {
+	var current atomic.Pointer[executor.ScopedExecutor]
+	current.Store(newSignedExecutor(t))
+	verifyCache := &mockResultCache{entries: make(map[string]*result)}
+	s := &server{
+		getExecutor: current.Load,
+		verifyCache: verifyCache,
+		sfGroup:     new(flightGroup),
+		ServerOptions: ServerOptions{
+			VerifyTimeout:      time.Second,
+			DisableMutation:    true,
+			PrewarmReferences:  []string{"registry.example.com/test/image:v1"},
+			PrewarmConcurrency: 1,
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.runPrewarm(ctx)
+
+	waitForCacheEntries := func() {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			verifyCache.mu.Lock()
+			n := len(verifyCache.entries)
+			verifyCache.mu.Unlock()
+			if n > 0 {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatal("expected the cache to be pre-warmed")
+	}
+	waitForCacheEntries()
+
+	// A reloaded executor triggers another run.
+	verifyCache.mu.Lock()
+	clear(verifyCache.entries)
+	verifyCache.mu.Unlock()
+	current.Store(newSignedExecutor(t))
+	waitForCacheEntries()
+}
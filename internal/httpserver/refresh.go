@@ -0,0 +1,114 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/notaryproject/ratify/v2/internal/cache"
+	"github.com/notaryproject/ratify/v2/internal/logger"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// refreshEnabled reports whether verify cache entries are re-validated ahead
+// of or after their expiry.
+func (s *server) refreshEnabled() bool {
+	return s.VerifyCacheRefreshWindow > 0 || s.VerifyCacheMaxStaleness > 0
+}
+
+// getVerifyCache returns the cached result of key and the remaining time
+// until the entry is evicted. The remaining time is zero if refresh-ahead is
+// disabled or the cache cannot report it.
+func (s *server) getVerifyCache(ctx context.Context, key string) (*result, time.Duration, error) {
+	if s.refreshEnabled() {
+		if ttlReader, ok := s.verifyCache.(cache.TTLReader[*result]); ok {
+			return ttlReader.GetWithTTL(ctx, key)
+		}
+	}
+	result, err := s.verifyCache.Get(ctx, key)
+	return result, 0, err
+}
+
+// getValidationCache returns the cached validation response of key and the
+// remaining time until the entry is evicted like [server.getVerifyCache].
+func (s *server) getValidationCache(ctx context.Context, key string) (*validationResponse, time.Duration, error) {
+	if s.refreshEnabled() {
+		if ttlReader, ok := s.validationCache.(cache.TTLReader[*validationResponse]); ok {
+			return ttlReader.GetWithTTL(ctx, key)
+		}
+	}
+	resp, err := s.validationCache.Get(ctx, key)
+	return resp, 0, err
+}
+
+// verifyCacheTTL returns the duration to keep a verify cache entry with the
+// ttl. Entries are kept for VerifyCacheMaxStaleness after they expire so that
+// they can be served while they are re-validated.
+func (s *server) verifyCacheTTL(ttl time.Duration) time.Duration {
+	if s.VerifyCacheMaxStaleness <= 0 {
+		return ttl
+	}
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	return ttl + s.VerifyCacheMaxStaleness
+}
+
+// needsRefresh reports whether an entry evicted in remaining is within the
+// refresh window before its expiry or already stale.
+func (s *server) needsRefresh(remaining time.Duration) bool {
+	return remaining > 0 && remaining <= s.VerifyCacheMaxStaleness+s.VerifyCacheRefreshWindow
+}
+
+// refreshVerifyCache re-validates the artifact in the background and replaces
+// the verify cache entry of key with the fresh result.
+func (s *server) refreshVerifyCache(ctx context.Context, key, artifact string, platform *ocispec.Platform) {
+	s.refreshCache(ctx, key, artifact, func(ctx context.Context) (any, error) {
+		return s.validateAndCache(ctx, key, artifact, platform, false)
+	})
+}
+
+// refreshValidationCache re-validates the subject of req in the background
+// and replaces the validation cache entry of key with the fresh response.
+func (s *server) refreshValidationCache(ctx context.Context, key string, req validationRequest, platform *ocispec.Platform) {
+	s.refreshCache(ctx, key, req.Subject, func(ctx context.Context) (any, error) {
+		return s.validateAndCacheSubject(ctx, key, &req, platform, false)
+	})
+}
+
+// refreshCache runs validate in the background to replace the cache entry of
+// key. At most one refresh per key runs at a time, and requests missing the
+// cache meanwhile join it through the flight group. Errors keep the cached
+// entry in place until it is evicted.
+func (s *server) refreshCache(ctx context.Context, key, artifact string, validate func(context.Context) (any, error)) {
+	if _, loaded := s.refreshing.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+	// Detach from the admission request, which completes with the cached
+	// result before the refresh does.
+	refreshCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.VerifyTimeout)
+	go func() {
+		defer s.refreshing.Delete(key)
+		defer cancel()
+		_, err, _ := s.sfGroup.Do(refreshCtx, key, validate)
+		if err != nil {
+			logger.GetLogger(refreshCtx, logOpt).Warnf("failed to refresh cached result of %s: %v", artifact, err)
+			return
+		}
+		logger.GetLogger(refreshCtx, logOpt).Debugf("refreshed cached result of %s", artifact)
+	}()
+}
@@ -0,0 +1,279 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/ratify/v2/internal/cache/ristretto"
+	"github.com/notaryproject/ratify/v2/internal/errcode"
+	"github.com/notaryproject/ratify/v2/internal/executor"
+)
+
+func TestNeedsRefresh(t *testing.T) {
+	tests := []struct {
+		name          string
+		refreshWindow time.Duration
+		maxStaleness  time.Duration
+		remaining     time.Duration
+		expected      bool
+	}{
+		{
+			name:      "disabled",
+			remaining: time.Second,
+		},
+		{
+			name:          "outside refresh window",
+			refreshWindow: time.Second,
+			remaining:     2 * time.Second,
+		},
+		{
+			name:          "within refresh window",
+			refreshWindow: time.Second,
+			remaining:     500 * time.Millisecond,
+			expected:      true,
+		},
+		{
+			name:         "stale",
+			maxStaleness: time.Minute,
+			remaining:    30 * time.Second,
+			expected:     true,
+		},
+		{
+			name:          "within refresh window before staleness",
+			refreshWindow: time.Second,
+			maxStaleness:  time.Minute,
+			remaining:     time.Minute + 500*time.Millisecond,
+			expected:      true,
+		},
+		{
+			name:          "no expiry",
+			refreshWindow: time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &server{
+				ServerOptions: ServerOptions{
+					VerifyCacheRefreshWindow: tt.refreshWindow,
+					VerifyCacheMaxStaleness:  tt.maxStaleness,
+				},
+			}
+			if got := s.needsRefresh(tt.remaining); got != tt.expected {
+				t.Errorf("expected %t, got %t", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestVerifyCacheTTL(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxStaleness time.Duration
+		ttl          time.Duration
+		expected     time.Duration
+	}{
+		{
+			name:     "no staleness",
+			ttl:      time.Minute,
+			expected: time.Minute,
+		},
+		{
+			name:         "staleness added",
+			maxStaleness: time.Minute,
+			ttl:          time.Minute,
+			expected:     2 * time.Minute,
+		},
+		{
+			name:         "staleness added to default ttl",
+			maxStaleness: time.Minute,
+			expected:     defaultCacheTTL + time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &server{
+				ServerOptions: ServerOptions{
+					VerifyCacheMaxStaleness: tt.maxStaleness,
+				},
+			}
+			if got := s.verifyCacheTTL(tt.ttl); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestValidateArtifact_Refresh(t *testing.T) {
+	const artifact = "registry.example.com/test/image:v1"
+	staleResult := convertFailure(errcode.RegistryUnavailable.Wrap(errors.New("stale")))
+
+	tests := []struct {
+		name            string
+		refreshWindow   time.Duration
+		executor        *executor.ScopedExecutor
+		expectRefreshed bool
+	}{
+		{
+			name:     "refresh disabled",
+			executor: newSignedExecutor(t),
+		},
+		{
+			name:            "refreshed within window",
+			refreshWindow:   time.Hour,
+			executor:        newSignedExecutor(t),
+			expectRefreshed: true,
+		},
+		{
+			name:          "failed refresh keeps entry",
+			refreshWindow: time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifyCache, err := ristretto.NewCache[*result](time.Minute)
+			if err != nil {
+				t.Fatalf("failed to create cache: %v", err)
+			}
+			s := &server{
+				getExecutor: func() *executor.ScopedExecutor {
+					return tt.executor
+				},
+				verifyCache: verifyCache,
+				sfGroup:     new(flightGroup),
+				ServerOptions: ServerOptions{
+					VerifyTimeout:            time.Second,
+					VerifyCacheRefreshWindow: tt.refreshWindow,
+				},
+			}
+			ctx := context.Background()
+			key := s.verifyCacheKey(artifact, nil)
+			if err := verifyCache.Set(ctx, key, staleResult, time.Minute); err != nil {
+				t.Fatalf("failed to set cache: %v", err)
+			}
+
+			// The cached result is served while it is refreshed.
+			item, cacheHit := s.validateArtifact(ctx, artifact)
+			if !cacheHit || item.Value != staleResult {
+				t.Fatalf("expected the cached result, got %+v", item)
+			}
+
+			deadline := time.Now().Add(2 * time.Second)
+			var refreshed bool
+			for time.Now().Before(deadline) {
+				if cached, err := verifyCache.Get(ctx, key); err == nil && cached != staleResult {
+					refreshed = true
+					if !cached.Succeeded {
+						t.Errorf("expected a succeeded refreshed result, got %+v", cached)
+					}
+					break
+				}
+				if _, running := s.refreshing.Load(key); !running && !tt.expectRefreshed {
+					break
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			if refreshed != tt.expectRefreshed {
+				t.Errorf("expected refreshed %t, got %t", tt.expectRefreshed, refreshed)
+			}
+		})
+	}
+}
+
+func TestValidateSubject_Refresh(t *testing.T) {
+	const subject = "registry.example.com/test/image:v1"
+	staleResp := failedValidation(subject, errcode.RegistryUnavailable.Wrap(errors.New("stale")))
+
+	tests := []struct {
+		name            string
+		refreshWindow   time.Duration
+		executor        *executor.ScopedExecutor
+		expectRefreshed bool
+	}{
+		{
+			name:     "refresh disabled",
+			executor: newSignedExecutor(t),
+		},
+		{
+			name:            "refreshed within window",
+			refreshWindow:   time.Hour,
+			executor:        newSignedExecutor(t),
+			expectRefreshed: true,
+		},
+		{
+			name:          "failed refresh keeps entry",
+			refreshWindow: time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validationCache, err := ristretto.NewCache[*validationResponse](time.Minute)
+			if err != nil {
+				t.Fatalf("failed to create cache: %v", err)
+			}
+			s := &server{
+				getExecutor: func() *executor.ScopedExecutor {
+					return tt.executor
+				},
+				validationCache: validationCache,
+				sfGroup:         new(flightGroup),
+				ServerOptions: ServerOptions{
+					VerifyTimeout:            time.Second,
+					VerifyCacheRefreshWindow: tt.refreshWindow,
+					ResponseSchema:           responseSchemaV2,
+				},
+			}
+			ctx := context.Background()
+			key := validationKey(subject, "", s.configFingerprint(subject), nil)
+			if err := validationCache.Set(ctx, key, staleResp, time.Minute); err != nil {
+				t.Fatalf("failed to set cache: %v", err)
+			}
+
+			// The cached response is served while it is refreshed.
+			item, cacheHit := s.validateArtifact(ctx, subject)
+			resp, ok := item.Value.(*validationResponse)
+			if !cacheHit || !ok || resp.Error == nil || resp.Error.Message != staleResp.Error.Message {
+				t.Fatalf("expected the cached response, got %+v", item)
+			}
+
+			deadline := time.Now().Add(2 * time.Second)
+			var refreshed bool
+			for time.Now().Before(deadline) {
+				if cached, err := validationCache.Get(ctx, key); err == nil && cached != staleResp {
+					refreshed = true
+					if !cached.Succeeded {
+						t.Errorf("expected a succeeded refreshed response, got %+v", cached)
+					}
+					break
+				}
+				if _, running := s.refreshing.Load(key); !running && !tt.expectRefreshed {
+					break
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			if refreshed != tt.expectRefreshed {
+				t.Errorf("expected refreshed %t, got %t", tt.expectRefreshed, refreshed)
+			}
+		})
+	}
+}
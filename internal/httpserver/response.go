@@ -17,17 +17,35 @@ package httpserver
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/errcode"
+	"github.com/notaryproject/ratify/v2/internal/executor"
 	"github.com/sirupsen/logrus"
 )
 
+// errorInfo is a rendered view of an error classified by [errcode.Classify].
+type errorInfo struct {
+	Code        errcode.Code     `json:"code"`
+	Category    errcode.Category `json:"category"`
+	Retryable   bool             `json:"retryable"`
+	Remediation string           `json:"remediation,omitempty"`
+	Message     string           `json:"message"`
+}
+
+// String returns the message prefixed with the error code.
+func (e *errorInfo) String() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
 // verificationResult is a rendered view of [ratify.VerificationResult].
 type verificationResult struct {
-	VerifierName string `json:"verifierName"`
-	Description  string `json:"description,omitempty"`
-	Detail       string `json:"detail,omitempty"`
-	ErrorReason  string `json:"errorReason,omitempty"`
+	VerifierName string     `json:"verifierName"`
+	Description  string     `json:"description,omitempty"`
+	Detail       string     `json:"detail,omitempty"`
+	ErrorReason  string     `json:"errorReason,omitempty"`
+	Error        *errorInfo `json:"error,omitempty"`
 }
 
 // validationReport is a rendered view of [ratify.ValidationReport].
@@ -42,6 +60,17 @@ type validationReport struct {
 type result struct {
 	Succeeded       bool                `json:"succeeded"`
 	ArtifactReports []*validationReport `json:"artifactReports"`
+	Unmatched       *unmatchedDecision  `json:"unmatched,omitempty"`
+	AuditOnly       *auditOnlyOutcome   `json:"auditOnly,omitempty"`
+	Error           *errorInfo          `json:"error,omitempty"`
+}
+
+// auditOnlyOutcome is a rendered view of the outcome of the audit-only
+// executors of a validation.
+type auditOnlyOutcome struct {
+	Scopes          []string   `json:"scopes"`
+	WouldHaveFailed bool       `json:"wouldHaveFailed"`
+	Error           *errorInfo `json:"error,omitempty"`
 }
 
 func convertResult(src *ratify.ValidationResult) *result {
@@ -99,6 +128,7 @@ func convertVerificationResult(src *ratify.VerificationResult) *verificationResu
 	}
 	if src.Err != nil {
 		result.ErrorReason = src.Err.Error()
+		result.Error = convertError(src.Err, errcode.VerificationFailed)
 	}
 	if src.Detail != nil {
 		detail, err := json.Marshal(src.Detail)
@@ -110,3 +140,46 @@ func convertVerificationResult(src *ratify.VerificationResult) *verificationResu
 	}
 	return result
 }
+
+// convertAuditOnly renders the outcome of the audit-only executors of src,
+// or nil if no audit-only executor validated the artifact.
+func convertAuditOnly(src *executor.ValidationResult) *auditOnlyOutcome {
+	if src == nil || len(src.AuditOnlyScopes) == 0 {
+		return nil
+	}
+	outcome := &auditOnlyOutcome{
+		Scopes:          src.AuditOnlyScopes,
+		WouldHaveFailed: src.WouldHaveFailed,
+	}
+	if src.AuditOnlyErr != nil {
+		outcome.Error = convertError(src.AuditOnlyErr, errcode.Unknown)
+	}
+	return outcome
+}
+
+// convertError renders err with its classification. Errors that cannot be
+// classified are reported with the fallback code.
+func convertError(err error, fallback errcode.Code) *errorInfo {
+	if err == nil {
+		return nil
+	}
+	code := errcode.Classify(err)
+	if code == errcode.Unknown {
+		code = fallback
+	}
+	return &errorInfo{
+		Code:        code,
+		Category:    code.Category(),
+		Retryable:   code.Retryable(),
+		Remediation: code.Remediation(),
+		Message:     err.Error(),
+	}
+}
+
+// convertFailure renders a validation that failed before producing a result.
+func convertFailure(err error) *result {
+	return &result{
+		Succeeded: false,
+		Error:     convertError(err, errcode.Unknown),
+	}
+}
@@ -22,6 +22,8 @@ import (
 	"testing"
 
 	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/errcode"
+	"github.com/notaryproject/ratify/v2/internal/executor"
 
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
@@ -49,6 +51,52 @@ func (m *mockVerifier) Verify(_ context.Context, _ *ratify.VerifyOptions) (*rati
 	return &ratify.VerificationResult{}, nil
 }
 
+func TestConvertError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		fallback errcode.Code
+		expected *errorInfo
+	}{
+		{
+			name:     "nil error",
+			err:      nil,
+			expected: nil,
+		},
+		{
+			name:     "classified error",
+			err:      errcode.ScopeNotMatched.Errorf("no executor"),
+			fallback: errcode.Unknown,
+			expected: &errorInfo{
+				Code:        errcode.ScopeNotMatched,
+				Category:    errcode.CategoryConfiguration,
+				Remediation: errcode.ScopeNotMatched.Remediation(),
+				Message:     "no executor",
+			},
+		},
+		{
+			name:     "unclassified error with fallback",
+			err:      errors.New("signature invalid"),
+			fallback: errcode.VerificationFailed,
+			expected: &errorInfo{
+				Code:        errcode.VerificationFailed,
+				Category:    errcode.CategoryVerification,
+				Remediation: errcode.VerificationFailed.Remediation(),
+				Message:     "signature invalid",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			info := convertError(test.err, test.fallback)
+			if !reflect.DeepEqual(info, test.expected) {
+				t.Errorf("Expected error info: %+v, got: %+v", test.expected, info)
+			}
+		})
+	}
+}
+
 func TestConvertResult(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -97,7 +145,13 @@ func TestConvertResult(t *testing.T) {
 							{
 								VerifierName: mockVerifierName,
 								ErrorReason:  "error",
-								Detail:       "{}",
+								Error: &errorInfo{
+									Code:        errcode.VerificationFailed,
+									Category:    errcode.CategoryVerification,
+									Remediation: errcode.VerificationFailed.Remediation(),
+									Message:     "error",
+								},
+								Detail: "{}",
 							},
 						},
 					},
@@ -146,3 +200,45 @@ func TestConvertResult(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertAuditOnly(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      *executor.ValidationResult
+		expected *auditOnlyOutcome
+	}{
+		{
+			name:     "nil source",
+			src:      nil,
+			expected: nil,
+		},
+		{
+			name: "enforced executor",
+			src: &executor.ValidationResult{
+				ValidationResult: &ratify.ValidationResult{Succeeded: true},
+			},
+			expected: nil,
+		},
+		{
+			name: "audit-only executor would have failed",
+			src: &executor.ValidationResult{
+				ValidationResult: &ratify.ValidationResult{Succeeded: true},
+				AuditOnlyScopes:  []string{"registry.example.com/team-a/*"},
+				WouldHaveFailed:  true,
+			},
+			expected: &auditOnlyOutcome{
+				Scopes:          []string{"registry.example.com/team-a/*"},
+				WouldHaveFailed: true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			outcome := convertAuditOnly(test.src)
+			if !reflect.DeepEqual(outcome, test.expected) {
+				t.Errorf("Expected outcome: %v, got: %v", test.expected, outcome)
+			}
+		})
+	}
+}
@@ -18,35 +18,62 @@ package httpserver
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/notaryproject/ratify/v2/internal/audit"
 	"github.com/notaryproject/ratify/v2/internal/cache"
-	"github.com/notaryproject/ratify/v2/internal/cache/ristretto"
 	"github.com/notaryproject/ratify/v2/internal/controller"
 	"github.com/notaryproject/ratify/v2/internal/executor"
 	"github.com/notaryproject/ratify/v2/internal/httpserver/config"
 	"github.com/notaryproject/ratify/v2/internal/httpserver/tlssecret"
+	"github.com/notaryproject/ratify/v2/internal/logger"
+	"github.com/notaryproject/ratify/v2/internal/metrics"
+	"github.com/notaryproject/ratify/v2/internal/tracing"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	goredis "github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/sync/singleflight"
 )
 
 const (
-	serverRootURL        = "/ratify/gatekeeper/v2"
-	verifyPath           = "verify"
-	mutatePath           = "mutate"
-	defaultVerifyTimeout = 5 * time.Second
-	defaultMutateTimeout = 2 * time.Second
-	readTimeout          = 5 * time.Second
-	writeTimeout         = 5 * time.Second
-	idleTimeout          = 60 * time.Second
-	defaultCacheTTL      = 5 * time.Second
+	serverRootURL            = "/ratify/gatekeeper/v2"
+	adminRootURL             = "/ratify/admin/v2"
+	apiRootURL               = "/ratify/v2"
+	verifyPath               = "verify"
+	metricsPath              = "/metrics"
+	mutatePath               = "mutate"
+	cachePurgePath           = "cache/purge"
+	validationsPath          = "validations"
+	defaultVerifyTimeout     = 5 * time.Second
+	defaultMutateTimeout     = 2 * time.Second
+	readTimeout              = 5 * time.Second
+	writeTimeout             = 5 * time.Second
+	idleTimeout              = 60 * time.Second
+	defaultCacheTTL          = 5 * time.Second
+	defaultMaxConcurrentKeys = 8
+	defaultResponseReserve   = 200 * time.Millisecond
+
+	defaultMaxRequestBodyBytes = 2 << 20
+)
+
+// Schemas of the item values returned by the verify handler.
+const (
+	// responseSchemaV1 renders the item value as a [result].
+	responseSchemaV1 = "v1"
+	// responseSchemaV2 renders the item value as a [validationResponse],
+	// which adds the verifier types and durations, the resolved digest, the
+	// cache provenance and the matched scope, and keeps the verifier details
+	// as JSON objects.
+	responseSchemaV2 = "v2"
 )
 
 type server struct {
@@ -54,7 +81,46 @@ type server struct {
 	router      *mux.Router
 	mutateCache cache.Cache[string]
 	verifyCache cache.Cache[*result]
-	sfGroup     *singleflight.Group
+	sfGroup     *flightGroup
+
+	// validationCache caches the responses of the validations API.
+	validationCache cache.Cache[*validationResponse]
+	// defaultPlatform is the parsed DefaultPlatform, or nil if not set.
+	defaultPlatform *ocispec.Platform
+	// limiter limits the in-flight validations, or is nil if unlimited.
+	limiter *inFlightLimiter
+	// metricsHandler serves the Prometheus metrics, or is nil if the metrics
+	// are not served.
+	metricsHandler http.Handler
+	// redisClient is the connection to the Redis server backing the caches,
+	// or nil if all caches are in memory.
+	redisClient *goredis.Client
+	// auditor writes the verification decisions to the audit log, or is nil
+	// if auditing is disabled.
+	auditor *audit.Logger
+	// refreshing holds the verify cache keys being refreshed in the
+	// background.
+	refreshing sync.Map
+	// prewarmTotal and prewarmDone count the references of the current
+	// pre-warm run and those processed so far.
+	prewarmTotal atomic.Int64
+	prewarmDone  atomic.Int64
+	// prewarmReady is set once the first pre-warm run processed
+	// PrewarmReadyPercent of its references.
+	prewarmReady atomic.Bool
+
+	// getReloadErr returns the error of the last executor reload, if any.
+	getReloadErr func() error
+	// certRotatorSignalled is set once CertRotatorReady is closed.
+	certRotatorSignalled atomic.Bool
+	// tlsCertsLoaded is set once the TLS secret watcher has loaded the
+	// certificates.
+	tlsCertsLoaded atomic.Bool
+	// certWatcher is the TLS secret watcher, or nil if TLS is disabled or the
+	// certificates are not loaded yet.
+	certWatcher atomic.Pointer[tlssecret.Watcher]
+	// draining is set once the server received a shutdown signal.
+	draining atomic.Bool
 	ServerOptions
 }
 
@@ -76,20 +142,188 @@ type ServerOptions struct {
 	// Optional.
 	KeyFile string
 
+	// HealthProbeAddress is the address where the liveness and readiness
+	// endpoints are served over plain HTTP, e.g. ":9090". The endpoints are
+	// always served on HTTPServerAddress as well, but probes cannot reach them
+	// there when client certificates are required.
+	// Optional.
+	HealthProbeAddress string
+
+	// MetricsAddress is the address where the Prometheus metrics are served
+	// over plain HTTP at /metrics, e.g. ":8888". Metrics are disabled if not
+	// provided.
+	// Optional.
+	MetricsAddress string
+
+	// Tracing configures the export of OpenTelemetry traces. Tracing is
+	// disabled if no exporter is provided.
+	// Optional.
+	Tracing tracing.Options
+
+	// Audit configures the sinks of the audit log, which records every
+	// verification decision returned to Gatekeeper, including cache hits.
+	// Auditing is disabled if no sink is configured.
+	// Optional.
+	Audit audit.Options
+
 	// GatekeeperCACertFile is the path to the Gatekeeper CA certificate file.
 	// Optional.
 	GatekeeperCACertFile string
 
+	// ClientAllowlistFile is the path to the JSON file of the client
+	// certificate identities, i.e. subject common names, DNS SANs and SPIFFE
+	// IDs, authorized to call the server. Clients not in the allowlist are
+	// rejected during the TLS handshake. The file is reloaded on change.
+	// Requires GatekeeperCACertFile. All verified clients are accepted if not
+	// specified.
+	// Optional.
+	ClientAllowlistFile string
+
 	// VerifyTimeout is the duration to wait for a verification request to
 	// complete before timing out. Default is 5 seconds if not specified.
 	// Optional.
 	VerifyTimeout time.Duration
 
+	// DrainGracePeriod is the duration the server keeps serving requests
+	// while reporting not ready after receiving a shutdown signal, so that
+	// load balancers stop routing new requests to it before it drains. The
+	// server drains immediately if not specified.
+	// Optional.
+	DrainGracePeriod time.Duration
+
+	// DrainTimeout is the maximum duration to wait for in-flight requests to
+	// complete after the grace period. Default is VerifyTimeout if not
+	// specified.
+	// Optional.
+	DrainTimeout time.Duration
+
 	// MutateTimeout is the duration to wait for a mutation request to
 	// complete before timing out. Default is 2 seconds if not specified.
 	// Optional.
 	MutateTimeout time.Duration
 
+	// MaxConcurrentKeys is the maximum number of keys validated or resolved
+	// concurrently within a single request. Default is 8 if not specified.
+	// Optional.
+	MaxConcurrentKeys int
+
+	// ResponseReserve is the duration reserved before the request deadline to
+	// write the response. Keys that are not processed by then are reported
+	// with a retryable timeout error. Default is 200 milliseconds if not
+	// specified.
+	// Optional.
+	ResponseReserve time.Duration
+
+	// MaxRequestBodyBytes is the maximum size of a request body. Larger
+	// requests are rejected with 413. Default is 2 MiB if not specified.
+	// Optional.
+	MaxRequestBodyBytes int64
+
+	// MaxKeys is the maximum number of keys in a verify or mutate request.
+	// Requests with more keys are rejected with 413. Unlimited if not
+	// specified.
+	// Optional.
+	MaxKeys int
+
+	// MaxInFlight is the maximum number of validations and resolutions
+	// running concurrently across all requests. Cache hits and requests
+	// sharing an in-flight validation are not counted. Unlimited if not
+	// specified.
+	// Optional.
+	MaxInFlight int
+
+	// MaxInFlightPerRegistry is the maximum number of validations and
+	// resolutions running concurrently against a single registry. Unlimited
+	// if not specified.
+	// Optional.
+	MaxInFlightPerRegistry int
+
+	// RegistryInFlightLimits overrides MaxInFlightPerRegistry for specific
+	// registries, e.g. {"docker.io": 4}.
+	// Optional.
+	RegistryInFlightLimits map[string]int
+
+	// MaxQueued is the maximum number of validations and resolutions waiting
+	// for an in-flight slot. Validations beyond it are rejected with a
+	// retryable OVERLOADED error instead of waiting until the request times
+	// out. Validations never wait if not specified.
+	// Optional.
+	MaxQueued int
+
+	// Cache configures the backends of the verify, mutate and registry
+	// credential caches.
+	// Optional. Defaults to in-memory caches.
+	Cache CacheOptions
+
+	// DefaultPlatform is the platform in the format "os/arch[/variant]" used
+	// if a verify or mutate key has no platform hint, e.g. "linux/amd64". The
+	// mutation handler resolves tags of image indexes to the manifest of the
+	// platform, and executors in the platform index mode validate it. Tags are
+	// resolved to the digest of the index if not set.
+	// Optional.
+	DefaultPlatform string
+
+	// ResponseSchema is the schema of the item values returned by the verify
+	// handler, either "v1" or "v2". The v2 schema adds the verifier types and
+	// durations, the resolved digest, the cache provenance and the matched
+	// scope, and renders the verifier details as JSON objects instead of
+	// strings. Default is "v1" if not specified.
+	// Optional.
+	ResponseSchema string
+
+	// UnmatchedScope is the decision for artifacts that do not match the
+	// scope of any executor, one of "error", "deny" or "allow". "error" fails
+	// the validation with a SCOPE_NOT_MATCHED error, "deny" reports the
+	// artifact as not succeeded and "allow" reports it as succeeded without
+	// validating it. The applied decision is recorded in the result. Default
+	// is "error" if not specified.
+	// Optional.
+	UnmatchedScope string
+
+	// VerifyCacheRefreshWindow is the duration before the expiry of a verify
+	// cache entry within which a read serves the cached result and triggers a
+	// re-validation in the background, so that hot entries are refreshed
+	// before they expire. The cached responses of the validations API, which
+	// also back the v2 response schema, are refreshed alike. Refresh-ahead is
+	// disabled if not specified.
+	// Optional.
+	VerifyCacheRefreshWindow time.Duration
+
+	// VerifyCacheMaxStaleness is the duration after the expiry of a verify
+	// cache entry within which the expired result is still served while the
+	// artifact is re-validated in the background. Expired results are never
+	// served if not specified.
+	// Optional.
+	VerifyCacheMaxStaleness time.Duration
+
+	// PrewarmFile is the path to a file listing artifact references, one per
+	// line, that are validated in the background whenever an executor is
+	// loaded so that the verify and mutate caches are warm before admission
+	// requests arrive. The file is read on every pre-warm run.
+	// Optional.
+	PrewarmFile string
+
+	// PrewarmReferences are artifact references pre-warmed along with those
+	// of PrewarmFile.
+	// Optional.
+	PrewarmReferences []string
+
+	// PrewarmPodImages enables pre-warming the images of the pending and
+	// running Pods of the cluster, discovered through the manager.
+	// Optional.
+	PrewarmPodImages bool
+
+	// PrewarmConcurrency is the number of references pre-warmed
+	// concurrently. Default is 4 if not specified.
+	// Optional.
+	PrewarmConcurrency int
+
+	// PrewarmReadyPercent is the percentage of references, between 0 and 100,
+	// the initial pre-warm run must process before the server reports ready.
+	// Readiness does not wait for pre-warming if not specified.
+	// Optional.
+	PrewarmReadyPercent int
+
 	// DisableMutation indicates whether to disable the mutation handler.
 	// If set to true, the mutation handler will not be registered.
 	// Optional.
@@ -101,6 +335,12 @@ type ServerOptions struct {
 	// Optional.
 	DisableCRDManager bool
 
+	// AdminTokenFile is the path to a file containing the bearer token required
+	// to call the admin endpoints. The admin endpoints are disabled if not
+	// provided.
+	// Optional.
+	AdminTokenFile string
+
 	// CertRotatorReady is a channel that signals when the certificate rotator
 	// is ready. If not provided, the server will run without rotating the TLS
 	// certificates.
@@ -111,47 +351,66 @@ type ServerOptions struct {
 // StartServer initializes and starts the Ratify server with provided options
 // and configuration file path.
 func StartServer(opts *ServerOptions, executorConfigPath string) error {
+	shutdownTracing, err := tracing.Init(context.Background(), opts.Tracing)
+	if err != nil {
+		logrus.Errorf("Failed to initialize tracing: %v", err)
+		return err
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logrus.Errorf("Failed to shutdown tracing: %v", err)
+		}
+	}()
+
+	// The metrics are initialized before the executor is loaded so that the
+	// fetching of its trust material is reported.
+	var metricsHandler http.Handler
+	if opts.MetricsAddress != "" {
+		if metricsHandler, err = metrics.NewPrometheusHandler(); err != nil {
+			logrus.Errorf("Failed to initialize metrics: %v", err)
+			return err
+		}
+	}
+
 	server, configWatcher, err := newServer(opts, executorConfigPath)
 	if err != nil {
 		logrus.Errorf("Failed to create server: %v", err)
 		return err
 	}
+	server.metricsHandler = metricsHandler
 
 	logrus.Infof("Starting server at port: %s", opts.HTTPServerAddress)
 	return server.Run(opts.CertRotatorReady, configWatcher)
 }
 
-func newServer(serverOpts *ServerOptions, executorConfigPath string) (*server, *config.Watcher, error) {
-	var configWatcher *config.Watcher
-	var getExecutorFunc func() *executor.ScopedExecutor
-	var err error
+func newServer(serverOpts *ServerOptions, executorConfigPath string) (_ *server, _ *config.Watcher, err error) {
+	server := &server{
+		router:        mux.NewRouter(),
+		sfGroup:       new(flightGroup),
+		ServerOptions: *serverOpts,
+	}
+	// The caches are set up before the executor is loaded so that its
+	// credential providers use the shared credential cache.
+	if err := server.setupCaches(); err != nil {
+		return nil, nil, fmt.Errorf("failed to set up caches: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			server.closeCaches()
+		}
+	}()
 
-	if serverOpts.DisableCRDManager {
+	var configWatcher *config.Watcher
+	if server.DisableCRDManager {
 		configWatcher, err = config.NewWatcher(executorConfigPath)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to create config watcher: %w", err)
 		}
-		getExecutorFunc = configWatcher.GetExecutor
+		server.getExecutor = configWatcher.GetExecutor
+		server.getReloadErr = configWatcher.Err
 	} else {
-		getExecutorFunc = controller.GlobalExecutorManager.GetExecutor
-	}
-
-	mutateCache, err := ristretto.NewCache[string](defaultCacheTTL)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create mutate cache: %w", err)
-	}
-	verifyCache, err := ristretto.NewCache[*result](defaultCacheTTL)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create verify cache: %w", err)
-	}
-
-	server := &server{
-		router:        mux.NewRouter(),
-		mutateCache:   mutateCache,
-		verifyCache:   verifyCache,
-		sfGroup:       new(singleflight.Group),
-		getExecutor:   getExecutorFunc,
-		ServerOptions: *serverOpts,
+		server.getExecutor = controller.GlobalExecutorManager.GetExecutor
+		server.getReloadErr = controller.GlobalExecutorManager.Err
 	}
 	if server.VerifyTimeout == 0 {
 		server.VerifyTimeout = defaultVerifyTimeout
@@ -159,10 +418,57 @@ func newServer(serverOpts *ServerOptions, executorConfigPath string) (*server, *
 	if server.MutateTimeout == 0 {
 		server.MutateTimeout = defaultMutateTimeout
 	}
+	if server.DrainTimeout <= 0 {
+		server.DrainTimeout = server.VerifyTimeout
+	}
+	if server.MaxConcurrentKeys <= 0 {
+		server.MaxConcurrentKeys = defaultMaxConcurrentKeys
+	}
+	if server.ResponseReserve == 0 {
+		server.ResponseReserve = defaultResponseReserve
+	}
+	if server.MaxRequestBodyBytes <= 0 {
+		server.MaxRequestBodyBytes = defaultMaxRequestBodyBytes
+	}
+	if server.VerifyCacheRefreshWindow < 0 || server.VerifyCacheMaxStaleness < 0 {
+		return nil, nil, errors.New("verify cache refresh window and max staleness must not be negative")
+	}
+	if _, ok := server.verifyCache.(cache.TTLReader[*result]); server.refreshEnabled() && !ok {
+		return nil, nil, errors.New("verify cache does not support refresh-ahead")
+	}
+	if server.PrewarmReadyPercent < 0 || server.PrewarmReadyPercent > 100 {
+		return nil, nil, fmt.Errorf("invalid pre-warm ready percent %d: must be between 0 and 100", server.PrewarmReadyPercent)
+	}
+	if server.PrewarmConcurrency <= 0 {
+		server.PrewarmConcurrency = defaultPrewarmConcurrency
+	}
+	server.limiter = newInFlightLimiter(server.MaxInFlight, server.MaxInFlightPerRegistry, server.RegistryInFlightLimits, server.MaxQueued)
+	switch server.ResponseSchema {
+	case "":
+		server.ResponseSchema = responseSchemaV1
+	case responseSchemaV1, responseSchemaV2:
+	default:
+		return nil, nil, fmt.Errorf("unsupported response schema %q, must be %s or %s", server.ResponseSchema, responseSchemaV1, responseSchemaV2)
+	}
+	switch server.UnmatchedScope {
+	case "":
+		server.UnmatchedScope = unmatchedScopeError
+	case unmatchedScopeError, unmatchedScopeDeny, unmatchedScopeAllow:
+	default:
+		return nil, nil, fmt.Errorf("unsupported unmatched scope decision %q, must be %s, %s or %s", server.UnmatchedScope, unmatchedScopeError, unmatchedScopeDeny, unmatchedScopeAllow)
+	}
+	if server.DefaultPlatform != "" {
+		if server.defaultPlatform, err = executor.ParsePlatform(server.DefaultPlatform); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse default platform: %w", err)
+		}
+	}
 
 	if err := server.registerHandlers(); err != nil {
 		return nil, nil, fmt.Errorf("failed to register handlers: %w", err)
 	}
+	if server.auditor, err = audit.New(server.Audit); err != nil {
+		return nil, nil, fmt.Errorf("failed to create audit logger: %w", err)
+	}
 	return server, configWatcher, nil
 }
 
@@ -171,11 +477,36 @@ func (s *server) registerHandlers() error {
 		return err
 	}
 
+	if err := s.registerValidationsHandler(); err != nil {
+		return err
+	}
+
 	if !s.DisableMutation {
 		if err := s.registerMutateHandler(); err != nil {
 			return err
 		}
 	}
+
+	if s.AdminTokenFile != "" {
+		if err := s.registerAdminHandler(); err != nil {
+			return err
+		}
+	}
+
+	s.registerHealthHandlers(s.router)
+	return nil
+}
+
+func (s *server) tlsEnabled() bool {
+	return s.CertFile != "" && s.KeyFile != ""
+}
+
+func (s *server) registerAdminHandler() error {
+	purgeURL, err := url.JoinPath(adminRootURL, cachePurgePath)
+	if err != nil {
+		return err
+	}
+	s.router.Methods(http.MethodPost).Path(purgeURL).Handler(s.purgeCacheHandler())
 	return nil
 }
 
@@ -185,7 +516,7 @@ func (s *server) registerMutateHandler() error {
 	if err != nil {
 		return err
 	}
-	s.router.Methods(http.MethodPost).PathPrefix(mutateURL).Handler(middlewareWithTimeout(s.mutateHandler(), s.MutateTimeout))
+	s.router.Methods(http.MethodPost).PathPrefix(mutateURL).Handler(tracing.Middleware("mutate", middlewareWithLogContext(middlewareWithTimeout(s.mutateHandler(), s.MutateTimeout))))
 	return nil
 }
 
@@ -194,22 +525,65 @@ func (s *server) registerVerifyHandler() error {
 	if err != nil {
 		return err
 	}
-	s.router.Methods(http.MethodPost).Path(verifyURL).Handler(middlewareWithTimeout(s.verifyHandler(), s.VerifyTimeout))
+	s.router.Methods(http.MethodPost).Path(verifyURL).Handler(tracing.Middleware("verify", middlewareWithLogContext(middlewareWithTimeout(s.verifyHandler(), s.VerifyTimeout))))
+	return nil
+}
+
+func (s *server) registerValidationsHandler() error {
+	validationsURL, err := url.JoinPath(apiRootURL, validationsPath)
+	if err != nil {
+		return err
+	}
+	s.router.Methods(http.MethodPost).Path(validationsURL).Handler(tracing.Middleware("validations", middlewareWithLogContext(middlewareWithTimeout(s.validationsHandler(), s.VerifyTimeout))))
 	return nil
 }
 
 func (s *server) verifyHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		_ = s.verify(r.Context(), w, r)
+		if err := s.verify(r.Context(), w, r); err != nil {
+			metrics.ReportSystemError(r.Context(), err.Error())
+			logger.GetLogger(r.Context(), logOpt).Errorf("failed to handle verify request: %v", err)
+		}
 	}
 }
 
 func (s *server) mutateHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		_ = s.mutate(r.Context(), w, r)
+		if err := s.mutate(r.Context(), w, r); err != nil {
+			metrics.ReportSystemError(r.Context(), err.Error())
+			logger.GetLogger(r.Context(), logOpt).Errorf("failed to handle mutate request: %v", err)
+		}
+	}
+}
+
+func (s *server) validationsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := s.validations(r.Context(), w, r); err != nil {
+			metrics.ReportSystemError(r.Context(), err.Error())
+			logger.GetLogger(r.Context(), logOpt).Errorf("failed to handle validations request: %v", err)
+		}
 	}
 }
 
+func (s *server) purgeCacheHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := s.purgeCache(r.Context(), w, r); err != nil {
+			logrus.Warnf("failed to handle cache purge request: %v", err)
+		}
+	}
+}
+
+// middlewareWithLogContext sets up the request context of the logger so that
+// all log lines of a request carry the same trace ID. The trace ID is also
+// returned in the configured trace ID response headers.
+func middlewareWithLogContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := logger.InitContext(r.Context(), r)
+		logger.SetTraceIDHeader(ctx, w.Header())
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 func middlewareWithTimeout(next http.Handler, timeout time.Duration) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx, cancel := context.WithTimeout(r.Context(), timeout)
@@ -219,7 +593,8 @@ func middlewareWithTimeout(next http.Handler, timeout time.Duration) http.Handle
 }
 
 // Run starts the HTTP server and listens for incoming requests.
-// It also handles graceful shutdown on receiving an interrupt signal.
+// It reloads the configuration on SIGHUP and drains gracefully on receiving
+// an interrupt signal or SIGTERM.
 func (s *server) Run(certRotatorReady chan struct{}, configWatcher *config.Watcher) error {
 	srv := &http.Server{
 		Addr:         s.HTTPServerAddress,
@@ -228,6 +603,19 @@ func (s *server) Run(certRotatorReady chan struct{}, configWatcher *config.Watch
 		ReadTimeout:  readTimeout,
 		IdleTimeout:  idleTimeout,
 	}
+
+	var auxServers []*http.Server
+	if s.HealthProbeAddress != "" {
+		probeRouter := mux.NewRouter()
+		s.registerHealthHandlers(probeRouter)
+		auxServers = append(auxServers, startAuxServer("health probe", s.HealthProbeAddress, probeRouter))
+	}
+	if s.metricsHandler != nil {
+		metricsRouter := mux.NewRouter()
+		metricsRouter.Methods(http.MethodGet).Path(metricsPath).Handler(s.metricsHandler)
+		auxServers = append(auxServers, startAuxServer("metrics", s.MetricsAddress, metricsRouter))
+	}
+
 	go func() {
 		// Start the configuration watcher (if any) and ensure
 		// it is properly stopped when the server goroutine exits.
@@ -239,14 +627,15 @@ func (s *server) Run(certRotatorReady chan struct{}, configWatcher *config.Watch
 			defer configWatcher.Stop()
 		}
 
-		if s.CertFile != "" && s.KeyFile != "" {
+		if s.tlsEnabled() {
 			logrus.Infof("starting server with TLS at %s", s.HTTPServerAddress)
 			if certRotatorReady != nil {
 				<-certRotatorReady
+				s.certRotatorSignalled.Store(true)
 				logrus.Infof("cert rotator is ready")
 			}
 
-			certWatcher, err := tlssecret.NewWatcher(s.GatekeeperCACertFile, s.CertFile, s.KeyFile)
+			certWatcher, err := tlssecret.NewWatcher(s.GatekeeperCACertFile, s.CertFile, s.KeyFile, s.ClientAllowlistFile)
 			if err != nil {
 				logrus.Errorf("failed to create TLS secret watcher: %v", err)
 				return
@@ -256,6 +645,8 @@ func (s *server) Run(certRotatorReady chan struct{}, configWatcher *config.Watch
 				return
 			}
 			defer certWatcher.Stop()
+			s.certWatcher.Store(certWatcher)
+			s.tlsCertsLoaded.Store(true)
 
 			// Use GetConfigForClient to dynamically load certificates.
 			srv.TLSConfig = &tls.Config{
@@ -273,16 +664,93 @@ func (s *server) Run(certRotatorReady chan struct{}, configWatcher *config.Watch
 		}
 	}()
 
-	// Handle graceful shutdown.
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
-	<-quit
+	prewarmCtx, stopPrewarm := context.WithCancel(context.Background())
+	defer stopPrewarm()
+	if s.prewarmEnabled() {
+		go s.runPrewarm(prewarmCtx)
+	}
+
+	// Reload on SIGHUP and shut down gracefully on interrupt or SIGTERM.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+	for sig := range signals {
+		if sig != syscall.SIGHUP {
+			logrus.Infof("received %s, shutting down", sig)
+			break
+		}
+		s.reload(configWatcher)
+	}
+	stopPrewarm()
+	return s.drain(srv, auxServers)
+}
+
+// reload reloads the executor configuration file, if executors are not
+// managed by the Executor resources, and the TLS material. Failures are
+// logged and the previously loaded configuration stays in use.
+func (s *server) reload(configWatcher *config.Watcher) {
+	logrus.Info("received SIGHUP, reloading configuration")
+	if configWatcher != nil {
+		if err := configWatcher.Reload(); err != nil {
+			logrus.Errorf("failed to reload executor configuration: %v", err)
+		} else {
+			logrus.Info("reloaded executor configuration")
+		}
+	}
+	if certWatcher := s.certWatcher.Load(); certWatcher != nil {
+		if err := certWatcher.Reload(); err != nil {
+			logrus.Errorf("failed to reload TLS certificates: %v", err)
+		} else {
+			logrus.Info("reloaded TLS certificates")
+		}
+	}
+}
+
+// drain marks the server as not ready, waits for DrainGracePeriod so that no
+// new requests are routed to the server, and then shuts down the server
+// within DrainTimeout. The auxiliary servers are shut down last so that the
+// readiness probe keeps reporting not ready while requests drain.
+func (s *server) drain(srv *http.Server, auxServers []*http.Server) error {
+	s.draining.Store(true)
+	if s.DrainGracePeriod > 0 {
+		logrus.Infof("marked server as not ready, draining in %s", s.DrainGracePeriod)
+		time.Sleep(s.DrainGracePeriod)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.VerifyTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), s.DrainTimeout)
 	defer cancel()
-	if err := srv.Shutdown(ctx); err != nil {
+	err := srv.Shutdown(ctx)
+	if err != nil {
 		logrus.Errorf("failed to shutdown server: %v", err)
-		return err
 	}
-	return nil
+	if auditErr := s.auditor.Close(ctx); auditErr != nil {
+		logrus.Errorf("failed to close audit logger: %v", auditErr)
+	}
+	s.closeCaches()
+	for _, auxSrv := range auxServers {
+		if auxErr := auxSrv.Shutdown(ctx); auxErr != nil {
+			logrus.Errorf("failed to shutdown server at %s: %v", auxSrv.Addr, auxErr)
+		}
+	}
+	return err
+}
+
+// startAuxServer starts a plain HTTP server for auxiliary endpoints such as
+// health probes and metrics, which must be reachable without client
+// certificates.
+func startAuxServer(name, addr string, handler http.Handler) *http.Server {
+	srv := &http.Server{
+		Addr:         addr,
+		Handler:      handler,
+		WriteTimeout: writeTimeout,
+		ReadTimeout:  readTimeout,
+		IdleTimeout:  idleTimeout,
+	}
+	go func() {
+		logrus.Infof("starting %s server at %s", name, addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logrus.Errorf("failed to start %s server: %v", name, err)
+		}
+	}()
+	return srv
 }
@@ -26,6 +26,8 @@ import (
 	"encoding/pem"
 	"fmt"
 	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"syscall"
@@ -33,7 +35,9 @@ import (
 	"time"
 
 	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/audit"
 	"github.com/notaryproject/ratify/v2/internal/executor"
+	"github.com/notaryproject/ratify/v2/internal/logger"
 	"github.com/notaryproject/ratify/v2/internal/store"
 	"github.com/notaryproject/ratify/v2/internal/verifier"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
@@ -116,6 +120,64 @@ func TestStartServer(t *testing.T) {
 			configPath:    filepath.Join(tempDir, "config.json"),
 			expectedError: true,
 		},
+		{
+			name: "Unsupported response schema",
+			serverOpts: &ServerOptions{
+				ResponseSchema: "v3",
+			},
+			executorOpts:  &executor.Options{},
+			configPath:    filepath.Join(tempDir, "config.json"),
+			expectedError: true,
+		},
+		{
+			name: "Unsupported unmatched scope decision",
+			serverOpts: &ServerOptions{
+				UnmatchedScope: "ignore",
+			},
+			executorOpts:  &executor.Options{},
+			configPath:    filepath.Join(tempDir, "config.json"),
+			expectedError: true,
+		},
+		{
+			name: "Negative verify cache max staleness",
+			serverOpts: &ServerOptions{
+				VerifyCacheMaxStaleness: -time.Second,
+			},
+			executorOpts:  &executor.Options{},
+			configPath:    filepath.Join(tempDir, "config.json"),
+			expectedError: true,
+		},
+		{
+			name: "Invalid cache backend",
+			serverOpts: &ServerOptions{
+				Cache: CacheOptions{
+					VerifyBackend: "memcached",
+				},
+			},
+			executorOpts:  &executor.Options{},
+			configPath:    filepath.Join(tempDir, "config.json"),
+			expectedError: true,
+		},
+		{
+			name: "Invalid pre-warm ready percent",
+			serverOpts: &ServerOptions{
+				PrewarmReadyPercent: 101,
+			},
+			executorOpts:  &executor.Options{},
+			configPath:    filepath.Join(tempDir, "config.json"),
+			expectedError: true,
+		},
+		{
+			name: "Invalid audit webhook URL",
+			serverOpts: &ServerOptions{
+				Audit: audit.Options{
+					WebhookURL: "ftp://audit.example.com",
+				},
+			},
+			executorOpts:  &executor.Options{},
+			configPath:    filepath.Join(tempDir, "config.json"),
+			expectedError: true,
+		},
 	}
 
 	for _, test := range tests {
@@ -245,6 +307,81 @@ func TestStartServer_TLSEnabled(t *testing.T) {
 	}
 }
 
+func TestStartServer_ReloadAndDrain(t *testing.T) {
+	tempDir := t.TempDir()
+
+	executorOpts := &executor.Options{
+		Executors: []*executor.ScopedOptions{
+			{
+				Scopes: []string{registryPattern},
+				Verifiers: []*verifier.NewOptions{
+					{
+						Name: mockVerifierName,
+						Type: mockVerifierType,
+					},
+				},
+				Stores: []*store.NewOptions{
+					{
+						Type: mockStoreType,
+					},
+				},
+			},
+		},
+	}
+
+	raw, err := json.Marshal(executorOpts)
+	if err != nil {
+		t.Fatalf("failed to marshal executor options: %v", err)
+	}
+	configPath := filepath.Join(tempDir, "config.json")
+	if err := os.WriteFile(configPath, raw, 0600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	serverOpts := &ServerOptions{
+		HTTPServerAddress:  ":8080",
+		HealthProbeAddress: "localhost:9093",
+		DisableCRDManager:  true,
+		DrainGracePeriod:   time.Second,
+	}
+
+	errChan := make(chan error)
+	go func() {
+		errChan <- StartServer(serverOpts, configPath)
+	}()
+
+	time.Sleep(1 * time.Second)
+	p, _ := os.FindProcess(os.Getpid())
+	if err = p.Signal(syscall.SIGHUP); err != nil {
+		t.Fatalf("failed to send SIGHUP: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if statusCode := getStatusCode(t, "http://localhost:9093"+readyzPath); statusCode != http.StatusOK {
+		t.Fatalf("expected the server to stay ready after SIGHUP, got %d", statusCode)
+	}
+
+	if err = p.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("failed to send SIGTERM: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if statusCode := getStatusCode(t, "http://localhost:9093"+readyzPath); statusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected the server to report not ready while draining, got %d", statusCode)
+	}
+
+	if err := <-errChan; err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+}
+
+func getStatusCode(t *testing.T, url string) int {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("failed to get %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode
+}
+
 func TestStartServer_InvalidTLS(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -357,3 +494,16 @@ func createTLSCertAndKey(dir string) (string, string, error) {
 
 	return certPath, keyPath, nil
 }
+
+func TestMiddlewareWithLogContext(t *testing.T) {
+	var traceID string
+	handler := middlewareWithLogContext(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		traceID = logger.GetTraceID(r.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/ratify/gatekeeper/v2/verify", nil))
+	if traceID == "" {
+		t.Fatalf("expected the request context to carry a trace ID")
+	}
+}
@@ -18,6 +18,7 @@ package tlssecret
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"os"
 	"sync/atomic"
@@ -31,17 +32,25 @@ type Watcher struct {
 	watcher             *fsnotify.Watcher
 	ratifyServerTLSCert atomic.Pointer[tls.Certificate]
 	clientCAs           atomic.Pointer[x509.CertPool]
+	clientIdentities    atomic.Pointer[ClientIdentities]
 
 	gatekeeperCACertPath    string
 	ratifyServerTLSCertPath string
 	ratifyServerTLSKeyPath  string
+	clientAllowlistPath     string
 }
 
-// NewWatcher creates a new TLS secret watcher.
-func NewWatcher(gatekeeperCACertPath, ratifyServerTLSCertPath, ratifyServerTLSKeyPath string) (*Watcher, error) {
+// NewWatcher creates a new TLS secret watcher. If clientAllowlistPath is set,
+// only clients whose certificate identity is in the [ClientIdentities]
+// allowlist of the file are accepted. The allowlist requires the Gatekeeper
+// CA cert to verify the client certificates.
+func NewWatcher(gatekeeperCACertPath, ratifyServerTLSCertPath, ratifyServerTLSKeyPath, clientAllowlistPath string) (*Watcher, error) {
 	if ratifyServerTLSCertPath == "" || ratifyServerTLSKeyPath == "" {
 		return nil, fmt.Errorf("ratify server TLS cert and key paths must be set")
 	}
+	if clientAllowlistPath != "" && gatekeeperCACertPath == "" {
+		return nil, fmt.Errorf("gatekeeper CA cert path must be set to authorize client identities")
+	}
 
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
@@ -53,23 +62,31 @@ func NewWatcher(gatekeeperCACertPath, ratifyServerTLSCertPath, ratifyServerTLSKe
 		gatekeeperCACertPath:    gatekeeperCACertPath,
 		ratifyServerTLSCertPath: ratifyServerTLSCertPath,
 		ratifyServerTLSKeyPath:  ratifyServerTLSKeyPath,
+		clientAllowlistPath:     clientAllowlistPath,
 	}
 
 	if err = tlsWatcher.loadCerts(); err != nil {
 		return nil, fmt.Errorf("failed to initialize TLS certs: %w", err)
 	}
+	if err = tlsWatcher.loadClientIdentities(); err != nil {
+		return nil, fmt.Errorf("failed to initialize client identity allowlist: %w", err)
+	}
 
 	return tlsWatcher, nil
 }
 
 // Start begins watching the specified files for changes.
 // It adds the ratify server TLS cert and key files to the watcher.
-// If a gatekeeper CA cert path is provided, it adds that file as well.
+// If a gatekeeper CA cert path or a client allowlist path is provided, it
+// adds those files as well.
 func (w *Watcher) Start() error {
 	files := []string{w.ratifyServerTLSCertPath, w.ratifyServerTLSKeyPath}
 	if w.gatekeeperCACertPath != "" {
 		files = append(files, w.gatekeeperCACertPath)
 	}
+	if w.clientAllowlistPath != "" {
+		files = append(files, w.clientAllowlistPath)
+	}
 	for _, file := range files {
 		if err := w.watcher.Add(file); err != nil {
 			return fmt.Errorf("failed to watch file %s: %w", file, err)
@@ -80,6 +97,12 @@ func (w *Watcher) Start() error {
 	return nil
 }
 
+// Reload reloads the TLS certificates and the client identity allowlist. The
+// previously loaded material stays in use if loading fails.
+func (w *Watcher) Reload() error {
+	return errors.Join(w.loadCerts(), w.loadClientIdentities())
+}
+
 // loadCerts loads the TLS certificates from the specified paths.
 func (w *Watcher) loadCerts() error {
 	if w.gatekeeperCACertPath != "" {
@@ -101,6 +124,20 @@ func (w *Watcher) loadCerts() error {
 	return nil
 }
 
+// loadClientIdentities loads the client identity allowlist from the specified
+// path.
+func (w *Watcher) loadClientIdentities() error {
+	if w.clientAllowlistPath == "" {
+		return nil
+	}
+	identities, err := loadClientIdentities(w.clientAllowlistPath)
+	if err != nil {
+		return err
+	}
+	w.clientIdentities.Store(identities)
+	return nil
+}
+
 // watcher monitors the CA cert file and reloads it on change
 func (w *Watcher) watch() {
 	for {
@@ -117,6 +154,12 @@ func (w *Watcher) watch() {
 						logrus.Errorf("error re-watching file: %v", err)
 					}
 				}
+				if event.Name == w.clientAllowlistPath {
+					if err := w.loadClientIdentities(); err != nil {
+						logrus.Errorf("failed to reload client identity allowlist: %v", err)
+					}
+					continue
+				}
 				if err := w.loadCerts(); err != nil {
 					logrus.Errorf("failed to reload CA certs: %v", err)
 				}
@@ -150,6 +193,23 @@ func (w *Watcher) GetConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error)
 		config.ClientCAs = clientCAs
 		config.ClientAuth = tls.RequireAndVerifyClientCert
 	}
+	if w.clientAllowlistPath != "" {
+		config.VerifyConnection = w.verifyConnection
+	}
 
 	return config, nil
 }
+
+// verifyConnection authorizes the verified client certificate against the
+// latest client identity allowlist.
+func (w *Watcher) verifyConnection(state tls.ConnectionState) error {
+	var leaf *x509.Certificate
+	if len(state.PeerCertificates) > 0 {
+		leaf = state.PeerCertificates[0]
+	}
+	if err := w.clientIdentities.Load().Authorize(leaf); err != nil {
+		logrus.Warnf("rejected TLS client: %v", err)
+		return err
+	}
+	return nil
+}
@@ -25,19 +25,20 @@ import (
 	"encoding/pem"
 	"math/big"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
 )
 
 func TestLoadingTLSCerts_EmptyPaths(t *testing.T) {
-	if _, err := NewWatcher("", "", ""); err == nil {
+	if _, err := NewWatcher("", "", "", ""); err == nil {
 		t.Fatalf("expected error but got none")
 	}
 }
 
 func TestLoadingTLSCerts_InvalidCertPaths(t *testing.T) {
-	_, err := NewWatcher("", "invalid_cert.pem", "invalid_key.pem")
+	_, err := NewWatcher("", "invalid_cert.pem", "invalid_key.pem", "")
 	if err == nil {
 		t.Fatalf("expected error but got none")
 	}
@@ -62,7 +63,7 @@ func TestLoadingTLSCerts_SuccessfullyLoaded(t *testing.T) {
 		os.Remove(keyFile)
 	}()
 
-	watcher, err := NewWatcher("", certFile, keyFile)
+	watcher, err := NewWatcher("", certFile, keyFile, "")
 	if err != nil {
 		t.Fatalf("failed to create TLS secret watcher: %v", err)
 	}
@@ -77,7 +78,7 @@ func TestLoadingTLSCerts_SuccessfullyLoaded(t *testing.T) {
 }
 
 func TestLoadingTLSCerts_InvalidCACertProvided(t *testing.T) {
-	if _, err := NewWatcher("invalidCACert.pem", "invalidCert.pem", "invalidKey.pem"); err == nil {
+	if _, err := NewWatcher("invalidCACert.pem", "invalidCert.pem", "invalidKey.pem", ""); err == nil {
 		t.Fatalf("Expected error but got none")
 	}
 }
@@ -115,7 +116,7 @@ func TestLoadingTLSCerts_GKCACertProvided(t *testing.T) {
 	}()
 
 	gatekeeperCACertFile := caCertFile
-	watcher, err := NewWatcher(gatekeeperCACertFile, certFile, keyFile)
+	watcher, err := NewWatcher(gatekeeperCACertFile, certFile, keyFile, "")
 	if err != nil {
 		t.Fatalf("failed to create TLS secret watcher: %v", err)
 	}
@@ -160,7 +161,7 @@ func TestLoadingTLSCerts_StartWatching(t *testing.T) {
 		os.Remove(keyFile)
 	}()
 
-	watcher, err := NewWatcher("", certFile, keyFile)
+	watcher, err := NewWatcher("", certFile, keyFile, "")
 	if err != nil {
 		t.Fatalf("failed to create TLS secret watcher: %v", err)
 	}
@@ -228,7 +229,7 @@ func TestGetConfigForClient(t *testing.T) {
 		os.Remove(caKeyFile)
 	}()
 
-	watcher, err := NewWatcher(caCertFile, certFile, keyFile)
+	watcher, err := NewWatcher(caCertFile, certFile, keyFile, "")
 	if err != nil {
 		t.Fatalf("failed to create TLS secret watcher: %v", err)
 	}
@@ -246,6 +247,108 @@ func TestGetConfigForClient(t *testing.T) {
 	}
 }
 
+func TestReload(t *testing.T) {
+	certPem, keyPem, err := generateRSAKeyCertPair()
+	if err != nil {
+		t.Fatalf("failed to generate cert/key pair: %v", err)
+	}
+	certFile, keyFile, err := generateAndSaveCertKey(t.TempDir(), certPem, keyPem)
+	if err != nil {
+		t.Fatalf("failed to generate and save cert/key: %v", err)
+	}
+	watcher, err := NewWatcher("", certFile, keyFile, "")
+	if err != nil {
+		t.Fatalf("failed to create TLS secret watcher: %v", err)
+	}
+
+	newCertPem, newKeyPem, err := generateRSAKeyCertPair()
+	if err != nil {
+		t.Fatalf("failed to generate new cert/key pair: %v", err)
+	}
+	if err := os.WriteFile(certFile, newCertPem, 0600); err != nil {
+		t.Fatalf("failed to write new cert file: %v", err)
+	}
+	if err := os.WriteFile(keyFile, newKeyPem, 0600); err != nil {
+		t.Fatalf("failed to write new key file: %v", err)
+	}
+	if err := watcher.Reload(); err != nil {
+		t.Fatalf("failed to reload: %v", err)
+	}
+	newCert, _ := tls.X509KeyPair(newCertPem, newKeyPem)
+	if !certificatesEqual(newCert, *watcher.ratifyServerTLSCert.Load()) {
+		t.Fatalf("expected cert to be reloaded")
+	}
+
+	if err := os.WriteFile(keyFile, []byte("invalid"), 0600); err != nil {
+		t.Fatalf("failed to write invalid key file: %v", err)
+	}
+	if err := watcher.Reload(); err == nil {
+		t.Fatal("expected reload to fail")
+	}
+	if !certificatesEqual(newCert, *watcher.ratifyServerTLSCert.Load()) {
+		t.Fatalf("expected the previous cert to stay in use")
+	}
+}
+
+func TestClientAllowlist(t *testing.T) {
+	certPem, keyPem, err := generateRSAKeyCertPair()
+	if err != nil {
+		t.Fatalf("failed to generate cert/key pair: %v", err)
+	}
+	certDir := t.TempDir()
+	certFile, keyFile, err := generateAndSaveCertKey(certDir, certPem, keyPem)
+	if err != nil {
+		t.Fatalf("failed to generate and save cert/key: %v", err)
+	}
+	allowlistFile := filepath.Join(certDir, "allowlist.json")
+	if err := os.WriteFile(allowlistFile, []byte(`{"commonNames": ["gatekeeper"]}`), 0600); err != nil {
+		t.Fatalf("failed to write allowlist: %v", err)
+	}
+
+	if _, err := NewWatcher("", certFile, keyFile, allowlistFile); err == nil {
+		t.Fatal("expected error without gatekeeper CA cert")
+	}
+	watcher, err := NewWatcher(certFile, certFile, keyFile, allowlistFile)
+	if err != nil {
+		t.Fatalf("failed to create TLS secret watcher: %v", err)
+	}
+	if err = watcher.Start(); err != nil {
+		t.Fatalf("failed to start watcher: %v", err)
+	}
+	defer watcher.Stop()
+
+	config, err := watcher.GetConfigForClient(nil)
+	if err != nil {
+		t.Fatalf("failed to get config for client: %v", err)
+	}
+	if config.ClientAuth != tls.RequireAndVerifyClientCert || config.VerifyConnection == nil {
+		t.Fatal("expected client certificates to be required and authorized")
+	}
+	gatekeeper := tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "gatekeeper"}}}}
+	ci := tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "ci"}}}}
+	if err := config.VerifyConnection(gatekeeper); err != nil {
+		t.Errorf("expected gatekeeper to be authorized, got %v", err)
+	}
+	if err := config.VerifyConnection(ci); err == nil {
+		t.Error("expected ci to be rejected")
+	}
+
+	// The allowlist is reloaded on change and applies to existing configs.
+	if err := os.WriteFile(allowlistFile, []byte(`{"commonNames": ["ci"]}`), 0600); err != nil {
+		t.Fatalf("failed to write allowlist: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for config.VerifyConnection(ci) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the allowlist to be reloaded")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if err := config.VerifyConnection(gatekeeper); err == nil {
+		t.Error("expected gatekeeper to be rejected after reload")
+	}
+}
+
 // generateAndSaveCertKey generates a new RSA key/cert pair and saves them to temp files.
 // It returns the cert file path, key file path, and any error encountered.
 func generateAndSaveCertKey(dir string, certPEM, keyPEM []byte) (certFile string, keyFile string, err error) {
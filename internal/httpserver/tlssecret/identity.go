@@ -0,0 +1,99 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tlssecret
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+)
+
+const spiffeScheme = "spiffe"
+
+// ClientIdentities is the allowlist of client certificate identities
+// authorized to call the server. A client is authorized if its leaf
+// certificate matches any of the entries. An allowlist without entries
+// rejects all clients.
+type ClientIdentities struct {
+	// CommonNames are the authorized subject common names, e.g.
+	// "gatekeeper-webhook-service.gatekeeper-system.svc". Optional.
+	CommonNames []string `json:"commonNames,omitempty"`
+
+	// DNSNames are the authorized DNS subject alternative names. Optional.
+	DNSNames []string `json:"dnsNames,omitempty"`
+
+	// SPIFFEIDs are the authorized SPIFFE IDs carried as URI subject
+	// alternative names, e.g.
+	// "spiffe://cluster.local/ns/gatekeeper-system/sa/gatekeeper-admin".
+	// Optional.
+	SPIFFEIDs []string `json:"spiffeIDs,omitempty"`
+}
+
+// loadClientIdentities reads the allowlist from the JSON file at path.
+func loadClientIdentities(path string) (*ClientIdentities, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var identities ClientIdentities
+	if err := json.Unmarshal(data, &identities); err != nil {
+		return nil, fmt.Errorf("failed to parse client identity allowlist %s: %w", path, err)
+	}
+	for _, id := range identities.SPIFFEIDs {
+		if u, err := url.Parse(id); err != nil || u.Scheme != spiffeScheme || u.Host == "" {
+			return nil, fmt.Errorf("invalid SPIFFE ID %q in client identity allowlist %s", id, path)
+		}
+	}
+	return &identities, nil
+}
+
+// Authorize checks the identity of the client certificate against the
+// allowlist.
+func (c *ClientIdentities) Authorize(cert *x509.Certificate) error {
+	if cert == nil {
+		return errors.New("client certificate is required")
+	}
+	if cert.Subject.CommonName != "" && slices.Contains(c.CommonNames, cert.Subject.CommonName) {
+		return nil
+	}
+	for _, name := range cert.DNSNames {
+		if slices.Contains(c.DNSNames, name) {
+			return nil
+		}
+	}
+	for _, uri := range cert.URIs {
+		if uri.Scheme == spiffeScheme && slices.Contains(c.SPIFFEIDs, uri.String()) {
+			return nil
+		}
+	}
+	return fmt.Errorf("client certificate %q is not authorized", identityOf(cert))
+}
+
+// identityOf describes the identities of the certificate for logging.
+func identityOf(cert *x509.Certificate) string {
+	identity := "CN=" + cert.Subject.CommonName
+	for _, name := range cert.DNSNames {
+		identity += ",DNS=" + name
+	}
+	for _, uri := range cert.URIs {
+		identity += ",URI=" + uri.String()
+	}
+	return identity
+}
@@ -0,0 +1,106 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tlssecret
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadClientIdentities(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectError bool
+	}{
+		{
+			name:    "valid allowlist",
+			content: `{"commonNames": ["gatekeeper"], "dnsNames": ["ci.example.com"], "spiffeIDs": ["spiffe://cluster.local/ns/gatekeeper-system/sa/gatekeeper-admin"]}`,
+		},
+		{
+			name:        "invalid JSON",
+			content:     `{invalid-json}`,
+			expectError: true,
+		},
+		{
+			name:        "invalid SPIFFE ID",
+			content:     `{"spiffeIDs": ["https://cluster.local/ns/gatekeeper-system"]}`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "allowlist.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatalf("failed to write allowlist: %v", err)
+			}
+			if _, err := loadClientIdentities(path); (err != nil) != tt.expectError {
+				t.Errorf("loadClientIdentities() error = %v, expectError %v", err, tt.expectError)
+			}
+		})
+	}
+}
+
+func TestClientIdentities_Authorize(t *testing.T) {
+	identities := &ClientIdentities{
+		CommonNames: []string{"gatekeeper"},
+		DNSNames:    []string{"ci.example.com"},
+		SPIFFEIDs:   []string{"spiffe://cluster.local/ns/gatekeeper-system/sa/gatekeeper-admin"},
+	}
+	spiffeID, _ := url.Parse("spiffe://cluster.local/ns/gatekeeper-system/sa/gatekeeper-admin")
+	otherID, _ := url.Parse("spiffe://cluster.local/ns/default/sa/default")
+
+	tests := []struct {
+		name        string
+		cert        *x509.Certificate
+		expectError bool
+	}{
+		{
+			name:        "no certificate",
+			expectError: true,
+		},
+		{
+			name: "common name",
+			cert: &x509.Certificate{Subject: pkix.Name{CommonName: "gatekeeper"}},
+		},
+		{
+			name: "DNS name",
+			cert: &x509.Certificate{Subject: pkix.Name{CommonName: "ci"}, DNSNames: []string{"other.example.com", "ci.example.com"}},
+		},
+		{
+			name: "SPIFFE ID",
+			cert: &x509.Certificate{URIs: []*url.URL{spiffeID}},
+		},
+		{
+			name:        "unauthorized identities",
+			cert:        &x509.Certificate{Subject: pkix.Name{CommonName: "ci.example.com"}, DNSNames: []string{"gatekeeper"}, URIs: []*url.URL{otherID}},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := identities.Authorize(tt.cert); (err != nil) != tt.expectError {
+				t.Errorf("Authorize() error = %v, expectError %v", err, tt.expectError)
+			}
+		})
+	}
+}
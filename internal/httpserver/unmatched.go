@@ -0,0 +1,71 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+
+	"github.com/notaryproject/ratify/v2/internal/errcode"
+	"github.com/notaryproject/ratify/v2/internal/logger"
+)
+
+// Decisions for artifacts that do not match the scope of any executor.
+const (
+	// unmatchedScopeError fails the validation with a SCOPE_NOT_MATCHED
+	// error. This is the default.
+	unmatchedScopeError = "error"
+	// unmatchedScopeDeny reports the artifact as not succeeded with the
+	// reason, so that policies deny it.
+	unmatchedScopeDeny = "deny"
+	// unmatchedScopeAllow reports the artifact as succeeded without
+	// validating it and logs a warning.
+	unmatchedScopeAllow = "allow"
+)
+
+// unmatchedDecision records the decision applied to an artifact that does not
+// match the scope of any executor.
+type unmatchedDecision struct {
+	// Mode is the applied decision, either "deny" or "allow".
+	Mode string `json:"mode"`
+
+	// Reason explains why the artifact is not validated.
+	Reason string `json:"reason"`
+}
+
+// decideUnmatched returns the decision for the artifact if it does not match
+// the scope of any executor and UnmatchedScope is deny or allow. It returns
+// nil if the artifact is matched, no executor is loaded, or unmatched
+// artifacts fail with an error.
+func (s *server) decideUnmatched(ctx context.Context, artifact string) *unmatchedDecision {
+	if s.UnmatchedScope != unmatchedScopeDeny && s.UnmatchedScope != unmatchedScopeAllow {
+		return nil
+	}
+	scopedExecutor := s.getExecutor()
+	if scopedExecutor == nil {
+		return nil
+	}
+	_, err := scopedExecutor.MatchScope(artifact)
+	if errcode.Classify(err) != errcode.ScopeNotMatched {
+		return nil
+	}
+	if s.UnmatchedScope == unmatchedScopeAllow {
+		logger.GetLogger(ctx, logOpt).Warnf("allowing artifact %s without validation: %v", artifact, err)
+	}
+	return &unmatchedDecision{
+		Mode:   s.UnmatchedScope,
+		Reason: err.Error(),
+	}
+}
@@ -0,0 +1,423 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/errcode"
+	"github.com/notaryproject/ratify/v2/internal/executor"
+	"github.com/notaryproject/ratify/v2/internal/logger"
+	"github.com/notaryproject/ratify/v2/internal/metrics"
+	"github.com/notaryproject/ratify/v2/internal/tracing"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"oras.land/oras-go/v2/registry"
+)
+
+// retryAfterSeconds is the Retry-After header of responses rejected with 503,
+// e.g. because the server is overloaded.
+const retryAfterSeconds = "1"
+
+// validationRequest is the request body of the validations API.
+type validationRequest struct {
+	// Subject is the reference of the artifact to validate. Required.
+	Subject string `json:"subject"`
+
+	// Platform selects the manifest validated by executors in the platform
+	// index mode if the subject is an image index, in the format
+	// "os/arch[/variant]". Optional.
+	Platform string `json:"platform,omitempty"`
+
+	// ArtifactTypes restricts the verified referrers to the artifact types.
+	// All referrers are verified if not set. Optional.
+	ArtifactTypes []string `json:"artifactTypes,omitempty"`
+
+	// BypassCache skips the lookup of a cached result. The fresh result is
+	// still cached. Optional.
+	BypassCache bool `json:"bypassCache,omitempty"`
+}
+
+// validationResponse is the response body of the validations API. It renders
+// the full [ratify.ValidationResult] tree. It is also the item value of the
+// verify handler in the v2 response schema.
+type validationResponse struct {
+	SchemaVersion   string                      `json:"schemaVersion,omitempty"`
+	Subject         string                      `json:"subject"`
+	ResolvedDigest  string                      `json:"resolvedDigest,omitempty"`
+	Platform        string                      `json:"platform,omitempty"`
+	MatchedScope    string                      `json:"matchedScope,omitempty"`
+	Succeeded       bool                        `json:"succeeded"`
+	CacheHit        bool                        `json:"cacheHit"`
+	DurationMs      float64                     `json:"durationMs"`
+	ArtifactReports []*detailedValidationReport `json:"artifactReports"`
+	Unmatched       *unmatchedDecision          `json:"unmatched,omitempty"`
+	AuditOnly       *auditOnlyOutcome           `json:"auditOnly,omitempty"`
+	Error           *errorInfo                  `json:"error,omitempty"`
+}
+
+// detailedValidationReport is a rendered view of [ratify.ValidationReport]
+// including the full artifact descriptor.
+type detailedValidationReport struct {
+	Subject         string                        `json:"subject"`
+	Artifact        ocispec.Descriptor            `json:"artifact"`
+	Results         []*detailedVerificationResult `json:"results,omitempty"`
+	ArtifactReports []*detailedValidationReport   `json:"artifactReports,omitempty"`
+}
+
+// detailedVerificationResult is a rendered view of
+// [ratify.VerificationResult] including the verifier type, the duration of
+// the verification and the detail as a JSON object.
+type detailedVerificationResult struct {
+	VerifierName string          `json:"verifierName"`
+	VerifierType string          `json:"verifierType"`
+	Description  string          `json:"description,omitempty"`
+	Detail       json.RawMessage `json:"detail,omitempty"`
+	DurationMs   *float64        `json:"durationMs,omitempty"`
+	Error        *errorInfo      `json:"error,omitempty"`
+}
+
+// validate checks the request and returns the parsed platform, if any.
+func (r *validationRequest) validate() (*ocispec.Platform, error) {
+	if r.Subject == "" {
+		return nil, errcode.InvalidReference.Errorf("subject must be set")
+	}
+	if _, err := registry.ParseReference(r.Subject); err != nil {
+		return nil, errcode.InvalidReference.Errorf("failed to parse subject %q: %w", r.Subject, err)
+	}
+	if r.Platform == "" {
+		return nil, nil
+	}
+	platform, err := executor.ParsePlatform(r.Platform)
+	if err != nil {
+		return nil, errcode.InvalidReference.Wrap(err)
+	}
+	return platform, nil
+}
+
+// validations handles the validation request of the REST API.
+func (s *server) validations(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	start := time.Now()
+	defer r.Body.Close()
+
+	var req validationRequest
+	if err := json.NewDecoder(s.limitBody(w, r)).Decode(&req); err != nil {
+		code := errcode.InvalidRequest
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			code = errcode.RequestTooLarge
+		}
+		err = code.Errorf("failed to decode validation request: %w", err)
+		return sendValidationResponse(w, failedValidation(req.Subject, err), start)
+	}
+	platform, err := req.validate()
+	if err != nil {
+		return sendValidationResponse(w, failedValidation(req.Subject, err), start)
+	}
+
+	return sendValidationResponse(w, s.validateSubject(ctx, &req, platform), start)
+}
+
+// validateSubject resolves the subject to a digest and validates the resolved
+// artifact. Results are cached per subject, platform and artifact types.
+func (s *server) validateSubject(ctx context.Context, req *validationRequest, platform *ocispec.Platform) (resp *validationResponse) {
+	ctx, span := tracing.StartSpan(ctx, "validateSubject",
+		attribute.String("ratify.artifact", req.Subject),
+		attribute.Bool("ratify.bypass_cache", req.BypassCache),
+	)
+	defer func() {
+		span.SetAttributes(attribute.Bool("ratify.failed", resp.Error != nil || !resp.Succeeded))
+		span.End()
+	}()
+	if decision := s.decideUnmatched(ctx, req.Subject); decision != nil {
+		span.SetAttributes(attribute.String("ratify.unmatched", decision.Mode))
+		return &validationResponse{
+			Subject:         req.Subject,
+			Platform:        req.Platform,
+			Succeeded:       decision.Mode == unmatchedScopeAllow,
+			ArtifactReports: []*detailedValidationReport{},
+			Unmatched:       decision,
+		}
+	}
+	key := validationKey(req.Subject, req.Platform, s.configFingerprint(req.Subject), req.ArtifactTypes)
+
+	if !req.BypassCache {
+		cached, remaining, err := s.getValidationCache(ctx, key)
+		hit := err == nil && cached != nil
+		metrics.ReportCacheCount(ctx, metrics.CacheValidations, hit)
+		span.SetAttributes(attribute.Bool("ratify.cache_hit", hit))
+		if hit {
+			if s.needsRefresh(remaining) {
+				span.SetAttributes(attribute.Bool("ratify.cache_refresh", true))
+				s.refreshValidationCache(ctx, key, *req, platform)
+			}
+			cachedResp := *cached
+			cachedResp.CacheHit = true
+			return &cachedResp
+		}
+	}
+
+	// Block multiple goroutines from validating the same subject with the
+	// same options. The validation outlives this request if others wait for
+	// it.
+	val, err, shared := s.sfGroup.Do(ctx, key, func(ctx context.Context) (any, error) {
+		return s.validateAndCacheSubject(ctx, key, req, platform, true)
+	})
+	metrics.ReportSingleflightCount(ctx, metrics.CacheValidations, shared)
+	if err != nil {
+		return failedValidation(req.Subject, err)
+	}
+	// Copy the shared response so that the caller can set per-request fields.
+	resp = new(validationResponse)
+	*resp = *val.(*validationResponse)
+	return resp
+}
+
+// validateAndCacheSubject validates the subject of req and caches the
+// response under key. Errors not caused by the cancellation of ctx are
+// negatively cached if cacheErrors is set and the executor configures a
+// failure TTL.
+func (s *server) validateAndCacheSubject(ctx context.Context, key string, req *validationRequest, platform *ocispec.Platform, cacheErrors bool) (*validationResponse, error) {
+	scopedExecutor := s.getExecutor()
+	if scopedExecutor == nil {
+		return nil, errcode.ExecutorNotConfigured.Wrap(errors.New("no valid executor configured"))
+	}
+	release, err := s.limiter.acquire(ctx, req.Subject)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	cacheTTL := scopedExecutor.CacheTTL(req.Subject)
+	resp, err := s.resolveAndValidate(ctx, scopedExecutor, req, platform)
+	if err != nil {
+		// Negatively cache the error only if it is not caused by the
+		// cancellation of the request.
+		if cacheErrors && cacheTTL.Failure > 0 && ctx.Err() == nil {
+			if cacheErr := s.validationCache.Set(ctx, key, failedValidation(req.Subject, err), s.verifyCacheTTL(cacheTTL.Failure)); cacheErr != nil {
+				logger.GetLogger(ctx, logOpt).Warnf("failed to set validation cache for subject %s: %v", req.Subject, cacheErr)
+			}
+		}
+		return nil, err
+	}
+	ttl := cacheTTL.Success
+	if !resp.Succeeded && cacheTTL.Failure > 0 {
+		ttl = cacheTTL.Failure
+	}
+	if err = s.validationCache.Set(ctx, key, resp, s.verifyCacheTTL(ttl)); err != nil {
+		logger.GetLogger(ctx, logOpt).Warnf("failed to set validation cache for subject %s: %v", req.Subject, err)
+	}
+	return resp, nil
+}
+
+// resolveAndValidate resolves the subject to a digest and validates the
+// artifact by the digest. The platform is passed to the executor, so that the
+// children of an image index are validated according to its index mode like
+// the v1 verify handler does.
+//
+// If the subject cannot be resolved by an audit-only executor, the subject is
+// validated as is, so that the executor flags the failure instead of failing
+// the validation.
+func (s *server) resolveAndValidate(ctx context.Context, scopedExecutor *executor.ScopedExecutor, req *validationRequest, platform *ocispec.Platform) (*validationResponse, error) {
+	ref, err := registry.ParseReference(req.Subject)
+	if err != nil {
+		return nil, errcode.InvalidReference.Errorf("failed to parse subject %q: %w", req.Subject, err)
+	}
+	var resolvedDigest string
+	desc, err := scopedExecutor.Resolve(ctx, req.Subject)
+	if err != nil {
+		if !scopedExecutor.AuditOnly(req.Subject) {
+			return nil, fmt.Errorf("failed to resolve subject %q: %w", req.Subject, err)
+		}
+	} else {
+		resolvedDigest = desc.Digest.String()
+		ref.Reference = resolvedDigest
+	}
+	matchedScope, err := scopedExecutor.MatchScope(req.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, timings := metrics.WithVerifierTimings(ctx)
+	result, err := scopedExecutor.ValidateArtifactWithOptions(ctx, executor.ValidateOptions{
+		ValidateArtifactOptions: ratify.ValidateArtifactOptions{
+			Subject:        ref.String(),
+			ReferenceTypes: req.ArtifactTypes,
+		},
+		Platform: platform,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &validationResponse{
+		Subject:         req.Subject,
+		ResolvedDigest:  resolvedDigest,
+		Platform:        req.Platform,
+		MatchedScope:    matchedScope,
+		Succeeded:       result.Succeeded,
+		ArtifactReports: convertDetailedReports(result.ArtifactReports, timings),
+		AuditOnly:       convertAuditOnly(result),
+	}, nil
+}
+
+// failedValidation renders a validation that failed before producing a
+// result.
+func failedValidation(subject string, err error) *validationResponse {
+	return &validationResponse{
+		Subject: subject,
+		Error:   convertError(err, errcode.Unknown),
+	}
+}
+
+func convertDetailedReports(src []*ratify.ValidationReport, timings *metrics.VerifierTimings) []*detailedValidationReport {
+	if src == nil {
+		return nil
+	}
+	reports := make([]*detailedValidationReport, len(src))
+	for idx, report := range src {
+		reports[idx] = convertDetailedReport(report, timings)
+	}
+	return reports
+}
+
+func convertDetailedReport(src *ratify.ValidationReport, timings *metrics.VerifierTimings) *detailedValidationReport {
+	if src == nil {
+		return nil
+	}
+	report := &detailedValidationReport{
+		Subject:         src.Subject,
+		Artifact:        src.Artifact,
+		ArtifactReports: convertDetailedReports(src.ArtifactReports, timings),
+	}
+	if len(src.Results) > 0 {
+		report.Results = make([]*detailedVerificationResult, len(src.Results))
+		for idx, result := range src.Results {
+			report.Results[idx] = convertDetailedResult(result, src.Artifact, timings)
+		}
+	}
+	return report
+}
+
+func convertDetailedResult(src *ratify.VerificationResult, artifact ocispec.Descriptor, timings *metrics.VerifierTimings) *detailedVerificationResult {
+	if src == nil {
+		return nil
+	}
+	result := &detailedVerificationResult{
+		Description: src.Description,
+	}
+	if src.Verifier != nil {
+		result.VerifierName = src.Verifier.Name()
+		result.VerifierType = src.Verifier.Type()
+		if duration, ok := timings.Get(artifact.Digest, result.VerifierName); ok {
+			durationMs := milliseconds(duration)
+			result.DurationMs = &durationMs
+		}
+	}
+	if src.Err != nil {
+		result.Error = convertError(src.Err, errcode.VerificationFailed)
+	}
+	if src.Detail != nil {
+		detail, err := json.Marshal(src.Detail)
+		if err != nil {
+			// Keep the rest of the result if the detail cannot be rendered.
+			detail, _ = json.Marshal(fmt.Sprintf("failed to marshal detail: %v", err))
+		}
+		result.Detail = detail
+	}
+	return result
+}
+
+// sendValidationResponse writes resp with the status code derived from its
+// error.
+func sendValidationResponse(w http.ResponseWriter, resp *validationResponse, start time.Time) error {
+	resp.DurationMs = milliseconds(time.Since(start))
+	statusCode := http.StatusOK
+	if resp.Error != nil {
+		statusCode = validationStatusCode(resp.Error)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if statusCode == http.StatusServiceUnavailable {
+		w.Header().Set("Retry-After", retryAfterSeconds)
+	}
+	w.WriteHeader(statusCode)
+	return json.NewEncoder(w).Encode(resp)
+}
+
+// validationStatusCode maps the error of a validation to an HTTP status code.
+// A completed validation is reported with 200 even if the artifact failed
+// validation, so the status code only reflects whether Ratify could produce a
+// result.
+func validationStatusCode(info *errorInfo) int {
+	if info.Code == errcode.RequestTooLarge {
+		return http.StatusRequestEntityTooLarge
+	}
+	switch info.Category {
+	case errcode.CategoryInvalidInput:
+		return http.StatusBadRequest
+	case errcode.CategoryNotFound:
+		return http.StatusNotFound
+	case errcode.CategoryTimeout:
+		return http.StatusGatewayTimeout
+	case errcode.CategoryOverload:
+		return http.StatusServiceUnavailable
+	case errcode.CategoryAuthentication, errcode.CategoryRegistry:
+		return http.StatusBadGateway
+	case errcode.CategoryConfiguration:
+		if info.Retryable {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func milliseconds(duration time.Duration) float64 {
+	return float64(duration) / float64(time.Millisecond)
+}
+
+// validationKey returns the cache key of a validation under the executor
+// configuration with the fingerprint. The subject comes first so that the
+// entries can be purged by repository prefix.
+func validationKey(subject, platform, fingerprint string, artifactTypes []string) string {
+	query := url.Values{}
+	if platform != "" {
+		query.Set("platform", platform)
+	}
+	if fingerprint != "" {
+		query.Set("config", fingerprint)
+	}
+	if len(artifactTypes) > 0 {
+		types := slices.Clone(artifactTypes)
+		slices.Sort(types)
+		query.Set("artifactTypes", strings.Join(slices.Compact(types), ","))
+	}
+	return validationKeyPrefix(subject) + "?" + query.Encode()
+}
+
+// validationKeyPrefix returns the prefix of the cache keys of all validations
+// of subjects starting with subject.
+func validationKeyPrefix(subject string) string {
+	return fmt.Sprintf("%s_%s", validationsPath, subject)
+}
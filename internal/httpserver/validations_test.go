@@ -0,0 +1,400 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/errcode"
+	"github.com/notaryproject/ratify/v2/internal/executor"
+	"github.com/notaryproject/ratify/v2/internal/policyenforcer"
+	_ "github.com/notaryproject/ratify/v2/internal/policyenforcer/threshold"
+	"github.com/notaryproject/ratify/v2/internal/store"
+	"github.com/notaryproject/ratify/v2/internal/verifier"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	signedStoreType       = "mock-signed-store"
+	signatureVerifierType = "mock-signature-verifier"
+	signedSubject         = "registry.example.com/test/image:v1"
+	signatureType         = "application/vnd.cncf.notary.signature"
+)
+
+var (
+	signedSubjectDesc = ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromString("subject"),
+	}
+	signatureDesc = ocispec.Descriptor{
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: signatureType,
+		Digest:       digest.FromString("signature"),
+	}
+)
+
+// signedStore resolves every reference to signedSubjectDesc which has a
+// single signature referrer.
+type signedStore struct {
+	mockStore
+}
+
+func (s *signedStore) Resolve(_ context.Context, ref string) (ocispec.Descriptor, error) {
+	if strings.Contains(ref, "missing") {
+		return ocispec.Descriptor{}, errcode.SubjectNotFound.Errorf("%s: not found", ref)
+	}
+	return signedSubjectDesc, nil
+}
+
+func (s *signedStore) ListReferrers(_ context.Context, _ string, artifactTypes []string, fn func(referrers []ocispec.Descriptor) error) error {
+	if len(artifactTypes) > 0 && artifactTypes[0] != signatureType {
+		return nil
+	}
+	return fn([]ocispec.Descriptor{signatureDesc})
+}
+
+func newSignedStore(_ *store.NewOptions) (ratify.Store, error) {
+	return &signedStore{}, nil
+}
+
+// signatureVerifier accepts every signature with a detail.
+type signatureVerifier struct {
+	mockVerifier
+}
+
+func (v *signatureVerifier) Type() string {
+	return signatureVerifierType
+}
+
+func (v *signatureVerifier) Verify(_ context.Context, _ *ratify.VerifyOptions) (*ratify.VerificationResult, error) {
+	return &ratify.VerificationResult{
+		Verifier:    v,
+		Description: "signature verified",
+		Detail:      map[string]string{"issuer": "test"},
+	}, nil
+}
+
+func newSignatureVerifier(_ *verifier.NewOptions, _ []string) (ratify.Verifier, error) {
+	return &signatureVerifier{}, nil
+}
+
+func init() {
+	store.RegisterStoreFactory(signedStoreType, newSignedStore)
+	verifier.RegisterVerifierFactory(signatureVerifierType, newSignatureVerifier)
+}
+
+type mockTypedCache[T any] struct {
+	mu      sync.Mutex
+	entries map[string]T
+}
+
+func (c *mockTypedCache[T]) Get(_ context.Context, key string) (T, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if val, ok := c.entries[key]; ok {
+		return val, nil
+	}
+	var zero T
+	return zero, fmt.Errorf("key not found")
+}
+
+func (c *mockTypedCache[T]) Set(_ context.Context, key string, value T, _ time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = value
+	return nil
+}
+
+func (c *mockTypedCache[T]) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+	return nil
+}
+
+func (c *mockTypedCache[T]) DeleteByPrefix(_ context.Context, prefix string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+	return nil
+}
+
+func newSignedExecutor(t *testing.T) *executor.ScopedExecutor {
+	t.Helper()
+	scopedExecutor, err := executor.NewScopedExecutor(signedExecutorOptions())
+	if err != nil {
+		t.Fatalf("failed to create executor: %v", err)
+	}
+	return scopedExecutor
+}
+
+// signedExecutorOptions returns the options of an executor validating the
+// artifacts of registry.example.com with the signature verifier.
+func signedExecutorOptions() *executor.Options {
+	return &executor.Options{
+		Executors: []*executor.ScopedOptions{
+			{
+				Scopes: []string{"registry.example.com"},
+				Verifiers: []*verifier.NewOptions{
+					{
+						Name: mockVerifierName,
+						Type: signatureVerifierType,
+					},
+				},
+				Stores: []*store.NewOptions{
+					{
+						Type: signedStoreType,
+					},
+				},
+				Policy: &policyenforcer.NewOptions{
+					Type: "threshold-policy",
+					Parameters: map[string]any{
+						"policy": map[string]any{
+							"rules": []any{
+								map[string]any{
+									"verifierName": mockVerifierName,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestValidations(t *testing.T) {
+	scopedExecutor := newSignedExecutor(t)
+
+	tests := []struct {
+		name               string
+		requestBody        string
+		cacheEntries       map[string]*validationResponse
+		getExecutorFunc    func() *executor.ScopedExecutor
+		maxBodyBytes       int64
+		limiter            *inFlightLimiter
+		expectedStatusCode int
+		expectedSucceeded  bool
+		expectedCacheHit   bool
+		expectedErrorCode  errcode.Code
+		expectedReports    int
+	}{
+		{
+			name:               "invalid request body",
+			requestBody:        `{invalid-json}`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedErrorCode:  errcode.InvalidRequest,
+		},
+		{
+			name:               "mistyped request field",
+			requestBody:        `{"subject": ["registry.example.com/test/image:v1"]}`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedErrorCode:  errcode.InvalidRequest,
+		},
+		{
+			name:               "request body too large",
+			requestBody:        `{"subject": "registry.example.com/test/image:v1"}`,
+			maxBodyBytes:       16,
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+			expectedErrorCode:  errcode.RequestTooLarge,
+		},
+		{
+			name:               "invalid subject",
+			requestBody:        `{"subject": "image"}`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedErrorCode:  errcode.InvalidReference,
+		},
+		{
+			name:               "invalid platform",
+			requestBody:        `{"subject": "registry.example.com/test/image:v1", "platform": "linux"}`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedErrorCode:  errcode.InvalidReference,
+		},
+		{
+			name:               "executor not configured",
+			requestBody:        `{"subject": "registry.example.com/test/image:v1"}`,
+			getExecutorFunc:    func() *executor.ScopedExecutor { return nil },
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedErrorCode:  errcode.ExecutorNotConfigured,
+		},
+		{
+			name:               "scope not matched",
+			requestBody:        `{"subject": "unknown.example.org/test/image:v1"}`,
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedErrorCode:  errcode.ScopeNotMatched,
+		},
+		{
+			name:               "subject not found",
+			requestBody:        `{"subject": "registry.example.com/test/missing:v1"}`,
+			expectedStatusCode: http.StatusNotFound,
+			expectedErrorCode:  errcode.SubjectNotFound,
+		},
+		{
+			name:               "overloaded",
+			requestBody:        `{"subject": "registry.example.com/test/image:v1"}`,
+			limiter:            newSaturatedLimiter(),
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedErrorCode:  errcode.Overloaded,
+		},
+		{
+			name:               "validated",
+			requestBody:        `{"subject": "registry.example.com/test/image:v1"}`,
+			expectedStatusCode: http.StatusOK,
+			expectedSucceeded:  true,
+			expectedReports:    1,
+		},
+		{
+			name:               "filtered artifact types",
+			requestBody:        `{"subject": "registry.example.com/test/image:v1", "artifactTypes": ["application/vnd.unknown"]}`,
+			expectedStatusCode: http.StatusOK,
+			expectedReports:    0,
+		},
+		{
+			name:        "cache hit",
+			requestBody: `{"subject": "registry.example.com/test/image:v1"}`,
+			cacheEntries: map[string]*validationResponse{
+				validationKey(signedSubject, "", scopedExecutor.Fingerprint(signedSubject), nil): {Subject: signedSubject, Succeeded: true},
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedSucceeded:  true,
+			expectedCacheHit:   true,
+		},
+		{
+			name:        "bypass cache",
+			requestBody: `{"subject": "registry.example.com/test/image:v1", "bypassCache": true}`,
+			cacheEntries: map[string]*validationResponse{
+				validationKey(signedSubject, "", scopedExecutor.Fingerprint(signedSubject), nil): {Subject: signedSubject, Succeeded: false},
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedSucceeded:  true,
+			expectedReports:    1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cacheEntries := test.cacheEntries
+			if cacheEntries == nil {
+				cacheEntries = make(map[string]*validationResponse)
+			}
+			server := &server{
+				getExecutor: func() *executor.ScopedExecutor {
+					return scopedExecutor
+				},
+				validationCache: &mockTypedCache[*validationResponse]{entries: cacheEntries},
+				sfGroup:         new(flightGroup),
+				limiter:         test.limiter,
+				ServerOptions: ServerOptions{
+					MaxRequestBodyBytes: test.maxBodyBytes,
+				},
+			}
+			if test.getExecutorFunc != nil {
+				server.getExecutor = test.getExecutorFunc
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/ratify/v2/validations", strings.NewReader(test.requestBody))
+			w := httptest.NewRecorder()
+			if err := server.validations(context.Background(), w, req); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if w.Code != test.expectedStatusCode {
+				t.Errorf("expected status code %d, got %d", test.expectedStatusCode, w.Code)
+			}
+			if w.Code == http.StatusServiceUnavailable && w.Header().Get("Retry-After") != retryAfterSeconds {
+				t.Errorf("expected Retry-After %s, got %q", retryAfterSeconds, w.Header().Get("Retry-After"))
+			}
+			var resp validationResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Succeeded != test.expectedSucceeded {
+				t.Errorf("expected succeeded %t, got %t", test.expectedSucceeded, resp.Succeeded)
+			}
+			if resp.CacheHit != test.expectedCacheHit {
+				t.Errorf("expected cache hit %t, got %t", test.expectedCacheHit, resp.CacheHit)
+			}
+			var code errcode.Code
+			if resp.Error != nil {
+				code = resp.Error.Code
+			}
+			if code != test.expectedErrorCode {
+				t.Errorf("expected error code %q, got %q", test.expectedErrorCode, code)
+			}
+			if test.expectedCacheHit || test.expectedErrorCode != "" {
+				return
+			}
+
+			if resp.ResolvedDigest != signedSubjectDesc.Digest.String() {
+				t.Errorf("expected resolved digest %s, got %s", signedSubjectDesc.Digest, resp.ResolvedDigest)
+			}
+			if resp.MatchedScope != "registry.example.com" {
+				t.Errorf("expected matched scope registry.example.com, got %q", resp.MatchedScope)
+			}
+			if len(resp.ArtifactReports) != test.expectedReports {
+				t.Fatalf("expected %d artifact reports, got %d", test.expectedReports, len(resp.ArtifactReports))
+			}
+			if test.expectedReports == 0 {
+				return
+			}
+			report := resp.ArtifactReports[0]
+			if report.Artifact.Digest != signatureDesc.Digest || report.Artifact.ArtifactType != signatureType {
+				t.Errorf("expected artifact %+v, got %+v", signatureDesc, report.Artifact)
+			}
+			if len(report.Results) != 1 {
+				t.Fatalf("expected 1 verification result, got %d", len(report.Results))
+			}
+			result := report.Results[0]
+			if result.VerifierName != mockVerifierName || result.VerifierType != signatureVerifierType {
+				t.Errorf("expected verifier %s of type %s, got %s of type %s", mockVerifierName, signatureVerifierType, result.VerifierName, result.VerifierType)
+			}
+			if string(result.Detail) != `{"issuer":"test"}` {
+				t.Errorf("expected detail object, got %s", result.Detail)
+			}
+			if result.DurationMs == nil {
+				t.Errorf("expected duration of the verification")
+			}
+			if _, ok := cacheEntries[validationKey(signedSubject, "", scopedExecutor.Fingerprint(signedSubject), nil)]; !ok {
+				t.Errorf("expected the response to be cached")
+			}
+		})
+	}
+}
+
+func TestValidationKey(t *testing.T) {
+	key := validationKey(signedSubject, "linux/amd64", "0123456789abcdef", []string{"b", "a", "b"})
+	expected := "validations_registry.example.com/test/image:v1?artifactTypes=a%2Cb&config=0123456789abcdef&platform=linux%2Famd64"
+	if key != expected {
+		t.Errorf("expected key %q, got %q", expected, key)
+	}
+	if !strings.HasPrefix(key, validationKeyPrefix(signedSubject)+"?") {
+		t.Errorf("expected key %q to start with the subject prefix", key)
+	}
+}
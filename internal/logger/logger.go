@@ -27,6 +27,7 @@ import (
 	icontext "github.com/notaryproject/ratify/v2/internal/context"
 	re "github.com/ratify-project/ratify/errors"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ContextKey defines the key type used for the context.
@@ -94,11 +95,16 @@ func InitContext(ctx context.Context, r *http.Request) context.Context {
 	return setTraceID(ctx, r)
 }
 
-// GetLogger returns a logger with provided values.
+// GetLogger returns a logger with provided values. The returned logger carries
+// ctx so that logrus hooks can access the values of the context.
 func GetLogger(ctx context.Context, opt Option) dcontext.Logger {
 	ctx = dcontext.WithLogger(ctx, dcontext.GetLogger(ctx, icontext.ContextKeyNamespace))
 	ctx = context.WithValue(ctx, ContextKeyComponentType, opt.ComponentType)
-	return dcontext.GetLogger(ctx, ContextKeyComponentType)
+	logger := dcontext.GetLogger(ctx, ContextKeyComponentType)
+	if entry, ok := logger.(*logrus.Entry); ok {
+		return entry.WithContext(ctx)
+	}
+	return logger
 }
 
 // GetTraceID returns the trace ID from the context.
@@ -110,7 +116,8 @@ func GetTraceID(ctx context.Context) string {
 	return traceID.(string)
 }
 
-// setTraceID sets the trace ID in the context. If the trace ID is not present in the request headers, a new one is generated.
+// setTraceID sets the trace ID in the context. If the trace ID is not present in the request headers, the ID of the
+// OpenTelemetry trace in the context is used, or a new one is generated if there is none.
 func setTraceID(ctx context.Context, r *http.Request) context.Context {
 	traceID := ""
 	for _, headerName := range traceIDHeaderNames {
@@ -119,7 +126,11 @@ func setTraceID(ctx context.Context, r *http.Request) context.Context {
 		}
 	}
 	if traceID == "" {
-		traceID = uuid.New().String()
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+			traceID = spanContext.TraceID().String()
+		} else {
+			traceID = uuid.New().String()
+		}
 	}
 	ctx = context.WithValue(ctx, ContextKeyTraceID, traceID)
 	return dcontext.WithLogger(ctx, dcontext.GetLogger(ctx, ContextKeyTraceID))
@@ -25,6 +25,7 @@ import (
 	logstash "github.com/bshuster-repo/logrus-logstash-hook"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -85,6 +86,25 @@ func TestInitContext(t *testing.T) {
 	}
 }
 
+func TestInitContext_OpenTelemetryTraceID(t *testing.T) {
+	defer cleanup()
+
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	if err != nil {
+		t.Fatalf("failed to parse trace ID: %v", err)
+	}
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  trace.SpanID{1},
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+
+	ctx = InitContext(ctx, &http.Request{Header: http.Header{}})
+	if got := GetTraceID(ctx); got != traceID.String() {
+		t.Fatalf("expected traceID %s, but got %s", traceID, got)
+	}
+}
+
 func TestSetTraceIDHeader(t *testing.T) {
 	defer cleanup()
 
@@ -49,8 +49,9 @@ func init() {
 }
 
 // StartManager creates a new Manager which is responsible for creating
-// Controllers.
-func StartManager(certRotatorReady chan struct{}, disableMutation bool, disableCRDManager bool) {
+// Controllers. If discoverPodImages is set, the manager also sets up the
+// lister of the images of running Pods used to pre-warm the caches.
+func StartManager(certRotatorReady chan struct{}, disableMutation bool, disableCRDManager bool, discoverPodImages bool) {
 	ctrl.SetLogger(logrusr.New(logrus.StandardLogger()))
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme: scheme,
@@ -62,6 +63,7 @@ func StartManager(certRotatorReady chan struct{}, disableMutation bool, disableC
 
 	setupCertRotator(certRotatorReady, mgr, disableMutation)
 	setupCRDControllers(mgr, disableCRDManager)
+	setupPodImageLister(mgr, discoverPodImages)
 
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "could not start manager")
@@ -126,3 +128,14 @@ func setupCRDControllers(mgr ctrl.Manager, disableCRDManager bool) {
 		os.Exit(1)
 	}
 }
+
+func setupPodImageLister(mgr ctrl.Manager, discoverPodImages bool) {
+	if !discoverPodImages {
+		return
+	}
+
+	setupLog.Info("setting up pod image lister")
+	// List Pods directly from the API server instead of caching all Pods of
+	// the cluster, as they are only listed when the caches are pre-warmed.
+	controller.GlobalPodImageLister.SetReader(mgr.GetAPIReader())
+}
@@ -0,0 +1,153 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scrape returns the metrics served by handler in the Prometheus text format.
+func scrape(t *testing.T, handler http.Handler) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	body, err := io.ReadAll(w.Body)
+	if err != nil {
+		t.Fatalf("failed to read metrics: %v", err)
+	}
+	return string(body)
+}
+
+func TestNewPrometheusHandler(t *testing.T) {
+	handler, err := NewPrometheusHandler()
+	if err != nil {
+		t.Fatalf("failed to create handler: %v", err)
+	}
+
+	ctx := context.Background()
+	ReportVerificationRequest(ctx, 120*time.Millisecond)
+	ReportMutationRequest(ctx, 20*time.Millisecond)
+	ReportCacheCount(ctx, CacheVerify, true)
+	ReportCacheCount(ctx, CacheMutate, false)
+	ReportSingleflightCount(ctx, CacheVerify, true)
+	ReportCredentialRefresh(ctx, "azure", false)
+
+	body := scrape(t, handler)
+	expected := []string{
+		`ratify_verification_request_bucket{le="200"} 1`,
+		`ratify_mutation_request_count 1`,
+		`ratify_cache_count_total{cache="verify",hit="true"} 1`,
+		`ratify_cache_count_total{cache="mutate",hit="false"} 1`,
+		`ratify_singleflight_count_total{cache="verify",shared="true"} 1`,
+		`ratify_credential_refresh_count_total{provider="azure",success="false"} 1`,
+		`go_goroutines`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+var (
+	// dashboardMetricPattern matches the metric names queried by dashboards.
+	dashboardMetricPattern = regexp.MustCompile(`ratify_[a-z_]+`)
+
+	// dashboardSelectorPattern matches the label selectors of the queried
+	// metrics and dashboardLabelPattern the label names in a selector.
+	dashboardSelectorPattern = regexp.MustCompile(`(ratify_[a-z_]+)\{([^}]*)\}`)
+	dashboardLabelPattern    = regexp.MustCompile(`([a-z_]+)\s*[=!]~?`)
+
+	// dashboardLabelValuesPattern matches the label_values queries of the
+	// dashboard variables.
+	dashboardLabelValuesPattern = regexp.MustCompile(`label_values\((ratify_[a-z_]+),\s*([a-z_]+)\)`)
+)
+
+func TestNewPrometheusHandler_Dashboards(t *testing.T) {
+	handler, err := NewPrometheusHandler()
+	if err != nil {
+		t.Fatalf("failed to create handler: %v", err)
+	}
+
+	ctx := context.Background()
+	ReportVerificationRequest(ctx, 120*time.Millisecond)
+	ReportMutationRequest(ctx, 20*time.Millisecond)
+	ReportVerifierDuration(ctx, 10*time.Millisecond, "notation", "notation", true, false)
+	ReportSystemError(ctx, "failed to read request body")
+	ReportRegistryRequestCount(ctx, http.StatusTooManyRequests, "registry.example.com")
+	ReportBlobCacheCount(ctx, true)
+	ReportAADExchangeDuration(ctx, 100*time.Millisecond, "ACR")
+	ReportACRExchangeDuration(ctx, 100*time.Millisecond, "registry.example.com")
+	ReportAKVCertificateDuration(ctx, 100*time.Millisecond, "cert")
+
+	// series maps the name of each scraped series to its label names.
+	series := make(map[string]map[string]bool)
+	for _, line := range strings.Split(scrape(t, handler), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, labels, _ := strings.Cut(strings.Fields(line)[0], "{")
+		if series[name] == nil {
+			series[name] = make(map[string]bool)
+		}
+		for _, match := range dashboardLabelPattern.FindAllStringSubmatch(labels, -1) {
+			series[name][match[1]] = true
+		}
+	}
+
+	dashboards, err := filepath.Glob(filepath.Join("..", "..", "instrumentation", "grafana_*.yaml"))
+	if err != nil || len(dashboards) == 0 {
+		t.Fatalf("failed to find dashboards: %v", err)
+	}
+	for _, dashboard := range dashboards {
+		content, err := os.ReadFile(dashboard)
+		if err != nil {
+			t.Fatalf("failed to read dashboard: %v", err)
+		}
+		// The quotes of the label values are escaped in the dashboard JSON.
+		queries := strings.ReplaceAll(string(content), `\"`, `"`)
+		for _, name := range dashboardMetricPattern.FindAllString(queries, -1) {
+			if series[name] == nil {
+				t.Errorf("metric %s queried by %s is not served", name, filepath.Base(dashboard))
+			}
+		}
+		expectLabel := func(name, label string) {
+			if series[name] != nil && !series[name][label] {
+				t.Errorf("metric %s queried by %s has no label %s", name, filepath.Base(dashboard), label)
+			}
+		}
+		for _, match := range dashboardSelectorPattern.FindAllStringSubmatch(queries, -1) {
+			for _, label := range dashboardLabelPattern.FindAllStringSubmatch(match[2], -1) {
+				expectLabel(match[1], label[1])
+			}
+		}
+		for _, match := range dashboardLabelValuesPattern.FindAllStringSubmatch(queries, -1) {
+			expectLabel(match[1], match[2])
+		}
+	}
+}
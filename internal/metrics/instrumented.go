@@ -0,0 +1,136 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/notaryproject/ratify-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Store operation names used as the value of the operation attribute.
+const (
+	operationResolve       = "resolve"
+	operationListReferrers = "list_referrers"
+	operationFetchBlob     = "fetch_blob"
+	operationFetchManifest = "fetch_manifest"
+)
+
+// instrumentedStore reports the duration and outcome of each call to the
+// underlying store.
+type instrumentedStore struct {
+	store     ratify.Store
+	storeType string
+}
+
+// InstrumentStore wraps the store to report the duration and outcome of its
+// operations labelled with storeType.
+func InstrumentStore(store ratify.Store, storeType string) ratify.Store {
+	if store == nil {
+		return nil
+	}
+	return &instrumentedStore{
+		store:     store,
+		storeType: storeType,
+	}
+}
+
+// Resolve implements [ratify.Store].
+func (s *instrumentedStore) Resolve(ctx context.Context, ref string) (ocispec.Descriptor, error) {
+	start := time.Now()
+	desc, err := s.store.Resolve(ctx, ref)
+	ReportStoreDuration(ctx, time.Since(start), s.storeType, operationResolve, err != nil)
+	return desc, err
+}
+
+// ListReferrers implements [ratify.Store].
+func (s *instrumentedStore) ListReferrers(ctx context.Context, ref string, artifactTypes []string, fn func(referrers []ocispec.Descriptor) error) error {
+	start := time.Now()
+	err := s.store.ListReferrers(ctx, ref, artifactTypes, fn)
+	ReportStoreDuration(ctx, time.Since(start), s.storeType, operationListReferrers, err != nil)
+	return err
+}
+
+// FetchBlob implements [ratify.Store].
+func (s *instrumentedStore) FetchBlob(ctx context.Context, repo string, desc ocispec.Descriptor) ([]byte, error) {
+	start := time.Now()
+	blob, err := s.store.FetchBlob(ctx, repo, desc)
+	ReportStoreDuration(ctx, time.Since(start), s.storeType, operationFetchBlob, err != nil)
+	return blob, err
+}
+
+// FetchManifest implements [ratify.Store].
+func (s *instrumentedStore) FetchManifest(ctx context.Context, repo string, desc ocispec.Descriptor) ([]byte, error) {
+	start := time.Now()
+	manifest, err := s.store.FetchManifest(ctx, repo, desc)
+	ReportStoreDuration(ctx, time.Since(start), s.storeType, operationFetchManifest, err != nil)
+	return manifest, err
+}
+
+// instrumentedVerifier reports the duration and outcome of each verification
+// of the underlying verifier.
+type instrumentedVerifier struct {
+	ratify.Verifier
+}
+
+// InstrumentVerifier wraps the verifier to report the duration and outcome of
+// its verifications.
+func InstrumentVerifier(verifier ratify.Verifier) ratify.Verifier {
+	if verifier == nil {
+		return nil
+	}
+	return &instrumentedVerifier{Verifier: verifier}
+}
+
+// Verify implements [ratify.Verifier].
+func (v *instrumentedVerifier) Verify(ctx context.Context, opts *ratify.VerifyOptions) (*ratify.VerificationResult, error) {
+	start := time.Now()
+	result, err := v.Verifier.Verify(ctx, opts)
+	duration := time.Since(start)
+	success := err == nil && result != nil && result.Err == nil
+	ReportVerifierDuration(ctx, duration, v.Name(), v.Type(), success, err != nil)
+	if opts != nil {
+		recordVerifierTiming(ctx, opts.ArtifactDescriptor.Digest, v.Name(), duration)
+	}
+	return result, err
+}
+
+// instrumentedTransport reports each request to a registry with the status
+// code of its response.
+type instrumentedTransport struct {
+	base http.RoundTripper
+}
+
+// InstrumentTransport wraps the transport to report the requests sent to
+// registries. [http.DefaultTransport] is wrapped if base is nil.
+func InstrumentTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &instrumentedTransport{base: base}
+}
+
+// RoundTrip implements [http.RoundTripper].
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		ReportRegistryRequestCount(req.Context(), resp.StatusCode, req.URL.Host)
+	}
+	return resp, err
+}
@@ -0,0 +1,178 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/ratify-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+type mockStore struct {
+	err error
+}
+
+func (m *mockStore) Resolve(_ context.Context, _ string) (ocispec.Descriptor, error) {
+	return ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest}, m.err
+}
+
+func (m *mockStore) ListReferrers(_ context.Context, _ string, _ []string, _ func(referrers []ocispec.Descriptor) error) error {
+	return m.err
+}
+
+func (m *mockStore) FetchBlob(_ context.Context, _ string, _ ocispec.Descriptor) ([]byte, error) {
+	return []byte("blob"), m.err
+}
+
+func (m *mockStore) FetchManifest(_ context.Context, _ string, _ ocispec.Descriptor) ([]byte, error) {
+	return []byte("manifest"), m.err
+}
+
+type mockVerifier struct {
+	result *ratify.VerificationResult
+	err    error
+}
+
+func (m *mockVerifier) Name() string {
+	return "mock-verifier"
+}
+
+func (m *mockVerifier) Type() string {
+	return "mock-type"
+}
+
+func (m *mockVerifier) Verifiable(_ ocispec.Descriptor) bool {
+	return true
+}
+
+func (m *mockVerifier) Verify(_ context.Context, _ *ratify.VerifyOptions) (*ratify.VerificationResult, error) {
+	return m.result, m.err
+}
+
+func TestInstrumentStore(t *testing.T) {
+	if InstrumentStore(nil, "mock") != nil {
+		t.Fatalf("expected nil store to stay nil")
+	}
+
+	handler, err := NewPrometheusHandler()
+	if err != nil {
+		t.Fatalf("failed to create handler: %v", err)
+	}
+	ctx := context.Background()
+	store := InstrumentStore(&mockStore{}, "mock-store")
+	desc, err := store.Resolve(ctx, "registry.example.com/test:v1")
+	if err != nil || desc.MediaType != ocispec.MediaTypeImageManifest {
+		t.Fatalf("unexpected resolve result: %v, %v", desc, err)
+	}
+	if blob, err := store.FetchBlob(ctx, "registry.example.com/test", desc); err != nil || string(blob) != "blob" {
+		t.Fatalf("unexpected fetch blob result: %s, %v", blob, err)
+	}
+	failingStore := InstrumentStore(&mockStore{err: errors.New("failed")}, "mock-store")
+	if err := failingStore.ListReferrers(ctx, "registry.example.com/test:v1", nil, nil); err == nil {
+		t.Fatalf("expected error from list referrers")
+	}
+
+	body := scrape(t, handler)
+	expected := []string{
+		`ratify_store_duration_count{error="false",operation="resolve",store_type="mock-store"} 1`,
+		`ratify_store_duration_count{error="false",operation="fetch_blob",store_type="mock-store"} 1`,
+		`ratify_store_duration_count{error="true",operation="list_referrers",store_type="mock-store"} 1`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestInstrumentVerifier(t *testing.T) {
+	if InstrumentVerifier(nil) != nil {
+		t.Fatalf("expected nil verifier to stay nil")
+	}
+
+	handler, err := NewPrometheusHandler()
+	if err != nil {
+		t.Fatalf("failed to create handler: %v", err)
+	}
+	ctx := context.Background()
+	tests := []*mockVerifier{
+		{result: &ratify.VerificationResult{}},
+		{result: &ratify.VerificationResult{Err: errors.New("invalid signature")}},
+		{err: errors.New("failed")},
+	}
+	for _, mock := range tests {
+		verifier := InstrumentVerifier(mock)
+		if verifier.Name() != mock.Name() || verifier.Type() != mock.Type() {
+			t.Fatalf("expected name and type to be passed through")
+		}
+		result, err := verifier.Verify(ctx, &ratify.VerifyOptions{})
+		if result != mock.result || err != mock.err {
+			t.Fatalf("expected result and error to be passed through")
+		}
+	}
+
+	body := scrape(t, handler)
+	expected := []string{
+		`ratify_verifier_duration_count{error="false",success="true",verifier="mock-verifier",verifier_type="mock-type",workload_namespace=""} 1`,
+		`ratify_verifier_duration_count{error="false",success="false",verifier="mock-verifier",verifier_type="mock-type",workload_namespace=""} 1`,
+		`ratify_verifier_duration_count{error="true",success="false",verifier="mock-verifier",verifier_type="mock-type",workload_namespace=""} 1`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestInstrumentTransport(t *testing.T) {
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/throttled" {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer registry.Close()
+
+	handler, err := NewPrometheusHandler()
+	if err != nil {
+		t.Fatalf("failed to create handler: %v", err)
+	}
+	client := &http.Client{Transport: InstrumentTransport(nil)}
+	for _, path := range []string{"/v2/", "/v2/throttled"} {
+		resp, err := client.Get(registry.URL + path)
+		if err != nil {
+			t.Fatalf("failed to send request: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	host := strings.TrimPrefix(registry.URL, "http://")
+	body := scrape(t, handler)
+	expected := []string{
+		`ratify_registry_request_count_total{registry_host="` + host + `",status_code="200",workload_namespace=""} 1`,
+		`ratify_registry_request_count_total{registry_host="` + host + `",status_code="429",workload_namespace=""} 1`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"time"
+
+	ctxUtils "github.com/notaryproject/ratify/v2/internal/context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+const scope = "github.com/notaryproject/ratify/v2"
+
+// Metric names. The names and attributes of the request, verifier, system
+// error, registry, blob cache and Azure metrics are shared with Ratify v1 so
+// that the dashboards in the instrumentation directory keep working.
+const (
+	metricNameVerificationDuration   = "ratify_verification_request"
+	metricNameMutationDuration       = "ratify_mutation_request"
+	metricNameVerifierDuration       = "ratify_verifier_duration"
+	metricNameSystemErrorCount       = "ratify_system_error_count"
+	metricNameRegistryRequestCount   = "ratify_registry_request_count"
+	metricNameBlobCacheCount         = "ratify_blob_cache_count"
+	metricNameAADExchangeDuration    = "ratify_aad_exchange_duration"
+	metricNameACRExchangeDuration    = "ratify_acr_exchange_duration"
+	metricNameAKVCertificateDuration = "ratify_akv_certificate_duration"
+	metricNameStoreDuration          = "ratify_store_duration"
+	metricNameCacheCount             = "ratify_cache_count"
+	metricNameSingleflightCount      = "ratify_singleflight_count"
+	metricNameCredentialRefresh      = "ratify_credential_refresh_count"
+)
+
+// Cache names used as the value of the cache attribute.
+const (
+	CacheVerify      = "verify"
+	CacheMutate      = "mutate"
+	CacheValidations = "validations"
+)
+
+var (
+	verificationDuration   metric.Int64Histogram
+	mutationDuration       metric.Int64Histogram
+	verifierDuration       metric.Int64Histogram
+	systemErrorCount       metric.Int64UpDownCounter
+	registryRequestCount   metric.Int64Counter
+	blobCacheCount         metric.Int64Counter
+	aadExchangeDuration    metric.Int64Histogram
+	acrExchangeDuration    metric.Int64Histogram
+	akvCertificateDuration metric.Int64Histogram
+	storeDuration          metric.Int64Histogram
+	cacheCount             metric.Int64Counter
+	singleflightCount      metric.Int64Counter
+	credentialRefreshCount metric.Int64Counter
+)
+
+// views defines the histogram boundaries in milliseconds. The boundaries are
+// aligned with the verify and mutate timeouts of Gatekeeper.
+func views() []sdkmetric.View {
+	newView := func(name string, boundaries ...float64) sdkmetric.View {
+		return sdkmetric.NewView(
+			sdkmetric.Instrument{
+				Name:  name,
+				Scope: instrumentation.Scope{Name: scope},
+			},
+			sdkmetric.Stream{
+				Aggregation: sdkmetric.AggregationExplicitBucketHistogram{Boundaries: boundaries},
+			},
+		)
+	}
+	return []sdkmetric.View{
+		newView(metricNameVerificationDuration, 0, 10, 30, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1400, 1600, 1800, 2000, 2300, 2600, 4000, 4400, 4900),
+		newView(metricNameMutationDuration, 0, 10, 30, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200, 1400, 1600, 1800),
+		newView(metricNameVerifierDuration, 0, 10, 50, 100, 200, 300, 400, 600, 800, 1100, 1500, 2000),
+		newView(metricNameStoreDuration, 0, 10, 50, 100, 200, 300, 400, 600, 800, 1100, 1500, 2000),
+		newView(metricNameAADExchangeDuration, 0, 10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1200),
+		newView(metricNameACRExchangeDuration, 0, 10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1200),
+		newView(metricNameAKVCertificateDuration, 0, 10, 50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1200),
+	}
+}
+
+// initInstruments creates the instruments from the meter provider.
+func initInstruments(provider metric.MeterProvider) error {
+	meter := provider.Meter(scope)
+	var err error
+	if verificationDuration, err = meter.Int64Histogram(metricNameVerificationDuration, metric.WithUnit("ms"), metric.WithDescription("verification request duration in ms")); err != nil {
+		return err
+	}
+	if mutationDuration, err = meter.Int64Histogram(metricNameMutationDuration, metric.WithUnit("ms"), metric.WithDescription("mutation request duration in ms")); err != nil {
+		return err
+	}
+	if verifierDuration, err = meter.Int64Histogram(metricNameVerifierDuration, metric.WithUnit("ms"), metric.WithDescription("verifier duration in ms")); err != nil {
+		return err
+	}
+	// The system error count is an up-down counter so that it is exported
+	// without the _total suffix, as queried by the dashboards.
+	if systemErrorCount, err = meter.Int64UpDownCounter(metricNameSystemErrorCount, metric.WithDescription("system error count")); err != nil {
+		return err
+	}
+	if registryRequestCount, err = meter.Int64Counter(metricNameRegistryRequestCount, metric.WithDescription("registry request count")); err != nil {
+		return err
+	}
+	if blobCacheCount, err = meter.Int64Counter(metricNameBlobCacheCount, metric.WithDescription("blob cache hit/miss count")); err != nil {
+		return err
+	}
+	if aadExchangeDuration, err = meter.Int64Histogram(metricNameAADExchangeDuration, metric.WithUnit("ms"), metric.WithDescription("AAD exchange duration in ms")); err != nil {
+		return err
+	}
+	if acrExchangeDuration, err = meter.Int64Histogram(metricNameACRExchangeDuration, metric.WithUnit("ms"), metric.WithDescription("ACR exchange duration in ms")); err != nil {
+		return err
+	}
+	if akvCertificateDuration, err = meter.Int64Histogram(metricNameAKVCertificateDuration, metric.WithUnit("ms"), metric.WithDescription("AKV certificate duration in ms")); err != nil {
+		return err
+	}
+	if storeDuration, err = meter.Int64Histogram(metricNameStoreDuration, metric.WithUnit("ms"), metric.WithDescription("store operation duration in ms")); err != nil {
+		return err
+	}
+	if cacheCount, err = meter.Int64Counter(metricNameCacheCount, metric.WithDescription("verify and mutate cache hit/miss count")); err != nil {
+		return err
+	}
+	if singleflightCount, err = meter.Int64Counter(metricNameSingleflightCount, metric.WithDescription("count of requests de-duplicated by singleflight")); err != nil {
+		return err
+	}
+	if credentialRefreshCount, err = meter.Int64Counter(metricNameCredentialRefresh, metric.WithDescription("credential provider refresh count")); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReportVerificationRequest reports the duration of a verification request.
+func ReportVerificationRequest(ctx context.Context, duration time.Duration) {
+	if verificationDuration != nil {
+		verificationDuration.Record(ctx, duration.Milliseconds())
+	}
+}
+
+// ReportMutationRequest reports the duration of a mutation request.
+func ReportMutationRequest(ctx context.Context, duration time.Duration) {
+	if mutationDuration != nil {
+		mutationDuration.Record(ctx, duration.Milliseconds())
+	}
+}
+
+// ReportVerifierDuration reports the duration of a single verifier execution.
+// Attributes:
+// verifier: the name of the verifier
+// verifier_type: the type of the verifier
+// success: whether the verification succeeded
+// error: whether the verification failed due to an error
+// workload_namespace: the namespace where the workload is deployed
+func ReportVerifierDuration(ctx context.Context, duration time.Duration, verifierName, verifierType string, success, isError bool) {
+	if verifierDuration != nil {
+		verifierDuration.Record(ctx, duration.Milliseconds(), metric.WithAttributes(
+			attribute.String("verifier", verifierName),
+			attribute.String("verifier_type", verifierType),
+			attribute.Bool("success", success),
+			attribute.Bool("error", isError),
+			attribute.String("workload_namespace", ctxUtils.GetNamespace(ctx)),
+		))
+	}
+}
+
+// ReportSystemError reports an error failing a whole request.
+// Attributes:
+// error: the error message
+// workload_namespace: the namespace where the workload is deployed
+func ReportSystemError(ctx context.Context, errorString string) {
+	if systemErrorCount != nil {
+		systemErrorCount.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("error", errorString),
+			attribute.String("workload_namespace", ctxUtils.GetNamespace(ctx)),
+		))
+	}
+}
+
+// ReportRegistryRequestCount reports a request to a registry.
+// Attributes:
+// status_code: the status code of the response
+// registry_host: the host name of the registry
+// workload_namespace: the namespace where the workload is deployed
+func ReportRegistryRequestCount(ctx context.Context, statusCode int, registryHost string) {
+	if registryRequestCount != nil {
+		registryRequestCount.Add(ctx, 1, metric.WithAttributes(
+			attribute.Int("status_code", statusCode),
+			attribute.String("registry_host", registryHost),
+			attribute.String("workload_namespace", ctxUtils.GetNamespace(ctx)),
+		))
+	}
+}
+
+// ReportBlobCacheCount reports a blob cache hit or miss.
+// Attributes:
+// hit: whether the blob was found in the cache
+// workload_namespace: the namespace where the workload is deployed
+func ReportBlobCacheCount(ctx context.Context, hit bool) {
+	if blobCacheCount != nil {
+		blobCacheCount.Add(ctx, 1, metric.WithAttributes(
+			attribute.Bool("hit", hit),
+			attribute.String("workload_namespace", ctxUtils.GetNamespace(ctx)),
+		))
+	}
+}
+
+// ReportAADExchangeDuration reports the duration of an AAD token request.
+// Attributes:
+// resource_type: the resource the token is requested for, e.g. ACR
+// workload_namespace: the namespace where the workload is deployed
+func ReportAADExchangeDuration(ctx context.Context, duration time.Duration, resourceType string) {
+	if aadExchangeDuration != nil {
+		aadExchangeDuration.Record(ctx, duration.Milliseconds(), metric.WithAttributes(
+			attribute.String("resource_type", resourceType),
+			attribute.String("workload_namespace", ctxUtils.GetNamespace(ctx)),
+		))
+	}
+}
+
+// ReportACRExchangeDuration reports the duration of exchanging an AAD token
+// for an ACR refresh token.
+// Attributes:
+// repository: the registry the refresh token is requested for
+// workload_namespace: the namespace where the workload is deployed
+func ReportACRExchangeDuration(ctx context.Context, duration time.Duration, repository string) {
+	if acrExchangeDuration != nil {
+		acrExchangeDuration.Record(ctx, duration.Milliseconds(), metric.WithAttributes(
+			attribute.String("repository", repository),
+			attribute.String("workload_namespace", ctxUtils.GetNamespace(ctx)),
+		))
+	}
+}
+
+// ReportAKVCertificateDuration reports the duration of fetching a certificate
+// from Azure Key Vault.
+// Attributes:
+// certificate_name: the name of the certificate
+// workload_namespace: the namespace where the workload is deployed
+func ReportAKVCertificateDuration(ctx context.Context, duration time.Duration, certificateName string) {
+	if akvCertificateDuration != nil {
+		akvCertificateDuration.Record(ctx, duration.Milliseconds(), metric.WithAttributes(
+			attribute.String("certificate_name", certificateName),
+			attribute.String("workload_namespace", ctxUtils.GetNamespace(ctx)),
+		))
+	}
+}
+
+// ReportStoreDuration reports the duration of a single store operation.
+// Attributes:
+// store_type: the type of the store
+// operation: the store method, e.g. resolve or list_referrers
+// error: whether the operation failed
+func ReportStoreDuration(ctx context.Context, duration time.Duration, storeType, operation string, isError bool) {
+	if storeDuration != nil {
+		storeDuration.Record(ctx, duration.Milliseconds(), metric.WithAttributes(
+			attribute.String("store_type", storeType),
+			attribute.String("operation", operation),
+			attribute.Bool("error", isError),
+		))
+	}
+}
+
+// ReportCacheCount reports a cache hit or miss.
+// Attributes:
+// cache: the name of the cache, either verify or mutate
+// hit: whether the entry was found in the cache
+func ReportCacheCount(ctx context.Context, cacheName string, hit bool) {
+	if cacheCount != nil {
+		cacheCount.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("cache", cacheName),
+			attribute.Bool("hit", hit),
+		))
+	}
+}
+
+// ReportSingleflightCount reports a call through singleflight.
+// Attributes:
+// cache: the name of the cache the call populates, either verify or mutate
+// shared: whether the result was shared with other callers
+func ReportSingleflightCount(ctx context.Context, cacheName string, shared bool) {
+	if singleflightCount != nil {
+		singleflightCount.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("cache", cacheName),
+			attribute.Bool("shared", shared),
+		))
+	}
+}
+
+// ReportCredentialRefresh reports a credential fetched from a credential
+// provider on a cache miss.
+// Attributes:
+// provider: the type of the credential provider
+// success: whether the credential was fetched successfully
+func ReportCredentialRefresh(ctx context.Context, providerType string, success bool) {
+	if credentialRefreshCount != nil {
+		credentialRefreshCount.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("provider", providerType),
+			attribute.Bool("success", success),
+		))
+	}
+}
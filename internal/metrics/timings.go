@@ -0,0 +1,72 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+type timingsContextKey struct{}
+
+// timingKey identifies a verification of an artifact by a verifier.
+type timingKey struct {
+	artifact digest.Digest
+	verifier string
+}
+
+// VerifierTimings collects the duration of each verification performed by
+// the instrumented verifiers within a context. It is safe for concurrent use.
+type VerifierTimings struct {
+	mu        sync.Mutex
+	durations map[timingKey]time.Duration
+}
+
+// WithVerifierTimings returns a context that collects the verification
+// durations into the returned [VerifierTimings].
+func WithVerifierTimings(ctx context.Context) (context.Context, *VerifierTimings) {
+	timings := &VerifierTimings{
+		durations: make(map[timingKey]time.Duration),
+	}
+	return context.WithValue(ctx, timingsContextKey{}, timings), timings
+}
+
+// Get returns the duration of the verification of the artifact by the named
+// verifier.
+func (t *VerifierTimings) Get(artifact digest.Digest, verifierName string) (time.Duration, bool) {
+	if t == nil {
+		return 0, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	duration, ok := t.durations[timingKey{artifact: artifact, verifier: verifierName}]
+	return duration, ok
+}
+
+// recordVerifierTiming records the duration into the [VerifierTimings] of ctx,
+// if any.
+func recordVerifierTiming(ctx context.Context, artifact digest.Digest, verifierName string, duration time.Duration) {
+	timings, ok := ctx.Value(timingsContextKey{}).(*VerifierTimings)
+	if !ok {
+		return
+	}
+	timings.mu.Lock()
+	defer timings.mu.Unlock()
+	timings.durations[timingKey{artifact: artifact, verifier: verifierName}] = duration
+}
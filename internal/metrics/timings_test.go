@@ -0,0 +1,58 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestVerifierTimings(t *testing.T) {
+	artifact := digest.FromString("signature")
+	verifier := InstrumentVerifier(&mockVerifier{result: &ratify.VerificationResult{}})
+
+	// Verifications outside of a collecting context are not recorded.
+	if _, err := verifier.Verify(context.Background(), &ratify.VerifyOptions{
+		ArtifactDescriptor: ocispec.Descriptor{Digest: artifact},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, timings := WithVerifierTimings(context.Background())
+	if _, ok := timings.Get(artifact, "mock-verifier"); ok {
+		t.Fatalf("expected no timing before verification")
+	}
+	if _, err := verifier.Verify(ctx, &ratify.VerifyOptions{
+		ArtifactDescriptor: ocispec.Descriptor{Digest: artifact},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := timings.Get(artifact, "mock-verifier"); !ok {
+		t.Errorf("expected timing of the verification to be recorded")
+	}
+	if _, ok := timings.Get(digest.FromString("other"), "mock-verifier"); ok {
+		t.Errorf("expected no timing for another artifact")
+	}
+
+	var nilTimings *VerifierTimings
+	if _, ok := nilTimings.Get(artifact, "mock-verifier"); ok {
+		t.Errorf("expected no timing from nil timings")
+	}
+}
@@ -27,16 +27,25 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/notaryproject/ratify-go"
 	"github.com/notaryproject/ratify/v2/internal/cloudprovider/azure"
+	"github.com/notaryproject/ratify/v2/internal/errcode"
+	"github.com/notaryproject/ratify/v2/internal/metrics"
 	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+	"github.com/notaryproject/ratify/v2/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
+	azureProviderType = "azure"
+
 	// GrantTypeAccessToken is the grant type for AAD access token
 	GrantTypeAccessToken = "access_token"
 
 	// AADResource is the Azure Container Registry resource scope
 	AADResource = "https://containerregistry.azure.net/.default"
 
+	// aadResourceTypeACR is the resource type the AAD token is reported for.
+	aadResourceTypeACR = "ACR"
+
 	// DefaultACRTokenTTL is the default TTL for ACR refresh tokens
 	// ACR refresh tokens typically expire in 3 hours, we set a shorter TTL for safety
 	DefaultACRTokenTTL = 3*time.Hour - 5*time.Minute
@@ -61,7 +70,7 @@ type IdentityProviderOptions struct {
 
 func init() {
 	// Register the Azure identity provider factory
-	credentialprovider.RegisterCredentialProviderFactory("azure", createAzureIdentityProvider)
+	credentialprovider.RegisterCredentialProviderFactory(azureProviderType, createAzureIdentityProvider)
 }
 
 // createAzureIdentityProvider creates a new Azure identity provider from
@@ -85,7 +94,7 @@ func createAzureIdentityProvider(opts credentialprovider.Options) (ratify.Regist
 	}
 
 	// Wrap with caching provider
-	return credentialprovider.NewCachedProvider(azureProvider)
+	return credentialprovider.NewCachedProvider(azureProviderType, azureOpts.TenantID+"/"+azureOpts.ClientID, azureProvider)
 }
 
 // GetWithTTL implements credentialprovider.CredentialSourceProvider interface.
@@ -95,13 +104,13 @@ func (p *IdentityProvider) GetWithTTL(ctx context.Context, serverAddress string)
 	// managed identity.
 	chain, err := azure.CreateCredentialChain(p.clientID, p.tenantID)
 	if err != nil {
-		return credentialprovider.CredentialWithTTL{}, fmt.Errorf("failed to create credential chain: %w", err)
+		return credentialprovider.CredentialWithTTL{}, errcode.RegistryAuthFailed.Errorf("failed to create credential chain: %w", err)
 	}
 
 	// Step 2: Exchange an AAD token for an ACR refresh token using ExchangeAADAccessTokenForACRRefreshToken
 	acrRefreshToken, err := p.exchangeAADTokenForACRToken(ctx, chain, serverAddress)
 	if err != nil {
-		return credentialprovider.CredentialWithTTL{}, fmt.Errorf("failed to exchange AAD token for ACR refresh token: %w", err)
+		return credentialprovider.CredentialWithTTL{}, errcode.RegistryAuthFailed.Errorf("failed to exchange AAD token for ACR refresh token: %w", err)
 	}
 
 	// Step 3: Parse the JWT token to extract the actual TTL
@@ -123,9 +132,13 @@ func (p *IdentityProvider) GetWithTTL(ctx context.Context, serverAddress string)
 // token.
 func (p *IdentityProvider) exchangeAADTokenForACRToken(ctx context.Context, credential azcore.TokenCredential, serverAddress string) (string, error) {
 	// Get an AAD access token
-	token, err := credential.GetToken(ctx, policy.TokenRequestOptions{
+	aadCtx, aadSpan := tracing.StartSpan(ctx, "azure.GetAADToken")
+	start := time.Now()
+	token, err := credential.GetToken(aadCtx, policy.TokenRequestOptions{
 		Scopes: []string{AADResource},
 	})
+	metrics.ReportAADExchangeDuration(ctx, time.Since(start), aadResourceTypeACR)
+	tracing.EndSpan(aadSpan, err)
 	if err != nil {
 		return "", fmt.Errorf("failed to get AAD access token: %w", err)
 	}
@@ -138,8 +151,10 @@ func (p *IdentityProvider) exchangeAADTokenForACRToken(ctx context.Context, cred
 	}
 
 	// Exchange AAD token for ACR refresh token
+	acrCtx, acrSpan := tracing.StartSpan(ctx, "azure.ExchangeACRRefreshToken", attribute.String("ratify.registry", serverAddress))
+	start = time.Now()
 	response, err := client.ExchangeAADAccessTokenForACRRefreshToken(
-		ctx,
+		acrCtx,
 		azcontainerregistry.PostContentSchemaGrantType(GrantTypeAccessToken),
 		serverAddress,
 		&azcontainerregistry.AuthenticationClientExchangeAADAccessTokenForACRRefreshTokenOptions{
@@ -147,6 +162,8 @@ func (p *IdentityProvider) exchangeAADTokenForACRToken(ctx context.Context, cred
 			Tenant:      &p.tenantID,
 		},
 	)
+	metrics.ReportACRExchangeDuration(ctx, time.Since(start), serverAddress)
+	tracing.EndSpan(acrSpan, err)
 	if err != nil {
 		return "", fmt.Errorf("failed to exchange AAD token for ACR refresh token: %w", err)
 	}
@@ -17,11 +17,16 @@ package credentialprovider
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/notaryproject/ratify-go"
 	"github.com/notaryproject/ratify/v2/internal/cache"
 	"github.com/notaryproject/ratify/v2/internal/cache/inmemory"
+	"github.com/notaryproject/ratify/v2/internal/errcode"
+	"github.com/notaryproject/ratify/v2/internal/metrics"
+	"github.com/notaryproject/ratify/v2/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // CredentialWithTTL represents a credential response with its expiration time.
@@ -37,23 +42,54 @@ type CredentialSourceProvider interface {
 	GetWithTTL(ctx context.Context, serverAddress string) (CredentialWithTTL, error)
 }
 
+var (
+	sharedCacheMu sync.RWMutex
+	// sharedCache is the cache shared by all cached providers, or nil if each
+	// provider caches its credentials in memory.
+	sharedCache cache.Cache[ratify.RegistryCredential]
+)
+
+// SetSharedCache configures all cached providers to store their credentials
+// in the given cache, e.g. a distributed cache shared by multiple replicas.
+// The credentials of each provider are namespaced by the provider type and
+// identity. A nil cache restores the in-memory cache of each provider.
+func SetSharedCache(c cache.Cache[ratify.RegistryCredential]) {
+	sharedCacheMu.Lock()
+	defer sharedCacheMu.Unlock()
+	sharedCache = c
+}
+
+func getSharedCache() cache.Cache[ratify.RegistryCredential] {
+	sharedCacheMu.RLock()
+	defer sharedCacheMu.RUnlock()
+	return sharedCache
+}
+
 // CachedProvider wraps a CredentialSourceProvider and provides caching functionality.
 // It implements the ratify.RegistryCredentialGetter interface.
 type CachedProvider struct {
-	source CredentialSourceProvider
-	cache  cache.Cache[ratify.RegistryCredential]
+	providerType string
+	source       CredentialSourceProvider
+	cache        cache.Cache[ratify.RegistryCredential]
+	// namespace prefixes the keys of the credentials in the shared cache.
+	namespace string
 }
 
 // NewCachedProvider creates a new cached credential provider that wraps the given source provider.
-func NewCachedProvider(source CredentialSourceProvider) (*CachedProvider, error) {
+// The providerType is used to label the refresh metrics of the provider. The
+// identity distinguishes the credentials of providers of the same type in the
+// shared cache, e.g. the client ID of a workload identity.
+func NewCachedProvider(providerType, identity string, source CredentialSourceProvider) (*CachedProvider, error) {
 	cache, err := inmemory.NewCache[ratify.RegistryCredential](10)
 	if err != nil {
 		return nil, err
 	}
 
 	return &CachedProvider{
-		source: source,
-		cache:  cache,
+		providerType: providerType,
+		source:       source,
+		cache:        cache,
+		namespace:    providerType + ":" + identity + ":",
 	}, nil
 }
 
@@ -62,20 +98,41 @@ func NewCachedProvider(source CredentialSourceProvider) (*CachedProvider, error)
 // new credentials from the source provider and caches them.
 func (c *CachedProvider) Get(ctx context.Context, serverAddress string) (ratify.RegistryCredential, error) {
 	// Check if we have a cached credential
-	if credential, err := c.cache.Get(ctx, serverAddress); err == nil {
+	credentialCache, key := c.cacheFor(serverAddress)
+	if credential, err := credentialCache.Get(ctx, key); err == nil {
 		return credential, nil
 	}
 
 	// Cache miss, fetch new credentials
+	ctx, span := tracing.StartSpan(ctx, "credentialprovider.Refresh",
+		attribute.String("ratify.credential.provider", c.providerType),
+		attribute.String("ratify.registry", serverAddress),
+	)
 	credWithTTL, err := c.source.GetWithTTL(ctx, serverAddress)
+	tracing.EndSpan(span, err)
+	metrics.ReportCredentialRefresh(ctx, c.providerType, err == nil)
 	if err != nil {
+		// Report failures the source did not classify, e.g. a rejected
+		// identity, as authentication failures.
+		if errcode.Classify(err) == errcode.Unknown {
+			err = errcode.RegistryAuthFailed.Wrap(err)
+		}
 		return ratify.RegistryCredential{}, err
 	}
 
 	if credWithTTL.TTL > 0 {
 		defer func() {
-			_ = c.cache.Set(ctx, serverAddress, credWithTTL.Credential, credWithTTL.TTL)
+			_ = credentialCache.Set(ctx, key, credWithTTL.Credential, credWithTTL.TTL)
 		}()
 	}
 	return credWithTTL.Credential, nil
 }
+
+// cacheFor returns the cache storing the credential of the server address and
+// the key of the credential in that cache.
+func (c *CachedProvider) cacheFor(serverAddress string) (cache.Cache[ratify.RegistryCredential], string) {
+	if shared := getSharedCache(); shared != nil {
+		return shared, c.namespace + serverAddress
+	}
+	return c.cache, serverAddress
+}
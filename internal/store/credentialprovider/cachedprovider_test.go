@@ -22,6 +22,8 @@ import (
 	"time"
 
 	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/cache/inmemory"
+	"github.com/notaryproject/ratify/v2/internal/errcode"
 )
 
 const testServerAddress = "registry.example.com"
@@ -75,10 +77,17 @@ func (m *mockCredentialSourceProvider) getCallCount(serverAddress string) int {
 	return m.callCount[serverAddress]
 }
 
+// credentialSourceFunc adapts a function to a CredentialSourceProvider.
+type credentialSourceFunc func(ctx context.Context, serverAddress string) (CredentialWithTTL, error)
+
+func (f credentialSourceFunc) GetWithTTL(ctx context.Context, serverAddress string) (CredentialWithTTL, error) {
+	return f(ctx, serverAddress)
+}
+
 func TestNewCachedProvider(t *testing.T) {
 	mockSource := newMockCredentialSourceProvider()
 
-	provider, err := NewCachedProvider(mockSource)
+	provider, err := NewCachedProvider("mock", "", mockSource)
 	if err != nil {
 		t.Fatalf("Failed to create cached provider: %v", err)
 	}
@@ -98,7 +107,7 @@ func TestNewCachedProvider(t *testing.T) {
 
 func TestCachedProvider_Get_CacheMiss(t *testing.T) {
 	mockSource := newMockCredentialSourceProvider()
-	provider, err := NewCachedProvider(mockSource)
+	provider, err := NewCachedProvider("mock", "", mockSource)
 	if err != nil {
 		t.Fatalf("Failed to create cached provider: %v", err)
 	}
@@ -134,7 +143,7 @@ func TestCachedProvider_Get_CacheMiss(t *testing.T) {
 
 func TestCachedProvider_Get_CacheHit(t *testing.T) {
 	mockSource := newMockCredentialSourceProvider()
-	provider, err := NewCachedProvider(mockSource)
+	provider, err := NewCachedProvider("mock", "", mockSource)
 	if err != nil {
 		t.Fatalf("Failed to create cached provider: %v", err)
 	}
@@ -177,7 +186,7 @@ func TestCachedProvider_Get_SourceError(t *testing.T) {
 	mockSource := newMockCredentialSourceProvider()
 	mockSource.setError(true, "source provider error")
 
-	provider, err := NewCachedProvider(mockSource)
+	provider, err := NewCachedProvider("mock", "", mockSource)
 	if err != nil {
 		t.Fatalf("Failed to create cached provider: %v", err)
 	}
@@ -194,9 +203,52 @@ func TestCachedProvider_Get_SourceError(t *testing.T) {
 	}
 }
 
+func TestCachedProvider_Get_SourceErrorCode(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode errcode.Code
+	}{
+		{
+			name:         "unclassified error",
+			err:          errors.New("identity rejected"),
+			expectedCode: errcode.RegistryAuthFailed,
+		},
+		{
+			name:         "classified error",
+			err:          errcode.RegistryUnavailable.Wrap(errors.New("token endpoint unavailable")),
+			expectedCode: errcode.RegistryUnavailable,
+		},
+		{
+			name:         "timeout",
+			err:          context.DeadlineExceeded,
+			expectedCode: errcode.Timeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewCachedProvider("mock", "", credentialSourceFunc(func(context.Context, string) (CredentialWithTTL, error) {
+				return CredentialWithTTL{}, tt.err
+			}))
+			if err != nil {
+				t.Fatalf("Failed to create cached provider: %v", err)
+			}
+
+			_, err = provider.Get(context.Background(), testServerAddress)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+			if code := errcode.Classify(err); code != tt.expectedCode {
+				t.Errorf("Expected code %s, got %s", tt.expectedCode, code)
+			}
+		})
+	}
+}
+
 func TestCachedProvider_Get_ZeroTTL(t *testing.T) {
 	mockSource := newMockCredentialSourceProvider()
-	provider, err := NewCachedProvider(mockSource)
+	provider, err := NewCachedProvider("mock", "", mockSource)
 	if err != nil {
 		t.Fatalf("Failed to create cached provider: %v", err)
 	}
@@ -233,7 +285,7 @@ func TestCachedProvider_Get_ZeroTTL(t *testing.T) {
 
 func TestCachedProvider_Get_MultipleServers(t *testing.T) {
 	mockSource := newMockCredentialSourceProvider()
-	provider, err := NewCachedProvider(mockSource)
+	provider, err := NewCachedProvider("mock", "", mockSource)
 	if err != nil {
 		t.Fatalf("Failed to create cached provider: %v", err)
 	}
@@ -301,7 +353,7 @@ func TestCachedProvider_Get_MultipleServers(t *testing.T) {
 
 func TestCachedProvider_Get_ContextCancellation(t *testing.T) {
 	mockSource := newMockCredentialSourceProvider()
-	provider, err := NewCachedProvider(mockSource)
+	provider, err := NewCachedProvider("mock", "", mockSource)
 	if err != nil {
 		t.Fatalf("Failed to create cached provider: %v", err)
 	}
@@ -317,7 +369,7 @@ func TestCachedProvider_Get_ContextCancellation(t *testing.T) {
 
 func TestCachedProvider_Get_EmptyServerAddress(t *testing.T) {
 	mockSource := newMockCredentialSourceProvider()
-	provider, err := NewCachedProvider(mockSource)
+	provider, err := NewCachedProvider("mock", "", mockSource)
 	if err != nil {
 		t.Fatalf("Failed to create cached provider: %v", err)
 	}
@@ -338,7 +390,7 @@ func TestCachedProvider_Get_EmptyServerAddress(t *testing.T) {
 
 func TestCachedProvider_Interface_Compliance(t *testing.T) {
 	mockSource := newMockCredentialSourceProvider()
-	provider, err := NewCachedProvider(mockSource)
+	provider, err := NewCachedProvider("mock", "", mockSource)
 	if err != nil {
 		t.Fatalf("Failed to create cached provider: %v", err)
 	}
@@ -346,3 +398,51 @@ func TestCachedProvider_Interface_Compliance(t *testing.T) {
 	// Verify that CachedProvider implements ratify.RegistryCredentialGetter interface
 	var _ ratify.RegistryCredentialGetter = provider
 }
+
+func TestCachedProvider_Get_SharedCache(t *testing.T) {
+	shared, err := inmemory.NewCache[ratify.RegistryCredential](0)
+	if err != nil {
+		t.Fatalf("Failed to create shared cache: %v", err)
+	}
+	SetSharedCache(shared)
+	defer SetSharedCache(nil)
+
+	ctx := context.Background()
+	source := newMockCredentialSourceProvider()
+	provider, err := NewCachedProvider("mock", "tenant/client", source)
+	if err != nil {
+		t.Fatalf("Failed to create cached provider: %v", err)
+	}
+	if _, err := provider.Get(ctx, testServerAddress); err != nil {
+		t.Fatalf("Failed to get credential: %v", err)
+	}
+	if _, err := shared.Get(ctx, "mock:tenant/client:"+testServerAddress); err != nil {
+		t.Errorf("Expected credential to be stored in the shared cache: %v", err)
+	}
+
+	// Another provider with the same identity reuses the credential.
+	otherSource := newMockCredentialSourceProvider()
+	other, err := NewCachedProvider("mock", "tenant/client", otherSource)
+	if err != nil {
+		t.Fatalf("Failed to create cached provider: %v", err)
+	}
+	if _, err := other.Get(ctx, testServerAddress); err != nil {
+		t.Fatalf("Failed to get credential: %v", err)
+	}
+	if otherSource.getCallCount(testServerAddress) != 0 {
+		t.Errorf("Expected credential to be served from the shared cache")
+	}
+
+	// A provider with another identity does not.
+	otherSource = newMockCredentialSourceProvider()
+	other, err = NewCachedProvider("mock", "tenant/other", otherSource)
+	if err != nil {
+		t.Fatalf("Failed to create cached provider: %v", err)
+	}
+	if _, err := other.Get(ctx, testServerAddress); err != nil {
+		t.Fatalf("Failed to get credential: %v", err)
+	}
+	if otherSource.getCallCount(testServerAddress) != 1 {
+		t.Errorf("Expected credential of another identity to be fetched")
+	}
+}
@@ -19,8 +19,13 @@ import (
 	"fmt"
 
 	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/metrics"
+	"github.com/notaryproject/ratify/v2/internal/tracing"
 )
 
+// catchAllScope is the scope matching any artifact.
+const catchAllScope = "*"
+
 // NewOptions defines the options for creating a new [ratify.Store].
 type NewOptions struct {
 	// Type represents a specific implementation of a store. Required.
@@ -70,6 +75,14 @@ func New(opts []*NewOptions, globalScopes []string) (ratify.Store, error) {
 			return nil, fmt.Errorf("failed to create store for type %q: %w", storeOptions.Type, err)
 		}
 		for _, scope := range storeOptions.Scopes {
+			if scope == catchAllScope {
+				// the mux does not accept "*" as a pattern, so the store is
+				// used for any artifact not matched by another store.
+				if err = storeMux.RegisterFallback(store); err != nil {
+					return nil, fmt.Errorf("failed to register store for scope %q: %w", scope, err)
+				}
+				continue
+			}
 			if err = storeMux.Register(scope, store); err != nil {
 				return nil, fmt.Errorf("failed to register store for scope %q: %w", scope, err)
 			}
@@ -89,5 +102,9 @@ func newStore(opts *NewOptions) (ratify.Store, error) {
 	if !ok {
 		return nil, fmt.Errorf("store factory of type %s is not registered", opts.Type)
 	}
-	return storeFactory(opts)
+	store, err := storeFactory(opts)
+	if err != nil {
+		return nil, err
+	}
+	return tracing.TraceStore(metrics.InstrumentStore(store, opts.Type), opts.Type), nil
 }
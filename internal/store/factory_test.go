@@ -156,7 +156,7 @@ func TestNew(t *testing.T) {
 			expectedError: false,
 		},
 		{
-			name: "invalid store scope",
+			name: "catch-all store scope",
 			opts: []*NewOptions{
 				{
 					Type:       "mock-store",
@@ -164,6 +164,17 @@ func TestNew(t *testing.T) {
 				},
 			},
 			globalScopes:  []string{"*"},
+			expectedError: false,
+		},
+		{
+			name: "invalid store scope",
+			opts: []*NewOptions{
+				{
+					Type:       "mock-store",
+					Parameters: map[string]any{},
+				},
+			},
+			globalScopes:  []string{"*example.com"},
 			expectedError: true,
 		},
 	}
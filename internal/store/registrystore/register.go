@@ -16,20 +16,54 @@ limitations under the License.
 package registrystore
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sync"
 
+	"github.com/dgraph-io/ristretto/v2"
 	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/errcode"
+	"github.com/notaryproject/ratify/v2/internal/metrics"
 	factory "github.com/notaryproject/ratify/v2/internal/store"
 	"github.com/notaryproject/ratify/v2/internal/store/credentialprovider"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/errdef"
+	orasErrcode "oras.land/oras-go/v2/registry/remote/errcode"
 )
 
 const registryStoreType = "registry-store"
 
+const (
+	// defaultMaxBlobBytes is the default maximum size of a blob, as applied by
+	// [ratify.RegistryStore].
+	defaultMaxBlobBytes = 32 * 1024 * 1024 // 32 MiB
+
+	// blobCacheMaxBytes is the maximum total size of the cached blobs.
+	blobCacheMaxBytes = 64 * 1024 * 1024 // 64 MiB
+)
+
+// blobCache returns the cache of the blobs fetched from registries, keyed by
+// repository and digest. Blobs are content addressed, so cached blobs never
+// go stale. The cache is shared by all registry stores so that it survives
+// reloads of the configuration. It returns nil if the cache cannot be created.
+var blobCache = sync.OnceValue(func() *ristretto.Cache[string, []byte] {
+	cache, err := ristretto.NewCache(&ristretto.Config[string, []byte]{
+		NumCounters: 10000,
+		MaxCost:     blobCacheMaxBytes,
+		BufferItems: 64,
+	})
+	if err != nil {
+		return nil
+	}
+	return cache
+})
+
 type options struct {
 	// PlainHTTP indicates whether to use HTTP instead of HTTPS. Optional.
 	PlainHTTP bool `json:"plainHttp,omitempty"`
@@ -121,8 +155,12 @@ func init() {
 			return nil, fmt.Errorf("failed to create HTTP client: %w", err)
 		}
 
+		// Report the requests sent to the registry.
+		instrumentedClient := *httpClient
+		instrumentedClient.Transport = metrics.InstrumentTransport(httpClient.Transport)
+
 		registryStoreOpts := ratify.RegistryStoreOptions{
-			HTTPClient:         httpClient,
+			HTTPClient:         &instrumentedClient,
 			PlainHTTP:          params.PlainHTTP,
 			UserAgent:          params.UserAgent,
 			MaxBlobBytes:       params.MaxBlobBytes,
@@ -131,6 +169,112 @@ func init() {
 			CredentialProvider: credProvider,
 		}
 
-		return ratify.NewRegistryStore(registryStoreOpts), nil
+		maxBlobBytes := params.MaxBlobBytes
+		if maxBlobBytes <= 0 {
+			maxBlobBytes = defaultMaxBlobBytes
+		}
+		return &registryStore{
+			RegistryStore: ratify.NewRegistryStore(registryStoreOpts),
+			maxBlobBytes:  maxBlobBytes,
+		}, nil
+	})
+}
+
+// registryStore wraps [ratify.RegistryStore] to annotate the authentication and
+// not-found failures of the registry with their error codes and to cache the
+// fetched blobs.
+type registryStore struct {
+	*ratify.RegistryStore
+
+	// maxBlobBytes is the maximum size of a blob, so that blobs exceeding it
+	// are rejected even if cached by another store.
+	maxBlobBytes int64
+}
+
+// Resolve resolves to a descriptor for the given artifact reference.
+func (s *registryStore) Resolve(ctx context.Context, ref string) (ocispec.Descriptor, error) {
+	desc, err := s.RegistryStore.Resolve(ctx, ref)
+	if err != nil {
+		return ocispec.Descriptor{}, annotateError(err)
+	}
+	return desc, nil
+}
+
+// ListReferrers returns the immediate set of supply chain artifacts for the
+// given subject reference. Errors returned by fn are passed through as is.
+func (s *registryStore) ListReferrers(ctx context.Context, ref string, artifactTypes []string, fn func(referrers []ocispec.Descriptor) error) error {
+	var fnErr error
+	err := s.RegistryStore.ListReferrers(ctx, ref, artifactTypes, func(referrers []ocispec.Descriptor) error {
+		fnErr = fn(referrers)
+		return fnErr
 	})
+	if err != nil && fnErr == nil {
+		return annotateError(err)
+	}
+	return err
+}
+
+// FetchBlob returns the blob by the given reference. Blobs are served from the
+// blob cache if they have been fetched before.
+func (s *registryStore) FetchBlob(ctx context.Context, repo string, desc ocispec.Descriptor) ([]byte, error) {
+	cache := blobCache()
+	key := repo + "@" + desc.Digest.String()
+	if cache != nil && desc.Size <= s.maxBlobBytes {
+		blob, hit := cache.Get(key)
+		metrics.ReportBlobCacheCount(ctx, hit)
+		if hit {
+			return blob, nil
+		}
+	}
+	blob, err := s.RegistryStore.FetchBlob(ctx, repo, desc)
+	if err != nil {
+		return nil, annotateError(err)
+	}
+	if cache != nil {
+		cache.Set(key, blob, int64(len(blob)))
+	}
+	return blob, nil
+}
+
+// FetchManifest returns the referenced manifest as given by the descriptor.
+func (s *registryStore) FetchManifest(ctx context.Context, repo string, desc ocispec.Descriptor) ([]byte, error) {
+	manifest, err := s.RegistryStore.FetchManifest(ctx, repo, desc)
+	if err != nil {
+		return nil, annotateError(err)
+	}
+	return manifest, nil
+}
+
+// annotateError annotates err with [errcode.RegistryAuthFailed] if the
+// registry rejected the request as unauthenticated or unauthorized, and with
+// [errcode.SubjectNotFound] if the requested content does not exist. Errors
+// already annotated with a code and all other errors are returned as is.
+func annotateError(err error) error {
+	var codedErr *errcode.Error
+	if errors.As(err, &codedErr) {
+		return err
+	}
+	var respErr *orasErrcode.ErrorResponse
+	if errors.As(err, &respErr) {
+		switch respErr.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return errcode.RegistryAuthFailed.Wrap(err)
+		case http.StatusNotFound:
+			return errcode.SubjectNotFound.Wrap(err)
+		}
+		// Some registries report the failure with an unexpected status code,
+		// so fall back to the error codes of the response.
+		for _, e := range respErr.Errors {
+			switch e.Code {
+			case orasErrcode.ErrorCodeUnauthorized, orasErrcode.ErrorCodeDenied:
+				return errcode.RegistryAuthFailed.Wrap(err)
+			case orasErrcode.ErrorCodeNameUnknown, orasErrcode.ErrorCodeManifestUnknown, orasErrcode.ErrorCodeBlobUnknown:
+				return errcode.SubjectNotFound.Wrap(err)
+			}
+		}
+	}
+	if errors.Is(err, errdef.ErrNotFound) {
+		return errcode.SubjectNotFound.Wrap(err)
+	}
+	return err
 }
@@ -16,6 +16,7 @@ limitations under the License.
 package registrystore
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -23,14 +24,23 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
+	"errors"
+	"fmt"
 	"math/big"
 	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/errcode"
 	"github.com/notaryproject/ratify/v2/internal/store"
 	_ "github.com/notaryproject/ratify/v2/internal/store/credentialprovider/static" // Register the static credential provider factory
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/errdef"
 )
 
 // generateTestCertificate creates a test certificate for testing purposes
@@ -816,3 +826,182 @@ func TestOptionsJSONUnmarshal(t *testing.T) {
 		})
 	}
 }
+
+func TestRegistryStoreErrorCodes(t *testing.T) {
+	const referrersIndex = `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha256:0000000000000000000000000000000000000000000000000000000000000000","size":1}]}`
+	subjectDigest := "sha256:" + strings.Repeat("a", 64)
+	errFn := errors.New("referrer rejected")
+
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		listFn       func([]ocispec.Descriptor) error
+		expectedCode errcode.Code
+		expectCoded  bool
+		expectedErr  error
+	}{
+		{
+			name:         "unauthorized",
+			status:       http.StatusUnauthorized,
+			expectedCode: errcode.RegistryAuthFailed,
+			expectCoded:  true,
+		},
+		{
+			name:         "forbidden",
+			status:       http.StatusForbidden,
+			expectedCode: errcode.RegistryAuthFailed,
+			expectCoded:  true,
+		},
+		{
+			name:         "denied with unexpected status",
+			status:       http.StatusBadRequest,
+			body:         `{"errors":[{"code":"DENIED","message":"access denied"}]}`,
+			expectedCode: errcode.RegistryAuthFailed,
+			expectCoded:  true,
+		},
+		{
+			name:         "not found",
+			status:       http.StatusNotFound,
+			expectedCode: errcode.SubjectNotFound,
+			expectCoded:  true,
+		},
+		{
+			name:         "name unknown with unexpected status",
+			status:       http.StatusBadRequest,
+			body:         `{"errors":[{"code":"NAME_UNKNOWN","message":"repository not found"}]}`,
+			expectedCode: errcode.SubjectNotFound,
+			expectCoded:  true,
+		},
+		{
+			name:         "server error left to classification",
+			status:       http.StatusInternalServerError,
+			expectedCode: errcode.RegistryUnavailable,
+		},
+		{
+			name:   "referrer callback error passed through",
+			status: http.StatusOK,
+			body:   referrersIndex,
+			listFn: func([]ocispec.Descriptor) error {
+				return fmt.Errorf("%w: %w", errFn, errdef.ErrNotFound)
+			},
+			expectedCode: errcode.SubjectNotFound,
+			expectedErr:  errFn,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if tt.body != "" {
+					w.Header().Set("Content-Type", "application/vnd.oci.image.index.v1+json")
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			s, err := store.New([]*store.NewOptions{{
+				Type:   registryStoreType,
+				Scopes: []string{"*"},
+				Parameters: map[string]any{
+					"plainHttp": true,
+					"credential": map[string]any{
+						"provider": "static",
+					},
+				},
+			}}, nil)
+			if err != nil {
+				t.Fatalf("failed to create store: %v", err)
+			}
+			subject := strings.TrimPrefix(server.URL, "http://") + "/test/image@" + subjectDigest
+
+			ctx := context.Background()
+			if tt.listFn != nil {
+				err = s.ListReferrers(ctx, subject, nil, tt.listFn)
+			} else {
+				_, err = s.FetchManifest(ctx, strings.TrimSuffix(subject, "@"+subjectDigest), ocispec.Descriptor{
+					MediaType: ocispec.MediaTypeImageManifest,
+					Digest:    digest.Digest(subjectDigest),
+					Size:      1,
+				})
+			}
+			if err == nil {
+				t.Fatal("expected error but got none")
+			}
+			if code := errcode.Classify(err); code != tt.expectedCode {
+				t.Errorf("expected code %s, got %s: %v", tt.expectedCode, code, err)
+			}
+			var codedErr *errcode.Error
+			if coded := errors.As(err, &codedErr); coded != tt.expectCoded {
+				t.Errorf("expected coded error %t, got %t: %v", tt.expectCoded, coded, err)
+			}
+			if tt.expectedErr != nil && !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestRegistryStoreBlobCache(t *testing.T) {
+	blob := []byte("signature envelope")
+	desc := ocispec.Descriptor{
+		MediaType: "application/jose+json",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write(blob)
+	}))
+	defer server.Close()
+
+	newStore := func(maxBlobBytes int) ratify.Store {
+		t.Helper()
+		s, err := store.New([]*store.NewOptions{{
+			Type:   registryStoreType,
+			Scopes: []string{"*"},
+			Parameters: map[string]any{
+				"plainHttp":    true,
+				"maxBlobBytes": maxBlobBytes,
+				"credential": map[string]any{
+					"provider": "static",
+				},
+			},
+		}}, nil)
+		if err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+		return s
+	}
+	repo := strings.TrimPrefix(server.URL, "http://") + "/test/image"
+	ctx := context.Background()
+
+	s := newStore(0)
+	for range 2 {
+		fetched, err := s.FetchBlob(ctx, repo, desc)
+		if err != nil {
+			t.Fatalf("failed to fetch blob: %v", err)
+		}
+		if string(fetched) != string(blob) {
+			t.Fatalf("expected blob %q, got %q", blob, fetched)
+		}
+		blobCache().Wait()
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("expected the blob to be fetched once, got %d requests", got)
+	}
+
+	// Stores are recreated on reload and share the cached blobs, but not
+	// beyond their size limit.
+	if _, err := newStore(0).FetchBlob(ctx, repo, desc); err != nil {
+		t.Fatalf("failed to fetch blob: %v", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("expected the blob to be served from the cache, got %d requests", got)
+	}
+	if _, err := newStore(1).FetchBlob(ctx, repo, desc); err == nil {
+		t.Error("expected the size limit to be enforced for cached blobs")
+	}
+}
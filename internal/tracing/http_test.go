@@ -0,0 +1,68 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const (
+	testTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testTraceparent = "00-" + testTraceID + "-00f067aa0ba902b7-01"
+)
+
+func TestMiddleware(t *testing.T) {
+	recorder := setupRecorder(t)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var logs bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&logs)
+	logger.AddHook(LogHook{})
+
+	handler := Middleware("verify", http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		logger.WithContext(r.Context()).Info("handling request")
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/ratify/gatekeeper/v2/verify", nil)
+	req.Header.Set("traceparent", testTraceparent)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Name() != "verify" {
+		t.Errorf("expected span name verify, got %s", spans[0].Name())
+	}
+	if got := spans[0].SpanContext().TraceID().String(); got != testTraceID {
+		t.Errorf("expected trace ID %s from traceparent, got %s", testTraceID, got)
+	}
+	if got := w.Header().Get("traceparent"); !strings.Contains(got, testTraceID) {
+		t.Errorf("expected traceparent response header to contain %s, got %q", testTraceID, got)
+	}
+	if !strings.Contains(logs.String(), logFieldTraceID+"="+testTraceID) {
+		t.Errorf("expected log to contain the trace ID, got %s", logs.String())
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+
+	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/logger"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	storeLogOpt = logger.Option{
+		ComponentType: logger.ReferrerStore,
+	}
+	verifierLogOpt = logger.Option{
+		ComponentType: logger.Verifier,
+	}
+)
+
+// tracedStore starts a span for each call to the underlying store and logs
+// its outcome with the trace ID of the request.
+type tracedStore struct {
+	store     ratify.Store
+	storeType string
+}
+
+// TraceStore wraps the store to start a span for each of its operations.
+func TraceStore(store ratify.Store, storeType string) ratify.Store {
+	if store == nil {
+		return nil
+	}
+	return &tracedStore{
+		store:     store,
+		storeType: storeType,
+	}
+}
+
+func (s *tracedStore) startSpan(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, func(error)) {
+	attrs = append(attrs, attribute.String("ratify.store.type", s.storeType))
+	ctx, span := StartSpan(ctx, "store."+operation, attrs...)
+	return ctx, func(err error) {
+		EndSpan(span, err)
+		if err != nil {
+			logger.GetLogger(ctx, storeLogOpt).Debugf("%s store %s failed: %v", s.storeType, operation, err)
+		}
+	}
+}
+
+// Resolve implements [ratify.Store].
+func (s *tracedStore) Resolve(ctx context.Context, ref string) (ocispec.Descriptor, error) {
+	ctx, end := s.startSpan(ctx, "Resolve", attribute.String("ratify.reference", ref))
+	desc, err := s.store.Resolve(ctx, ref)
+	end(err)
+	return desc, err
+}
+
+// ListReferrers implements [ratify.Store].
+func (s *tracedStore) ListReferrers(ctx context.Context, ref string, artifactTypes []string, fn func(referrers []ocispec.Descriptor) error) error {
+	ctx, end := s.startSpan(ctx, "ListReferrers", attribute.String("ratify.reference", ref), attribute.StringSlice("ratify.artifact_types", artifactTypes))
+	err := s.store.ListReferrers(ctx, ref, artifactTypes, fn)
+	end(err)
+	return err
+}
+
+// FetchBlob implements [ratify.Store].
+func (s *tracedStore) FetchBlob(ctx context.Context, repo string, desc ocispec.Descriptor) ([]byte, error) {
+	ctx, end := s.startSpan(ctx, "FetchBlob", attribute.String("ratify.repository", repo), attribute.String("ratify.digest", desc.Digest.String()))
+	blob, err := s.store.FetchBlob(ctx, repo, desc)
+	end(err)
+	return blob, err
+}
+
+// FetchManifest implements [ratify.Store].
+func (s *tracedStore) FetchManifest(ctx context.Context, repo string, desc ocispec.Descriptor) ([]byte, error) {
+	ctx, end := s.startSpan(ctx, "FetchManifest", attribute.String("ratify.repository", repo), attribute.String("ratify.digest", desc.Digest.String()))
+	manifest, err := s.store.FetchManifest(ctx, repo, desc)
+	end(err)
+	return manifest, err
+}
+
+// tracedVerifier starts a span for each verification of the underlying
+// verifier and logs its outcome with the trace ID of the request.
+type tracedVerifier struct {
+	ratify.Verifier
+}
+
+// TraceVerifier wraps the verifier to start a span for each verification.
+func TraceVerifier(verifier ratify.Verifier) ratify.Verifier {
+	if verifier == nil {
+		return nil
+	}
+	return &tracedVerifier{Verifier: verifier}
+}
+
+// Verify implements [ratify.Verifier].
+func (v *tracedVerifier) Verify(ctx context.Context, opts *ratify.VerifyOptions) (*ratify.VerificationResult, error) {
+	attrs := []attribute.KeyValue{
+		attribute.String("ratify.verifier.name", v.Name()),
+		attribute.String("ratify.verifier.type", v.Type()),
+	}
+	// Leave nil options to the wrapped verifier.
+	var artifactDigest digest.Digest
+	if opts != nil {
+		artifactDigest = opts.ArtifactDescriptor.Digest
+		attrs = append(attrs,
+			attribute.String("ratify.repository", opts.Repository),
+			attribute.String("ratify.subject.digest", opts.SubjectDescriptor.Digest.String()),
+			attribute.String("ratify.artifact.digest", artifactDigest.String()),
+		)
+	}
+	ctx, span := StartSpan(ctx, "verifier.Verify", attrs...)
+	result, err := v.Verifier.Verify(ctx, opts)
+	if err == nil && result != nil {
+		span.SetAttributes(attribute.Bool("ratify.verifier.success", result.Err == nil))
+	}
+	EndSpan(span, err)
+
+	log := logger.GetLogger(ctx, verifierLogOpt)
+	switch {
+	case err != nil:
+		log.Debugf("verifier %s failed to verify artifact %s: %v", v.Name(), artifactDigest, err)
+	case result != nil && result.Err != nil:
+		log.Debugf("verifier %s rejected artifact %s: %v", v.Name(), artifactDigest, result.Err)
+	default:
+		log.Debugf("verifier %s verified artifact %s", v.Name(), artifactDigest)
+	}
+	return result, err
+}
@@ -0,0 +1,120 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/notaryproject/ratify-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"go.opentelemetry.io/otel/codes"
+)
+
+type mockStore struct {
+	err error
+}
+
+func (m *mockStore) Resolve(_ context.Context, _ string) (ocispec.Descriptor, error) {
+	return ocispec.Descriptor{}, m.err
+}
+
+func (m *mockStore) ListReferrers(_ context.Context, _ string, _ []string, _ func(referrers []ocispec.Descriptor) error) error {
+	return m.err
+}
+
+func (m *mockStore) FetchBlob(_ context.Context, _ string, _ ocispec.Descriptor) ([]byte, error) {
+	return nil, m.err
+}
+
+func (m *mockStore) FetchManifest(_ context.Context, _ string, _ ocispec.Descriptor) ([]byte, error) {
+	return nil, m.err
+}
+
+type mockVerifier struct{}
+
+func (m *mockVerifier) Name() string {
+	return "mock-verifier"
+}
+
+func (m *mockVerifier) Type() string {
+	return "mock-type"
+}
+
+func (m *mockVerifier) Verifiable(_ ocispec.Descriptor) bool {
+	return true
+}
+
+func (m *mockVerifier) Verify(_ context.Context, _ *ratify.VerifyOptions) (*ratify.VerificationResult, error) {
+	return &ratify.VerificationResult{}, nil
+}
+
+func TestTraceStore(t *testing.T) {
+	if TraceStore(nil, "mock") != nil {
+		t.Fatalf("expected nil store to stay nil")
+	}
+
+	recorder := setupRecorder(t)
+	ctx, parent := StartSpan(context.Background(), "parent")
+	store := TraceStore(&mockStore{err: errors.New("not found")}, "mock-store")
+	if _, err := store.Resolve(ctx, "registry.example.com/test:v1"); err == nil {
+		t.Fatalf("expected error from resolve")
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "store.Resolve" {
+		t.Errorf("expected span name store.Resolve, got %s", span.Name())
+	}
+	if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("expected store span to be a child of the parent span")
+	}
+	if span.Status().Code != codes.Error {
+		t.Errorf("expected error status, got %v", span.Status().Code)
+	}
+}
+
+func TestTraceVerifier(t *testing.T) {
+	if TraceVerifier(nil) != nil {
+		t.Fatalf("expected nil verifier to stay nil")
+	}
+
+	recorder := setupRecorder(t)
+	verifier := TraceVerifier(&mockVerifier{})
+	if verifier.Name() != "mock-verifier" || verifier.Type() != "mock-type" {
+		t.Fatalf("expected name and type to be passed through")
+	}
+	if _, err := verifier.Verify(context.Background(), &ratify.VerifyOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Nil options are passed to the wrapped verifier without a panic.
+	if _, err := verifier.Verify(context.Background(), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 || spans[0].Name() != "verifier.Verify" {
+		t.Fatalf("expected a verifier.Verify span, got %v", spans)
+	}
+	if spans[0].Status().Code == codes.Error {
+		t.Errorf("expected successful span status")
+	}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	logFieldTraceID = "otel_trace_id"
+	logFieldSpanID  = "otel_span_id"
+)
+
+// LogHook is a logrus hook that adds the OpenTelemetry trace and span IDs to
+// log entries created with a context, e.g. logrus.WithContext(ctx), so that
+// log lines can be correlated with traces.
+type LogHook struct{}
+
+// Levels implements [logrus.Hook].
+func (LogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements [logrus.Hook].
+func (LogHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	spanContext := trace.SpanContextFromContext(entry.Context)
+	if !spanContext.IsValid() {
+		return nil
+	}
+	entry.Data[logFieldTraceID] = spanContext.TraceID().String()
+	entry.Data[logFieldSpanID] = spanContext.SpanID().String()
+	return nil
+}
@@ -0,0 +1,179 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	scope       = "github.com/notaryproject/ratify/v2"
+	serviceName = "ratify-gatekeeper-provider"
+
+	// ExporterOTLP exports spans to an OpenTelemetry collector over OTLP/HTTP.
+	ExporterOTLP = "otlp"
+
+	// ExporterFile writes spans as JSON lines to a file, which is useful for
+	// testing without a collector.
+	ExporterFile = "file"
+)
+
+var addLogHook sync.Once
+
+// Options defines the options to export traces.
+type Options struct {
+	// Exporter is the type of the span exporter, either "otlp" or "file".
+	// Tracing is disabled if not provided.
+	// Optional.
+	Exporter string
+
+	// Endpoint is the host and port of the OTLP/HTTP collector, e.g.
+	// "localhost:4318". Defaults to the OTEL_EXPORTER_OTLP_ENDPOINT
+	// environment variable or "localhost:4318" if not provided.
+	// Optional.
+	Endpoint string
+
+	// Insecure disables TLS when exporting to the OTLP collector.
+	// Optional.
+	Insecure bool
+
+	// FilePath is the path of the file spans are written to by the file
+	// exporter. Required if Exporter is "file".
+	FilePath string
+
+	// SampleRatio is the ratio of new traces to sample, between 0 and 1.
+	// Traces started by an incoming traceparent header follow the sampling
+	// decision of the caller.
+	// Optional.
+	SampleRatio float64
+}
+
+// Init sets up the global tracer provider and the W3C trace context
+// propagator according to opts. The returned function flushes pending spans
+// and releases the exporter. If tracing is disabled, the trace context is still
+// propagated so that log lines can be correlated with the caller's trace.
+func Init(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	addLogHook.Do(func() {
+		logrus.AddHook(LogHook{})
+	})
+	if opts.Exporter == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	if opts.SampleRatio < 0 || opts.SampleRatio > 1 {
+		return nil, fmt.Errorf("invalid sample ratio %v: must be between 0 and 1", opts.SampleRatio)
+	}
+
+	exporter, closer, err := newExporter(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	logrus.Infof("exporting traces with %s exporter", opts.Exporter)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			err = errors.Join(err, closer.Close())
+		}
+		return err
+	}, nil
+}
+
+// newExporter creates the span exporter configured by opts. The returned
+// closer, if any, must be closed after the exporter is shut down.
+func newExporter(ctx context.Context, opts Options) (sdktrace.SpanExporter, io.Closer, error) {
+	switch opts.Exporter {
+	case ExporterOTLP:
+		var clientOpts []otlptracehttp.Option
+		if opts.Endpoint != "" {
+			clientOpts = append(clientOpts, otlptracehttp.WithEndpoint(opts.Endpoint))
+		}
+		if opts.Insecure {
+			clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(ctx, clientOpts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+		}
+		return exporter, nil, nil
+	case ExporterFile:
+		if opts.FilePath == "" {
+			return nil, nil, errors.New("file path is required for the file trace exporter")
+		}
+		file, err := os.OpenFile(opts.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, nil, fmt.Errorf("failed to create file trace exporter: %w", err)
+		}
+		return exporter, file, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported trace exporter %q", opts.Exporter)
+	}
+}
+
+// StartSpan starts a span as a child of the span in ctx.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(scope).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records err on the span, if any, and ends the span.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceID returns the trace ID of the span in ctx. It returns an empty string
+// if ctx does not carry a valid span context.
+func TraceID(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
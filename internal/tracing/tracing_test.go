@@ -0,0 +1,128 @@
+/*
+Copyright The Ratify Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// setupRecorder installs a tracer provider recording spans in memory.
+func setupRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+	})
+	return recorder
+}
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      Options
+		expectErr bool
+	}{
+		{
+			name: "tracing disabled",
+			opts: Options{},
+		},
+		{
+			name:      "unsupported exporter",
+			opts:      Options{Exporter: "unknown", SampleRatio: 1},
+			expectErr: true,
+		},
+		{
+			name:      "invalid sample ratio",
+			opts:      Options{Exporter: ExporterFile, FilePath: "traces.json", SampleRatio: 2},
+			expectErr: true,
+		},
+		{
+			name:      "file exporter without path",
+			opts:      Options{Exporter: ExporterFile, SampleRatio: 1},
+			expectErr: true,
+		},
+		{
+			name: "otlp exporter",
+			opts: Options{Exporter: ExporterOTLP, Endpoint: "localhost:4318", Insecure: true, SampleRatio: 1},
+		},
+	}
+
+	previous := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(previous)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shutdown, err := Init(context.Background(), tt.opts)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if err == nil {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				_ = shutdown(ctx)
+			}
+		})
+	}
+}
+
+func TestInit_FileExporter(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(previous)
+
+	path := filepath.Join(t.TempDir(), "traces.json")
+	shutdown, err := Init(context.Background(), Options{Exporter: ExporterFile, FilePath: path, SampleRatio: 1})
+	if err != nil {
+		t.Fatalf("failed to initialize tracing: %v", err)
+	}
+	_, span := StartSpan(context.Background(), "test-span")
+	EndSpan(span, errors.New("test error"))
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("failed to shutdown tracing: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read trace file: %v", err)
+	}
+	for _, want := range []string{`"Name":"test-span"`, "test error"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("expected trace file to contain %q, got %s", want, content)
+		}
+	}
+}
+
+func TestTraceID(t *testing.T) {
+	if id := TraceID(context.Background()); id != "" {
+		t.Errorf("expected empty trace ID, got %q", id)
+	}
+
+	setupRecorder(t)
+	ctx, span := StartSpan(context.Background(), "test-span")
+	defer span.End()
+	if id := TraceID(ctx); id != span.SpanContext().TraceID().String() {
+		t.Errorf("expected trace ID %q, got %q", span.SpanContext().TraceID(), id)
+	}
+}
@@ -33,6 +33,7 @@ import (
 const (
 	verifierTypeCosign = "cosign"
 	artifactTypeCosign = "application/vnd.dev.cosign.artifact.sig.v1+json"
+	catchAllScope      = "*"
 )
 
 // Verifier implements the [ratify.Verifier] interface for Cosign signatures
@@ -46,16 +47,21 @@ const (
 //   - Repository paths: "registry.example.com/namespace/repo" matches a
 //     specific repository
 //
+// The catch-all scope "*" matches any repository not matched by another
+// scope.
+//
 // Scope matching follows a precedence order from most specific to least
 // specific:
 //  1. Exact repository match
 //  2. Exact registry match
 //  3. Wildcard registry match
+//  4. Catch-all match
 type Verifier struct {
 	name       string
 	wildcard   map[string]*cosign.Verifier
 	registry   map[string]*cosign.Verifier
 	repository map[string]*cosign.Verifier
+	catchAll   *cosign.Verifier
 }
 
 // ScopedOptions defines the configuration options for a scoped
@@ -214,6 +220,10 @@ func (v *Verifier) matchVerifier(repository string) (*cosign.Verifier, error) {
 		}
 	}
 
+	if v.catchAll != nil {
+		return v.catchAll, nil
+	}
+
 	return nil, fmt.Errorf("no verifier configured for the repository %q", repository)
 }
 
@@ -226,6 +236,13 @@ func (v *Verifier) registerVerifier(scope string, verifier *cosign.Verifier) err
 		return fmt.Errorf("verifier cannot be nil")
 	}
 
+	if scope == catchAllScope {
+		if v.catchAll != nil {
+			return fmt.Errorf("duplicate catch-all scope %q detected", scope)
+		}
+		v.catchAll = verifier
+		return nil
+	}
 	if strings.Contains(scope, "/") {
 		return v.registerRepository(scope, verifier)
 	}
@@ -592,6 +592,19 @@ func TestRegisterVerifier(t *testing.T) {
 			cosignVerifier: mockCosignVerifier,
 			wantErr:        false,
 		},
+		{
+			name:           "valid catch-all scope",
+			scope:          "*",
+			cosignVerifier: mockCosignVerifier,
+			wantErr:        false,
+		},
+		{
+			name:           "duplicate catch-all scope",
+			scope:          "*",
+			cosignVerifier: mockCosignVerifier,
+			wantErr:        true,
+			errContains:    "duplicate catch-all scope",
+		},
 	}
 
 	for _, tt := range tests {
@@ -614,6 +627,10 @@ func TestRegisterVerifier(t *testing.T) {
 			}
 		})
 	}
+
+	if matched, err := verifier.matchVerifier("other.registry.com/repo"); err != nil || matched != mockCosignVerifier {
+		t.Errorf("matchVerifier() expected catch-all verifier, got %v, %v", matched, err)
+	}
 }
 
 func TestToVerifierOptions(t *testing.T) {
@@ -19,6 +19,8 @@ import (
 	"fmt"
 
 	"github.com/notaryproject/ratify-go"
+	"github.com/notaryproject/ratify/v2/internal/metrics"
+	"github.com/notaryproject/ratify/v2/internal/tracing"
 )
 
 // NewOptions holds the options to create a [ratify.Verifier].
@@ -64,7 +66,11 @@ func New(opts *NewOptions, globalScopes []string) (ratify.Verifier, error) {
 	if !ok {
 		return nil, fmt.Errorf("verifier factory of type %s is not registered", opts.Type)
 	}
-	return create(opts, globalScopes)
+	verifier, err := create(opts, globalScopes)
+	if err != nil {
+		return nil, err
+	}
+	return tracing.TraceVerifier(metrics.InstrumentVerifier(verifier)), nil
 }
 
 // NewVerifiers creates a slice of [ratify.Verifier] instances based on the
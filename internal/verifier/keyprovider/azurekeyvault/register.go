@@ -23,11 +23,13 @@ import (
 	"encoding/pem"
 	"fmt"
 	"sync"
+	"time"
 
 	"golang.org/x/crypto/pkcs12"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
 	"github.com/notaryproject/ratify/v2/internal/cloudprovider/azure"
+	"github.com/notaryproject/ratify/v2/internal/metrics"
 	"github.com/notaryproject/ratify/v2/internal/verifier/keyprovider"
 	"github.com/sirupsen/logrus"
 )
@@ -173,7 +175,9 @@ func (p *Provider) fetchCertificateChain(ctx context.Context, certSpec Certifica
 // fetchChainFromSecrets attempts to fetch a full certificate chain stored as a
 // secret
 func (p *Provider) fetchChainFromSecrets(ctx context.Context, certSpec CertificateSpec) ([]*x509.Certificate, error) {
+	start := time.Now()
 	resp, err := p.secretsClient.GetSecret(ctx, certSpec.Name, certSpec.Version, nil)
+	metrics.ReportAKVCertificateDuration(ctx, time.Since(start), certSpec.Name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get certificates %q of version %q: %w", certSpec.Name, certSpec.Version, err)
 	}